@@ -13,6 +13,7 @@ import (
 	"github.com/tohjustin/kube-lineage/internal/version"
 	"github.com/tohjustin/kube-lineage/pkg/cmd/helm"
 	"github.com/tohjustin/kube-lineage/pkg/cmd/lineage"
+	"github.com/tohjustin/kube-lineage/pkg/cmd/why"
 )
 
 var rootCmdName = "kube-lineage"
@@ -28,6 +29,7 @@ func init() {
 func NewCmd(streams genericclioptions.IOStreams) *cobra.Command {
 	cmd := lineage.NewCmd(streams, rootCmdName, "")
 	cmd.AddCommand(helm.NewCmd(streams, "", rootCmdName))
+	cmd.AddCommand(why.NewCmd(streams, "", rootCmdName))
 	cmd.SetVersionTemplate("{{printf \"%s\" .Version}}\n")
 	cmd.Version = fmt.Sprintf("%#v", version.Get())
 	return cmd
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/client"
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+// MaxDepth returns the maximum depth of the relationship tree set by the
+// provided value of the "--depth" flag, which applies no limit if negative.
+func MaxDepth(depth int) uint {
+	if depth < 0 {
+		return graph.UnlimitedDepth
+	}
+	return uint(depth)
+}
+
+// WithTooManyNodesHint returns the provided error along with a hint on how to
+// shrink the relationship tree if it wraps graph.ErrTooManyNodes, otherwise it
+// returns the provided error as is.
+func WithTooManyNodesHint(err error) error {
+	if !errors.Is(err, graph.ErrTooManyNodes) {
+		return err
+	}
+	return fmt.Errorf("%w\nNarrow down the query with --depth, --exclude-types or --include-types, or raise the limit with --max-nodes", err)
+}
+
+// PrintForbiddenWarning prints a warning listing the provided resource types
+// that couldn't be listed due to missing permissions along with the provided
+// impact on the output (eg. "the relationship tree may be incomplete"), if any.
+func PrintForbiddenWarning(w io.Writer, forbidden map[string]struct{}, impact string) {
+	if len(forbidden) == 0 {
+		return
+	}
+	resources := make([]string, 0, len(forbidden))
+	for resource := range forbidden {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	fmt.Fprintf(w, "Warning: skipped %d resource type(s) due to RBAC, %s: %s\n", len(resources), impact, strings.Join(resources, ", "))
+}
+
+// ResolveGroupKindSet resolves the provided resource types into a set of
+// GroupKinds with the provided client.
+func ResolveGroupKindSet(c client.Interface, kinds []string) (map[schema.GroupKind]struct{}, error) {
+	apis := []client.APIResource{}
+	for _, kind := range kinds {
+		api, err := c.ResolveAPIResource(kind)
+		if err != nil {
+			return nil, err
+		}
+		apis = append(apis, *api)
+	}
+	return client.ResourcesToGroupKindSet(apis), nil
+}
+
+// WriteSnapshot writes a snapshot of the provided relationship tree to the
+// provided file.
+func WriteSnapshot(path string, nodeMap graph.NodeMap, rootUIDs []types.UID, depsIsDependencies bool) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := nodeMap.WriteSnapshot(f, rootUIDs, depsIsDependencies); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
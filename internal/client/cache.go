@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// informerCache keeps the objects listed from the server in informer caches,
+// which are kept up to date by watches so that subsequent lists of the same
+// API & namespace are served locally instead of by the server.
+type informerCache struct {
+	dynamicClient dynamic.Interface
+	stopCh        <-chan struct{}
+
+	mu        sync.Mutex
+	informers map[informerKey]informers.GenericInformer
+}
+
+type informerKey struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+func newInformerCache(dynamicClient dynamic.Interface, stopCh <-chan struct{}) *informerCache {
+	return &informerCache{
+		dynamicClient: dynamicClient,
+		stopCh:        stopCh,
+		informers:     map[informerKey]informers.GenericInformer{},
+	}
+}
+
+// list returns all objects of the provided API & namespace from the informer
+// cache, waiting for the informer to sync if needed. If there's no informer
+// yet, the objects are listed from the server with the provided listFn & an
+// informer is started for subsequent lists. Informers are only started once
+// listing succeeds, so that no informer keeps retrying to list objects that
+// the user isn't allowed to list.
+//
+// As a trade-off, the first list of each API & namespace hits the server twice
+// since the informer lists the same objects again when it starts, which only
+// pays off when the relationship tree is rebuilt (eg. with --watch).
+func (c *informerCache) list(ctx context.Context, api APIResource, ns string, listFn func() (*unstructuredv1.UnstructuredList, error)) (*unstructuredv1.UnstructuredList, error) {
+	key := informerKey{gvr: api.GroupVersionResource(), namespace: ns}
+	c.mu.Lock()
+	informer, ok := c.informers[key]
+	c.mu.Unlock()
+	if !ok {
+		objs, err := listFn()
+		if err != nil {
+			return nil, err
+		}
+		c.start(key)
+		return objs, nil
+	}
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.Informer().HasSynced) {
+		return nil, ctx.Err()
+	}
+	objs, err := informer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	// Objects in the cache are shared, so return copies of them
+	items := make([]unstructuredv1.Unstructured, 0, len(objs))
+	for _, obj := range objs {
+		if u, ok := obj.(*unstructuredv1.Unstructured); ok {
+			items = append(items, *u.DeepCopy())
+		}
+	}
+	if len(ns) == 0 {
+		klog.V(4).Infof("Got %4d cached objects from resource at the cluster scope: %s", len(items), api)
+	} else {
+		klog.V(4).Infof("Got %4d cached objects from resource in the namespace \"%s\": %s", len(items), ns, api)
+	}
+	return &unstructuredv1.UnstructuredList{Items: items}, nil
+}
+
+// start starts an informer for the provided API & namespace, unless one was
+// already started. The informer runs until the cache's stop channel is closed.
+func (c *informerCache) start(key informerKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.informers[key]; ok {
+		return
+	}
+	informer := dynamicinformer.NewFilteredDynamicInformer(c.dynamicClient, key.gvr, key.namespace, 0, cache.Indexers{}, nil)
+	c.informers[key] = informer
+	go informer.Informer().Run(c.stopCh)
+}
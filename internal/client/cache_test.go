@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func TestInformerCacheList(t *testing.T) {
+	t.Parallel()
+
+	newPod := func(name string) *unstructuredv1.Unstructured {
+		u := &unstructuredv1.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("Pod")
+		u.SetNamespace("default")
+		u.SetName(name)
+		return u
+	}
+	api := APIResource{Version: "v1", Name: "pods", Namespaced: true, Kind: "Pod"}
+	dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		api.GroupVersionResource(): "PodList",
+	}, newPod("cached"))
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	c := newInformerCache(dyn, stopCh)
+
+	ctx := context.Background()
+	listErr := errors.New("list failed")
+	calls := 0
+	listFn := func(err error) func() (*unstructuredv1.UnstructuredList, error) {
+		return func() (*unstructuredv1.UnstructuredList, error) {
+			calls++
+			if err != nil {
+				return nil, err
+			}
+			return &unstructuredv1.UnstructuredList{Items: []unstructuredv1.Unstructured{*newPod("listed")}}, nil
+		}
+	}
+	listNames := func(objs *unstructuredv1.UnstructuredList) []string {
+		names := make([]string, 0, len(objs.Items))
+		for _, u := range objs.Items {
+			names = append(names, u.GetName())
+		}
+		return names
+	}
+
+	// No informer is started if listing fails on a cache miss
+	if _, err := c.list(ctx, api, "default", listFn(listErr)); !errors.Is(err, listErr) {
+		t.Fatalf("expected \"%v\" got \"%v\"", listErr, err)
+	}
+	if len(c.informers) != 0 {
+		t.Fatalf("expected no informers got %d", len(c.informers))
+	}
+
+	// Cache misses are listed with listFn & start an informer
+	objs, err := c.list(ctx, api, "default", listFn(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := listNames(objs); len(names) != 1 || names[0] != "listed" {
+		t.Fatalf("expected objects from listFn got \"%v\"", names)
+	}
+	if len(c.informers) != 1 {
+		t.Fatalf("expected 1 informer got %d", len(c.informers))
+	}
+
+	// Cache hits are served by the informer without calling listFn
+	objs, err = c.list(ctx, api, "default", listFn(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := listNames(objs); len(names) != 1 || names[0] != "cached" {
+		t.Fatalf("expected objects from the informer got \"%v\"", names)
+	}
+	if calls != 2 {
+		t.Fatalf("expected listFn to be called 2 times got %d", calls)
+	}
+
+	// Other namespaces are cache misses
+	if _, err := c.list(ctx, api, "team-a", listFn(nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 || len(c.informers) != 2 {
+		t.Fatalf("expected listFn to be called 3 times with 2 informers got %d times with %d informers", calls, len(c.informers))
+	}
+}
@@ -17,9 +17,12 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/dynamic"
 	_ "k8s.io/client-go/plugin/pkg/client/auth" //nolint:gci
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/klog/v2"
 )
 
@@ -43,6 +46,20 @@ type ListOptions struct {
 	APIResourcesToExclude []APIResource
 	APIResourcesToInclude []APIResource
 	Namespaces            []string
+	// MaxConcurrency is the maximum number of list requests sent to the server
+	// concurrently, no limit is applied if set to 0.
+	MaxConcurrency uint
+	// ForbiddenFn is called with every API resource that couldn't be listed in
+	// at least one of the namespaces due to missing permissions. It's never
+	// called concurrently.
+	ForbiddenFn func(api APIResource)
+	// ListedFn is called with every API resource & namespace whose objects were
+	// all listed, with an empty namespace if they were listed at the cluster
+	// scope. It's never called concurrently.
+	ListedFn func(api APIResource, ns string)
+	// ProgressFn is called with the number of objects listed so far after
+	// every list request. It's never called concurrently.
+	ProgressFn func(objects int)
 }
 
 type Interface interface {
@@ -62,6 +79,46 @@ type client struct {
 	discoveryClient discovery.DiscoveryInterface
 	dynamicClient   dynamic.Interface
 	mapper          meta.RESTMapper
+	// cache serves objects from informer caches if non-nil, see
+	// Flags.ToCachedClient
+	cache *informerCache
+}
+
+// New returns a client backed by the provided dynamic & discovery clients
+// instead of the configuration flags (e.g. "fake.NewSimpleDynamicClient" in
+// tests). Its REST mapper is built lazily from the discovery client & expands
+// short names the same way kubectl does. Server-printed tables aren't supported
+// since they're requested through the configuration flags.
+//
+// A "discovery/fake.FakeDiscovery" client serves its resources as the server
+// preferred resources, which it doesn't support by itself.
+func New(dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface) Interface {
+	discoveryClient = withPreferredResources(discoveryClient)
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	return &client{
+		discoveryClient: discoveryClient,
+		dynamicClient:   dynamicClient,
+		mapper:          restmapper.NewShortcutExpander(mapper, discoveryClient),
+	}
+}
+
+// preferredResourcesDiscovery is a fake discovery client that serves its
+// resources as the server preferred resources.
+type preferredResourcesDiscovery struct {
+	*fakediscovery.FakeDiscovery
+}
+
+func (d preferredResourcesDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return d.Resources, nil
+}
+
+// withPreferredResources wraps the discovery client if it's a fake discovery
+// client, otherwise it's returned as is.
+func withPreferredResources(d discovery.DiscoveryInterface) discovery.DiscoveryInterface {
+	if fd, ok := d.(*fakediscovery.FakeDiscovery); ok {
+		return preferredResourcesDiscovery{fd}
+	}
+	return d
 }
 
 func (c *client) GetMapper() meta.RESTMapper {
@@ -74,6 +131,11 @@ func (c *client) IsReachable() error {
 	return err
 }
 
+// ResolveAPIResource resolves the provided resource type into an APIResource.
+// The resource type accepts any form that kubectl accepts: short names (eg.
+// "deploy"), kinds (eg. "Deployment"), singular & plural resource names
+// optionally qualified by their group (eg. "deployment.apps") or by both their
+// version & group (eg. "deployments.v1.apps").
 func (c *client) ResolveAPIResource(s string) (*APIResource, error) {
 	var gvr schema.GroupVersionResource
 	var gvk schema.GroupVersionKind
@@ -148,6 +210,9 @@ func (c *client) Get(ctx context.Context, name string, opts GetOptions) (*unstru
 // request made by `kubectl get TYPE NAME... [-n NAMESPACE]`.
 func (c *client) GetTable(ctx context.Context, opts GetTableOptions) (*metav1.Table, error) {
 	klog.V(4).Infof("GetTable with options: %+v", opts)
+	if c.configFlags == nil {
+		return nil, fmt.Errorf("server-printed tables aren't supported by clients without configuration flags")
+	}
 	gk := opts.APIResource.GroupVersionKind().GroupKind()
 	r := resource.NewBuilder(c.configFlags).
 		Unstructured().
@@ -214,7 +279,8 @@ func decodeIntoTable(obj runtime.Object) (*metav1.Table, error) {
 }
 
 // List returns a list of objects that matches the provided options on the
-// server.
+// server. If the provided context is done before every object is listed, the
+// objects listed so far are returned along with the context's error.
 //
 //nolint:funlen,gocognit
 func (c *client) List(ctx context.Context, opts ListOptions) (*unstructuredv1.UnstructuredList, error) {
@@ -259,20 +325,49 @@ func (c *client) List(ctx context.Context, opts ListOptions) (*unstructuredv1.Un
 		}
 	}
 
+	// Limit the number of in-flight list requests, only the actual requests
+	// acquire the semaphore so that nested goroutines can't deadlock
+	var sem chan struct{}
+	if opts.MaxConcurrency > 0 {
+		sem = make(chan struct{}, opts.MaxConcurrency)
+	}
 	var mu sync.Mutex
 	var items []unstructuredv1.Unstructured
+	reportForbidden := func(api APIResource) {
+		if opts.ForbiddenFn == nil {
+			return
+		}
+		mu.Lock()
+		opts.ForbiddenFn(api)
+		mu.Unlock()
+	}
 	createListFn := func(ctx context.Context, api APIResource, ns string) func() error {
 		return func() error {
+			if sem != nil {
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				defer func() { <-sem }()
+			}
 			objs, err := c.listByAPI(ctx, api, ns)
 			if err != nil {
 				return err
 			}
 			mu.Lock()
 			items = append(items, objs.Items...)
+			if opts.ListedFn != nil {
+				opts.ListedFn(api, ns)
+			}
+			if opts.ProgressFn != nil {
+				opts.ProgressFn(len(items))
+			}
 			mu.Unlock()
 			return nil
 		}
 	}
+	parentCtx := ctx
 	eg, ctx := errgroup.WithContext(ctx)
 	for i := range apis {
 		api := apis[i]
@@ -284,10 +379,11 @@ func (c *client) List(ctx context.Context, opts ListOptions) (*unstructuredv1.Un
 			for ns := range nsSet {
 				listFn := createListFn(ctxInner, api, ns)
 				egInner.Go(func() error {
-					err = listFn()
+					err := listFn()
 					// If no permissions to list the resource at the namespace scope,
 					// suppress the error to allow other goroutines to continue listing
 					if apierrors.IsForbidden(err) {
+						reportForbidden(api)
 						err = nil
 					}
 					return err
@@ -302,6 +398,7 @@ func (c *client) List(ctx context.Context, opts ListOptions) (*unstructuredv1.Un
 				// If no permissions to list the cluster-scoped resource,
 				// suppress the error to allow other goroutines to continue listing
 				if !api.Namespaced && apierrors.IsForbidden(err) {
+					reportForbidden(api)
 					err = nil
 				}
 				// If no permissions to list the namespaced resource at the cluster
@@ -310,11 +407,19 @@ func (c *client) List(ctx context.Context, opts ListOptions) (*unstructuredv1.Un
 				if !api.Namespaced || !apierrors.IsForbidden(err) {
 					return err
 				}
+				if len(nsSet) == 0 {
+					reportForbidden(api)
+					return nil
+				}
 			}
 			return namespaceScopeListFn()
 		})
 	}
 	if err := eg.Wait(); err != nil {
+		if ctxErr := parentCtx.Err(); ctxErr != nil {
+			klog.V(4).Infof("Got %4d objects before listing was interrupted: %s", len(items), ctxErr)
+			return &unstructuredv1.UnstructuredList{Items: items}, ctxErr
+		}
 		return nil, err
 	}
 
@@ -377,6 +482,17 @@ func (c *client) GetAPIResources(_ context.Context) ([]APIResource, error) {
 // listByAPI list all objects of the provided API & namespace. If listing the
 // API at the cluster scope, set the namespace argument as an empty string.
 func (c *client) listByAPI(ctx context.Context, api APIResource, ns string) (*unstructuredv1.UnstructuredList, error) {
+	if c.cache != nil {
+		return c.cache.list(ctx, api, ns, func() (*unstructuredv1.UnstructuredList, error) {
+			return c.listByAPIFromServer(ctx, api, ns)
+		})
+	}
+	return c.listByAPIFromServer(ctx, api, ns)
+}
+
+// listByAPIFromServer list all objects of the provided API & namespace from the
+// server, bypassing the informer cache.
+func (c *client) listByAPIFromServer(ctx context.Context, api APIResource, ns string) (*unstructuredv1.UnstructuredList, error) {
 	var ri dynamic.ResourceInterface
 	var items []unstructuredv1.Unstructured
 	var next string
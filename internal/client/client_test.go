@@ -0,0 +1,338 @@
+package client
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/restmapper"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func newTestClient() *client {
+	dis := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	dis.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", SingularName: "pod", Namespaced: true, Kind: "Pod", ShortNames: []string{"po"}},
+				{Name: "services", SingularName: "service", Namespaced: true, Kind: "Service", ShortNames: []string{"svc"}},
+				{Name: "nodes", SingularName: "node", Namespaced: false, Kind: "Node", ShortNames: []string{"no"}},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", SingularName: "deployment", Namespaced: true, Kind: "Deployment", ShortNames: []string{"deploy"}},
+				{Name: "replicasets", SingularName: "replicaset", Namespaced: true, Kind: "ReplicaSet", ShortNames: []string{"rs"}},
+			},
+		},
+		{
+			GroupVersion: "metrics.k8s.io/v1beta1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", SingularName: "", Namespaced: true, Kind: "PodMetrics"},
+			},
+		},
+	}
+	groupResources := []*restmapper.APIGroupResources{
+		{
+			Group: metav1.APIGroup{
+				Versions:         []metav1.GroupVersionForDiscovery{{GroupVersion: "v1", Version: "v1"}},
+				PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "v1", Version: "v1"},
+			},
+			VersionedResources: map[string][]metav1.APIResource{"v1": dis.Resources[0].APIResources},
+		},
+		{
+			Group: metav1.APIGroup{
+				Name:             "apps",
+				Versions:         []metav1.GroupVersionForDiscovery{{GroupVersion: "apps/v1", Version: "v1"}},
+				PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "apps/v1", Version: "v1"},
+			},
+			VersionedResources: map[string][]metav1.APIResource{"v1": dis.Resources[1].APIResources},
+		},
+		{
+			Group: metav1.APIGroup{
+				Name:             "metrics.k8s.io",
+				Versions:         []metav1.GroupVersionForDiscovery{{GroupVersion: "metrics.k8s.io/v1beta1", Version: "v1beta1"}},
+				PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "metrics.k8s.io/v1beta1", Version: "v1beta1"},
+			},
+			VersionedResources: map[string][]metav1.APIResource{"v1beta1": dis.Resources[2].APIResources},
+		},
+	}
+	mapper := restmapper.NewShortcutExpander(restmapper.NewDiscoveryRESTMapper(groupResources), dis)
+
+	return &client{discoveryClient: dis, mapper: mapper}
+}
+
+func TestResolveAPIResource(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient()
+	tests := []struct {
+		arg      string
+		expected string
+	}{
+		// Short names
+		{arg: "po", expected: "pods"},
+		{arg: "svc", expected: "services"},
+		{arg: "deploy", expected: "deployments.apps"},
+		{arg: "rs", expected: "replicasets.apps"},
+		{arg: "no", expected: "nodes"},
+		// Singular & plural resource names
+		{arg: "pod", expected: "pods"},
+		{arg: "pods", expected: "pods"},
+		{arg: "deployment", expected: "deployments.apps"},
+		{arg: "deployments", expected: "deployments.apps"},
+		// Kinds
+		{arg: "Pod", expected: "pods"},
+		{arg: "Deployment", expected: "deployments.apps"},
+		{arg: "ReplicaSet", expected: "replicasets.apps"},
+		// Group-qualified resource names
+		{arg: "deployments.apps", expected: "deployments.apps"},
+		{arg: "deployment.apps", expected: "deployments.apps"},
+		{arg: "deploy.apps", expected: "deployments.apps"},
+		{arg: "pods.metrics.k8s.io", expected: "pods.metrics.k8s.io"},
+		// Fully specified resource names
+		{arg: "deployments.v1.apps", expected: "deployments.apps"},
+		{arg: "pods.v1beta1.metrics.k8s.io", expected: "pods.metrics.k8s.io"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.arg, func(t *testing.T) {
+			t.Parallel()
+
+			api, err := c.ResolveAPIResource(tt.arg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := api.WithGroupString(); got != tt.expected {
+				t.Fatalf("expected \"%s\" got \"%s\"", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResolveAPIResourceUnknown(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient()
+	tests := []struct {
+		arg      string
+		expected string
+	}{
+		{arg: "foo", expected: "the server doesn't have a resource type \"foo\""},
+		{arg: "deploy.foo", expected: "the server doesn't have a resource type \"deploy\" in group \"foo\""},
+	}
+	for _, tt := range tests {
+		_, err := c.ResolveAPIResource(tt.arg)
+		if err == nil {
+			t.Fatalf("expected error for \"%s\"", tt.arg)
+		}
+		if got := err.Error(); got != tt.expected {
+			t.Fatalf("expected \"%s\" got \"%s\"", tt.expected, got)
+		}
+	}
+}
+
+func TestListForbidden(t *testing.T) {
+	t.Parallel()
+
+	verbs := metav1.Verbs{"get", "list", "watch"}
+	dis := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	dis.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", Namespaced: true, Kind: "Pod", Verbs: verbs},
+				{Name: "secrets", Namespaced: true, Kind: "Secret", Verbs: verbs},
+				{Name: "nodes", Namespaced: false, Kind: "Node", Verbs: verbs},
+			},
+		},
+	}
+	dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "pods"}:    "PodList",
+		{Version: "v1", Resource: "secrets"}: "SecretList",
+		{Version: "v1", Resource: "nodes"}:   "NodeList",
+	})
+	for _, resource := range []string{"secrets", "nodes"} {
+		resource := resource
+		dyn.PrependReactor("list", resource, func(clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: resource}, "", nil)
+		})
+	}
+	c := &client{discoveryClient: withPreferredResources(dis), dynamicClient: dyn}
+
+	forbidden := []string{}
+	_, err := c.List(context.Background(), ListOptions{
+		Namespaces: []string{"default"},
+		ForbiddenFn: func(api APIResource) {
+			forbidden = append(forbidden, api.WithGroupString())
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sort.Strings(forbidden)
+	if expected := []string{"nodes", "secrets"}; !reflect.DeepEqual(forbidden, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, forbidden)
+	}
+}
+
+func TestListCached(t *testing.T) {
+	t.Parallel()
+
+	verbs := metav1.Verbs{"get", "list", "watch"}
+	dis := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	dis.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", Namespaced: true, Kind: "Pod", Verbs: verbs},
+			},
+		},
+	}
+	newPod := func(name string) *unstructuredv1.Unstructured {
+		u := &unstructuredv1.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("Pod")
+		u.SetNamespace("default")
+		u.SetName(name)
+		return u
+	}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		gvr: "PodList",
+	}, newPod("foo"))
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	c := &client{discoveryClient: withPreferredResources(dis), dynamicClient: dyn, cache: newInformerCache(dyn, stopCh)}
+
+	ctx := context.Background()
+	opts := ListOptions{Namespaces: []string{"default"}}
+	objs, err := c.List(ctx, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objs.Items) != 1 {
+		t.Fatalf("expected 1 object got %d", len(objs.Items))
+	}
+
+	// Objects created afterwards are served by the informer cache
+	if _, err := dyn.Resource(gvr).Namespace("default").Create(ctx, newPod("bar"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		objs, err := c.List(ctx, opts)
+		if err != nil {
+			return false, err
+		}
+		return len(objs.Items) == 2, nil
+	})
+	if err != nil {
+		t.Fatalf("expected 2 objects from the cache: %v", err)
+	}
+	lists := 0
+	for _, action := range dyn.Actions() {
+		if action.GetVerb() == "list" {
+			lists++
+		}
+	}
+	if lists > 2 {
+		t.Fatalf("expected at most 2 list requests got %d", lists)
+	}
+}
+
+func TestToClientNoConfiguration(t *testing.T) {
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("KUBERNETES_MASTER", "")
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "")
+
+	if _, err := NewFlags().ToClient(); !errors.Is(err, ErrNoConfiguration) {
+		t.Fatalf("ToClient(): expected \"%v\" got \"%v\"", ErrNoConfiguration, err)
+	}
+
+	// Providing the server explicitly doesn't require any kubeconfig
+	f := NewFlags()
+	server := "https://127.0.0.1:6443"
+	f.APIServer = &server
+	if _, err := f.ToClient(); err != nil {
+		t.Fatalf("ToClient(): unexpected error: %v", err)
+	}
+}
+
+func TestToClientTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major":"1","minor":"23","gitVersion":"v1.23.4"}`))
+	}))
+	// Silence the handshake errors of requests rejecting the certificate
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	writeKubeconfig := func(name string, caData []byte) string {
+		config := clientcmdapi.NewConfig()
+		config.Clusters["test"] = &clientcmdapi.Cluster{Server: server.URL, CertificateAuthorityData: caData}
+		config.AuthInfos["test"] = &clientcmdapi.AuthInfo{}
+		config.Contexts["test"] = &clientcmdapi.Context{Cluster: "test", AuthInfo: "test"}
+		config.CurrentContext = "test"
+		path := filepath.Join(dir, name)
+		if err := clientcmd.WriteToFile(*config, path); err != nil {
+			t.Fatalf("failed to write kubeconfig: %v", err)
+		}
+		return path
+	}
+	if err := os.WriteFile(caFile, caData, 0o600); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	withCA := writeKubeconfig("with-ca", caData)
+	withoutCA := writeKubeconfig("without-ca", nil)
+
+	tests := []struct {
+		name       string
+		kubeconfig string
+		caFile     string
+		insecure   bool
+		expectErr  bool
+	}{
+		{"kubeconfig CA", withCA, "", false, false},
+		{"unknown CA", withoutCA, "", false, true},
+		{"--certificate-authority", withoutCA, caFile, false, false},
+		{"--insecure-skip-tls-verify", withoutCA, "", true, false},
+	}
+	for _, tt := range tests {
+		f := NewFlags()
+		kubeconfig, cacheDir, caFile, insecure := tt.kubeconfig, filepath.Join(dir, "cache"), tt.caFile, tt.insecure
+		f.KubeConfig, f.CacheDir, f.CAFile, f.Insecure = &kubeconfig, &cacheDir, &caFile, &insecure
+		c, err := f.ToClient()
+		if err != nil {
+			t.Fatalf("%s: ToClient(): unexpected error: %v", tt.name, err)
+		}
+		if err := c.IsReachable(); (err != nil) != tt.expectErr {
+			t.Fatalf("%s: IsReachable(): expected error: %t got \"%v\"", tt.name, tt.expectErr, err)
+		}
+	}
+}
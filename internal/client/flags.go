@@ -1,6 +1,9 @@
 package client
 
 import (
+	"os"
+
+	"github.com/cockroachdb/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -11,6 +14,10 @@ import (
 	"k8s.io/kubectl/pkg/util"
 )
 
+// ErrNoConfiguration is returned when neither a kubeconfig nor an in-cluster
+// configuration is available to connect to the cluster.
+var ErrNoConfiguration = errors.New("no kubeconfig found & not running inside a Pod with a service account, provide a kubeconfig with --kubeconfig or the KUBECONFIG environment variable")
+
 // Flags composes common client configuration flag structs used in the command.
 type Flags struct {
 	*genericclioptions.ConfigFlags
@@ -56,8 +63,29 @@ func (*Flags) RegisterFlagCompletionFunc(cmd *cobra.Command, f cmdutil.Factory)
 		}))
 }
 
-// ToClient returns a client based on the flag configuration.
+// ToClient returns a client based on the flag configuration. The in-cluster
+// configuration is used if no kubeconfig is found when running inside a Pod.
 func (f *Flags) ToClient() (Interface, error) {
+	return f.toClient()
+}
+
+// ToCachedClient returns a client based on the flag configuration, which keeps
+// the objects it lists in informer caches. Subsequent lists are served from the
+// caches, which are kept up to date by watches until the provided stop channel
+// is closed.
+func (f *Flags) ToCachedClient(stopCh <-chan struct{}) (Interface, error) {
+	c, err := f.toClient()
+	if err != nil {
+		return nil, err
+	}
+	c.cache = newInformerCache(c.dynamicClient, stopCh)
+	return c, nil
+}
+
+func (f *Flags) toClient() (*client, error) {
+	if err := f.checkConfiguration(); err != nil {
+		return nil, err
+	}
 	config, err := f.ToRESTConfig()
 	if err != nil {
 		return nil, err
@@ -71,6 +99,10 @@ func (f *Flags) ToClient() (Interface, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Both the discovery client & REST mapper are backed by a disk cache in
+	// the directory specified by "--cache-dir", so discovery results are
+	// reused across invocations until the cache expires. The REST mapper also
+	// expands the short names of resources the same way kubectl does
 	dis, err := f.ToDiscoveryClient()
 	if err != nil {
 		return nil, err
@@ -89,6 +121,38 @@ func (f *Flags) ToClient() (Interface, error) {
 	return c, nil
 }
 
+// ForContext returns flags that connect to the cluster of the provided
+// kubeconfig context instead. Only the kubeconfig, cache directory & request
+// timeout are kept from the flag configuration, since the remaining settings
+// (eg. the server or user) are specific to the current context.
+func (f *Flags) ForContext(name string) *Flags {
+	configFlags := genericclioptions.NewConfigFlags(true)
+	configFlags.KubeConfig = f.KubeConfig
+	configFlags.CacheDir = f.CacheDir
+	configFlags.Timeout = f.Timeout
+	configFlags.Context = &name
+	return &Flags{ConfigFlags: configFlags}
+}
+
+// checkConfiguration returns ErrNoConfiguration if there's neither a kubeconfig
+// nor an in-cluster configuration available, instead of letting the client
+// connect to the default server (i.e. "localhost:8080").
+func (f *Flags) checkConfiguration() error {
+	raw, err := f.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return err
+	}
+	if len(raw.Clusters) > 0 || (f.APIServer != nil && len(*f.APIServer) > 0) || len(os.Getenv("KUBERNETES_MASTER")) > 0 {
+		return nil
+	}
+	// The kubeconfig loader falls back to rest.InClusterConfig() when no
+	// kubeconfig is found, which is only possible when running inside a Pod
+	if _, err := rest.InClusterConfig(); errors.Is(err, rest.ErrNotInCluster) {
+		return ErrNoConfiguration
+	}
+	return nil
+}
+
 // NewFlags returns flags associated with client configuration, with default
 // values set.
 func NewFlags() *Flags {
@@ -78,3 +78,38 @@ type ObjectMeta struct {
 func (o ObjectMeta) String() string {
 	return fmt.Sprintf("%s/%s", o.APIResource, o.Name)
 }
+
+// ListedResources contains the namespaces each resource type was listed in,
+// where the empty namespace means the resource type was listed at the cluster
+// scope. It's populated by passing its Add method as ListOptions.ListedFn.
+type ListedResources map[schema.GroupKind]map[string]struct{}
+
+// Add records that every object of the provided API resource was listed in the
+// provided namespace.
+func (l ListedResources) Add(api APIResource, ns string) {
+	// Cluster-scoped objects are listed regardless of the namespace
+	if !api.Namespaced {
+		ns = ""
+	}
+	for gk := range ResourcesToGroupKindSet([]APIResource{api}) {
+		if _, ok := l[gk]; !ok {
+			l[gk] = map[string]struct{}{}
+		}
+		l[gk][ns] = struct{}{}
+	}
+}
+
+// Has returns true if every object of the provided resource type in the
+// provided namespace was listed, either in the namespace or at the cluster
+// scope.
+func (l ListedResources) Has(gk schema.GroupKind, ns string) bool {
+	nsSet, ok := l[gk]
+	if !ok {
+		return false
+	}
+	if _, ok := nsSet[""]; ok {
+		return true
+	}
+	_, ok = nsSet[ns]
+	return ok
+}
@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// CertManagerGroupName is the group name of the cert-manager resources. The
+// resources are defined by CRDs, so their relationships are only discovered on
+// clusters with cert-manager installed. CertificateRequests, Orders &
+// Challenges are linked to the object that created them through their owner
+// references.
+const CertManagerGroupName = "cert-manager.io"
+
+const (
+	// cert-manager relationships.
+	RelationshipCertificateIssuer        Relationship = "CertificateIssuer"
+	RelationshipCertificateSecret        Relationship = "CertificateSecret"
+	RelationshipCertificateRequestIssuer Relationship = "CertificateRequestIssuer"
+)
+
+// certificate is the subset of the "cert-manager.io" Certificate schema that is
+// needed to discover its relationships. It's defined here so we don't need to
+// import the entire github.com/cert-manager/cert-manager package.
+type certificate struct {
+	Spec struct {
+		SecretName string               `json:"secretName"`
+		IssuerRef  certManagerIssuerRef `json:"issuerRef"`
+	} `json:"spec"`
+}
+
+// certificateRequest is the subset of the "cert-manager.io" CertificateRequest
+// schema that is needed to discover its relationships.
+type certificateRequest struct {
+	Spec struct {
+		IssuerRef certManagerIssuerRef `json:"issuerRef"`
+	} `json:"spec"`
+}
+
+// certManagerIssuerRef represents a reference to the issuer of a certificate,
+// which defaults to an Issuer in the same namespace as the certificate.
+type certManagerIssuerRef struct {
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+}
+
+// toObjectReference converts the issuer reference into an ObjectReference,
+// filling in the omitted fields with the cert-manager defaults. ClusterIssuers
+// are cluster-scoped, so the provided namespace is only used for Issuers.
+func (r certManagerIssuerRef) toObjectReference(ns string) ObjectReference {
+	group, kind := r.Group, r.Kind
+	if len(group) == 0 {
+		group = CertManagerGroupName
+	}
+	if len(kind) == 0 {
+		kind = "Issuer"
+	}
+	if group == CertManagerGroupName && kind == "ClusterIssuer" {
+		ns = ""
+	}
+	return ObjectReference{Group: group, Kind: kind, Name: r.Name, Namespace: ns}
+}
+
+// getCertificateRelationships returns a map of relationships that this
+// Certificate has with other objects, based on what was referenced in its
+// manifest.
+func getCertificateRelationships(n *Node) (*RelationshipMap, error) {
+	var cert certificate
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &cert)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	ns := n.Namespace
+	result := newRelationshipMap()
+
+	// RelationshipCertificateIssuer
+	if ir := cert.Spec.IssuerRef; len(ir.Name) > 0 {
+		ref = ir.toObjectReference(ns)
+		result.AddDependencyByKey(ref.Key(), RelationshipCertificateIssuer)
+	}
+
+	// RelationshipCertificateSecret
+	if s := cert.Spec.SecretName; len(s) > 0 {
+		ref = ObjectReference{Kind: "Secret", Name: s, Namespace: ns}
+		result.AddDependentByKey(ref.Key(), RelationshipCertificateSecret)
+	}
+
+	return &result, nil
+}
+
+// getCertificateRequestRelationships returns a map of relationships that this
+// CertificateRequest has with other objects, based on what was referenced in
+// its manifest.
+func getCertificateRequestRelationships(n *Node) (*RelationshipMap, error) {
+	var cr certificateRequest
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &cr)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipCertificateRequestIssuer
+	if ir := cr.Spec.IssuerRef; len(ir.Name) > 0 {
+		ref = ir.toObjectReference(n.Namespace)
+		result.AddDependencyByKey(ref.Key(), RelationshipCertificateRequestIssuer)
+	}
+
+	return &result, nil
+}
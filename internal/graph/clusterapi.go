@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ClusterAPIGroupName is the group name of the Cluster API resources. The
+// resources are defined by CRDs, so their relationships are only discovered on
+// clusters with Cluster API installed.
+const ClusterAPIGroupName = "cluster.x-k8s.io"
+
+const (
+	// Cluster API relationships.
+	RelationshipMachineNodeRef Relationship = "MachineNodeReference"
+)
+
+// machine is the subset of the "cluster.x-k8s.io" Machine schema that is needed
+// to discover its relationships. It's defined here so we don't need to import
+// the entire sigs.k8s.io/cluster-api package.
+type machine struct {
+	Spec struct {
+		ClusterName string `json:"clusterName"`
+	} `json:"spec"`
+	Status struct {
+		NodeRef *struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"nodeRef"`
+	} `json:"status"`
+}
+
+// getMachineRelationships returns a map of relationships that this Machine has
+// with other objects, based on what was referenced in its manifest.
+func getMachineRelationships(n *Node) (*RelationshipMap, error) {
+	var m machine
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &m)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipMachineNodeRef
+	// The Node lives in the workload cluster of the Machine instead of the
+	// cluster the Machine was listed from, so it's kept as a subject tagged with
+	// the name of the workload cluster
+	if nr := m.Status.NodeRef; nr != nil && len(nr.Name) > 0 && len(m.Spec.ClusterName) > 0 {
+		kind := nr.Kind
+		if len(kind) == 0 {
+			kind = "Node"
+		}
+		ref = ObjectReference{Kind: kind, Name: nr.Name, Cluster: m.Spec.ClusterName}
+		result.AddDependentBySubject(ref, RelationshipMachineNodeRef)
+	}
+
+	return &result, nil
+}
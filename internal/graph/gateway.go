@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// GatewayAPIGroupName is the group name of the Gateway API resources. The
+// resources are defined by CRDs, so their relationships are only discovered on
+// clusters with the Gateway API installed.
+const GatewayAPIGroupName = "gateway.networking.k8s.io"
+
+const (
+	// Gateway API relationships.
+	RelationshipGatewayClass       Relationship = "GatewayClass"
+	RelationshipHTTPRouteBackend   Relationship = "HTTPRouteBackend"
+	RelationshipHTTPRouteParentRef Relationship = "HTTPRouteParentReference"
+)
+
+// gateway is the subset of the "gateway.networking.k8s.io" Gateway schema that
+// is needed to discover its relationships. It's defined here so we don't need
+// to import the entire sigs.k8s.io/gateway-api package.
+type gateway struct {
+	Spec struct {
+		GatewayClassName string `json:"gatewayClassName"`
+	} `json:"spec"`
+}
+
+// httpRoute is the subset of the "gateway.networking.k8s.io" HTTPRoute schema
+// that is needed to discover its relationships.
+type httpRoute struct {
+	Spec struct {
+		ParentRefs []gatewayObjectReference `json:"parentRefs"`
+		Rules      []struct {
+			BackendRefs []gatewayObjectReference `json:"backendRefs"`
+		} `json:"rules"`
+	} `json:"spec"`
+}
+
+// gatewayObjectReference represents either a parent or backend reference of a
+// route, which default to referencing a Gateway & a Service respectively in
+// the same namespace as the route.
+type gatewayObjectReference struct {
+	Group     *string `json:"group"`
+	Kind      *string `json:"kind"`
+	Namespace *string `json:"namespace"`
+	Name      string  `json:"name"`
+}
+
+// toObjectReference converts the route reference into an ObjectReference,
+// filling in the omitted fields with the provided defaults.
+func (r gatewayObjectReference) toObjectReference(group, kind, ns string) ObjectReference {
+	if r.Group != nil {
+		group = *r.Group
+	}
+	if r.Kind != nil {
+		kind = *r.Kind
+	}
+	if r.Namespace != nil {
+		ns = *r.Namespace
+	}
+	return ObjectReference{Group: group, Kind: kind, Name: r.Name, Namespace: ns}
+}
+
+// getGatewayRelationships returns a map of relationships that this Gateway has
+// with other objects, based on what was referenced in its manifest.
+func getGatewayRelationships(n *Node) (*RelationshipMap, error) {
+	var gw gateway
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &gw)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipGatewayClass
+	if gwc := gw.Spec.GatewayClassName; len(gwc) > 0 {
+		ref = ObjectReference{Group: GatewayAPIGroupName, Kind: "GatewayClass", Name: gwc}
+		result.AddDependencyByKey(ref.Key(), RelationshipGatewayClass)
+	}
+
+	return &result, nil
+}
+
+// getHTTPRouteRelationships returns a map of relationships that this HTTPRoute
+// has with other objects, based on what was referenced in its manifest.
+func getHTTPRouteRelationships(n *Node) (*RelationshipMap, error) {
+	var route httpRoute
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &route)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	ns := n.Namespace
+	result := newRelationshipMap()
+
+	// RelationshipHTTPRouteParentRef
+	for _, p := range route.Spec.ParentRefs {
+		ref = p.toObjectReference(GatewayAPIGroupName, "Gateway", ns)
+		result.AddDependencyByKey(ref.Key(), RelationshipHTTPRouteParentRef)
+	}
+
+	// RelationshipHTTPRouteBackend
+	for _, rule := range route.Spec.Rules {
+		for _, b := range rule.BackendRefs {
+			ref = b.toObjectReference("", "Service", ns)
+			result.AddDependencyByKey(ref.Key(), RelationshipHTTPRouteBackend)
+		}
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,140 @@
+package graph
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// Group names of the GitOps resources. The resources are defined by CRDs, so
+// their relationships are only discovered on clusters with Argo CD or Flux
+// installed.
+const (
+	ArgoCDGroupName        = "argoproj.io"
+	FluxHelmGroupName      = "helm.toolkit.fluxcd.io"
+	FluxKustomizeGroupName = "kustomize.toolkit.fluxcd.io"
+)
+
+// Defaults of the namespace Argo CD is installed in & of the annotation it
+// uses to track the objects managed by Applications. Argo CD tracks objects
+// with the "app.kubernetes.io/instance" label by default, but there's no
+// default label since Helm charts set the same label to their release name.
+const (
+	DefaultArgoCDNamespace          = "argocd"
+	DefaultArgoCDTrackingAnnotation = "argocd.argoproj.io/tracking-id"
+)
+
+const (
+	// GitOps relationships.
+	RelationshipArgoCDApplication Relationship = "ArgoCDApplication"
+	RelationshipFluxHelmRelease   Relationship = "FluxHelmRelease"
+	RelationshipFluxKustomization Relationship = "FluxKustomization"
+)
+
+// GitOpsTracking configures how the objects managed by Argo CD Applications are
+// discovered, since Argo CD can be configured to track its objects with a
+// custom label or annotation. Flux always tracks its objects with labels named
+// after the API group of the Kustomization or HelmRelease managing them.
+type GitOpsTracking struct {
+	// ArgoCDInstanceLabel is the label set to the name of the Application
+	// managing the object, objects aren't tracked by label if empty.
+	ArgoCDInstanceLabel string
+	// ArgoCDNamespace is the namespace Argo CD is installed in, which contains
+	// the Applications referenced by name only. Applications in other
+	// namespaces are referenced by "<namespace>_<name>".
+	ArgoCDNamespace string
+	// ArgoCDTrackingAnnotation is the annotation set to the tracking ID of the
+	// object, objects aren't tracked by annotation if empty.
+	ArgoCDTrackingAnnotation string
+}
+
+// addGitOpsRelationships populates the relationships between the provided
+// nodes & the Argo CD Applications, Flux Kustomizations & Flux HelmReleases
+// managing them, based on the tracking labels & annotations of each node.
+func addGitOpsRelationships(nodes map[types.UID]*Node, nodesByKey map[ObjectReferenceKey]*Node, tracking GitOpsTracking) {
+	// Applications are referenced by name if they're in the namespace Argo CD
+	// is installed in, or by "<namespace>_<name>" otherwise
+	applications := map[string]*Node{}
+	for _, n := range nodes {
+		if n.Group == ArgoCDGroupName && n.Kind == "Application" {
+			applications[n.Namespace+"_"+n.Name] = n
+			if n.Namespace == tracking.ArgoCDNamespace {
+				applications[n.Name] = n
+			}
+		}
+	}
+	addDependency := func(node, owner *Node, r Relationship) {
+		if node.UID == owner.UID {
+			return
+		}
+		node.AddDependency(owner.UID, r)
+		owner.AddDependent(node.UID, r)
+	}
+
+	for _, node := range nodes {
+		if node.Unstructured == nil {
+			continue
+		}
+		lbls, annotations := node.GetLabels(), node.GetAnnotations()
+
+		// RelationshipArgoCDApplication
+		if len(applications) > 0 {
+			appNames := map[string]struct{}{}
+			if k := tracking.ArgoCDInstanceLabel; len(k) > 0 {
+				if v := lbls[k]; len(v) > 0 {
+					appNames[v] = struct{}{}
+				}
+			}
+			if k := tracking.ArgoCDTrackingAnnotation; len(k) > 0 {
+				if v, ok := parseArgoCDTrackingID(annotations[k], node); ok {
+					appNames[v] = struct{}{}
+				}
+			}
+			for name := range appNames {
+				if app, ok := applications[name]; ok {
+					addDependency(node, app, RelationshipArgoCDApplication)
+				}
+			}
+		}
+
+		// RelationshipFluxKustomization
+		if name := lbls[FluxKustomizeGroupName+"/name"]; len(name) > 0 {
+			ref := ObjectReference{Group: FluxKustomizeGroupName, Kind: "Kustomization", Name: name, Namespace: lbls[FluxKustomizeGroupName+"/namespace"]}
+			if ks, ok := nodesByKey[ref.Key()]; ok {
+				addDependency(node, ks, RelationshipFluxKustomization)
+			} else {
+				klog.V(4).Infof("Kustomization \"%s\" managing %s \"%s\" in namespace \"%s\" not found", ref.Name, node.Kind, node.Name, node.Namespace)
+			}
+		}
+
+		// RelationshipFluxHelmRelease
+		if name := lbls[FluxHelmGroupName+"/name"]; len(name) > 0 {
+			ref := ObjectReference{Group: FluxHelmGroupName, Kind: "HelmRelease", Name: name, Namespace: lbls[FluxHelmGroupName+"/namespace"]}
+			if hr, ok := nodesByKey[ref.Key()]; ok {
+				addDependency(node, hr, RelationshipFluxHelmRelease)
+			} else {
+				klog.V(4).Infof("HelmRelease \"%s\" managing %s \"%s\" in namespace \"%s\" not found", ref.Name, node.Kind, node.Name, node.Namespace)
+			}
+		}
+	}
+}
+
+// parseArgoCDTrackingID returns the Application name of the provided Argo CD
+// tracking ID, which is in "<application>:<group>/<kind>:<namespace>/<name>"
+// form. Tracking IDs that don't match the provided node are ignored, since
+// they're typically copied from another object.
+func parseArgoCDTrackingID(id string, n *Node) (string, bool) {
+	parts := strings.SplitN(id, ":", 3)
+	if len(parts) != 3 || len(parts[0]) == 0 {
+		return "", false
+	}
+	gk, nsName := strings.SplitN(parts[1], "/", 2), strings.SplitN(parts[2], "/", 2)
+	if len(gk) != 2 || len(nsName) != 2 {
+		return "", false
+	}
+	if gk[0] != n.Group || gk[1] != n.Kind || nsName[1] != n.Name {
+		return "", false
+	}
+	return parts[0], true
+}
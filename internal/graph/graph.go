@@ -3,9 +3,15 @@ package graph
 import (
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
+	"github.com/cockroachdb/errors"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	eventsv1 "k8s.io/api/events/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -15,10 +21,12 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
@@ -35,11 +43,17 @@ type ObjectLabelSelector struct {
 	Kind      string
 	Namespace string
 	Selector  labels.Selector
+	// NamespaceSelector selects objects in namespaces whose labels match the
+	// selector instead of objects in Namespace, if set.
+	NamespaceSelector labels.Selector
 }
 
 // Key converts the ObjectLabelSelector into a ObjectLabelSelectorKey.
 func (o *ObjectLabelSelector) Key() ObjectLabelSelectorKey {
 	k := fmt.Sprintf("%s\\%s\\%s\\%s", o.Group, o.Kind, o.Namespace, o.Selector)
+	if o.NamespaceSelector != nil {
+		k = fmt.Sprintf("%s\\%s", k, o.NamespaceSelector)
+	}
 	return ObjectLabelSelectorKey(k)
 }
 
@@ -70,11 +84,18 @@ type ObjectReference struct {
 	Kind      string
 	Namespace string
 	Name      string
+	// Cluster is the name of the cluster the object lives in, if it isn't the
+	// cluster the relationship tree is built from (eg. the Nodes of Cluster API
+	// workload clusters).
+	Cluster string
 }
 
 // Key converts the ObjectReference into a ObjectReferenceKey.
 func (o *ObjectReference) Key() ObjectReferenceKey {
 	k := fmt.Sprintf("%s\\%s\\%s\\%s", o.Group, o.Kind, o.Namespace, o.Name)
+	if len(o.Cluster) > 0 {
+		k = fmt.Sprintf("%s\\%s", o.Cluster, k)
+	}
 	return ObjectReferenceKey(k)
 }
 
@@ -100,33 +121,76 @@ func (s RelationshipSet) List() []string {
 	return []string(res)
 }
 
+// RelationshipCategory represents a category of relationship types that are
+// discovered the same way (eg. by resolving label selectors).
+type RelationshipCategory string
+
+// RelationshipCategories returns the names of every relationship category as a
+// sorted string slice.
+func RelationshipCategories() []string {
+	res := make(sortableStringSlice, 0, len(relationshipCategories))
+	for c := range relationshipCategories {
+		res = append(res, string(c))
+	}
+	sort.Sort(res)
+	return []string(res)
+}
+
+// ResolveRelationshipCategories returns the set of relationship types of the
+// provided relationship categories.
+func ResolveRelationshipCategories(categories []string) (RelationshipSet, error) {
+	result := RelationshipSet{}
+	for _, c := range categories {
+		rset, ok := relationshipCategories[RelationshipCategory(c)]
+		if !ok {
+			return nil, fmt.Errorf("unknown relationship category \"%s\", must be one of: %s", c, strings.Join(RelationshipCategories(), ", "))
+		}
+		for r := range rset {
+			result[r] = struct{}{}
+		}
+	}
+	return result, nil
+}
+
 // RelationshipMap contains a map of relationships a Kubernetes object has with
 // other objects in the cluster.
 type RelationshipMap struct {
 	DependenciesByLabelSelector map[ObjectLabelSelectorKey]RelationshipSet
 	DependenciesByRef           map[ObjectReferenceKey]RelationshipSet
+	DependenciesByReference     map[ObjectReferenceKey]RelationshipSet
 	DependenciesBySelector      map[ObjectSelectorKey]RelationshipSet
+	DependenciesBySubject       map[ObjectReferenceKey]RelationshipSet
 	DependenciesByUID           map[types.UID]RelationshipSet
 	DependentsByLabelSelector   map[ObjectLabelSelectorKey]RelationshipSet
 	DependentsByRef             map[ObjectReferenceKey]RelationshipSet
+	DependentsByReference       map[ObjectReferenceKey]RelationshipSet
 	DependentsBySelector        map[ObjectSelectorKey]RelationshipSet
+	DependentsBySubject         map[ObjectReferenceKey]RelationshipSet
 	DependentsByUID             map[types.UID]RelationshipSet
 	ObjectLabelSelectors        map[ObjectLabelSelectorKey]ObjectLabelSelector
 	ObjectSelectors             map[ObjectSelectorKey]ObjectSelector
+	References                  map[ObjectReferenceKey]ObjectReference
+	Subjects                    map[ObjectReferenceKey]ObjectReference
 }
 
 func newRelationshipMap() RelationshipMap {
 	return RelationshipMap{
 		DependenciesByLabelSelector: map[ObjectLabelSelectorKey]RelationshipSet{},
 		DependenciesByRef:           map[ObjectReferenceKey]RelationshipSet{},
+		DependenciesByReference:     map[ObjectReferenceKey]RelationshipSet{},
 		DependenciesBySelector:      map[ObjectSelectorKey]RelationshipSet{},
+		DependenciesBySubject:       map[ObjectReferenceKey]RelationshipSet{},
 		DependenciesByUID:           map[types.UID]RelationshipSet{},
 		DependentsByLabelSelector:   map[ObjectLabelSelectorKey]RelationshipSet{},
 		DependentsByRef:             map[ObjectReferenceKey]RelationshipSet{},
+		DependentsByReference:       map[ObjectReferenceKey]RelationshipSet{},
 		DependentsBySelector:        map[ObjectSelectorKey]RelationshipSet{},
+		DependentsBySubject:         map[ObjectReferenceKey]RelationshipSet{},
 		DependentsByUID:             map[types.UID]RelationshipSet{},
 		ObjectLabelSelectors:        map[ObjectLabelSelectorKey]ObjectLabelSelector{},
 		ObjectSelectors:             map[ObjectSelectorKey]ObjectSelector{},
+		References:                  map[ObjectReferenceKey]ObjectReference{},
+		Subjects:                    map[ObjectReferenceKey]ObjectReference{},
 	}
 }
 
@@ -146,6 +210,18 @@ func (m *RelationshipMap) AddDependencyByLabelSelector(o ObjectLabelSelector, r
 	m.ObjectLabelSelectors[k] = o
 }
 
+// AddDependencyByReference adds the referenced object as a dependency. Unlike
+// AddDependencyByKey, objects that don't exist in the cluster are included in
+// the relationship tree as synthetic objects marked as not found.
+func (m *RelationshipMap) AddDependencyByReference(o ObjectReference, r Relationship) {
+	k := o.Key()
+	if _, ok := m.DependenciesByReference[k]; !ok {
+		m.DependenciesByReference[k] = RelationshipSet{}
+	}
+	m.DependenciesByReference[k][r] = struct{}{}
+	m.References[k] = o
+}
+
 func (m *RelationshipMap) AddDependencyBySelector(o ObjectSelector, r Relationship) {
 	k := o.Key()
 	if _, ok := m.DependenciesBySelector[k]; !ok {
@@ -155,6 +231,18 @@ func (m *RelationshipMap) AddDependencyBySelector(o ObjectSelector, r Relationsh
 	m.ObjectSelectors[k] = o
 }
 
+// AddDependencyBySubject adds a subject (e.g. external webhook endpoints) which
+// isn't backed by any object in the cluster as a dependency. Subjects are
+// included in the relationship tree as synthetic objects.
+func (m *RelationshipMap) AddDependencyBySubject(o ObjectReference, r Relationship) {
+	k := o.Key()
+	if _, ok := m.DependenciesBySubject[k]; !ok {
+		m.DependenciesBySubject[k] = RelationshipSet{}
+	}
+	m.DependenciesBySubject[k][r] = struct{}{}
+	m.Subjects[k] = o
+}
+
 func (m *RelationshipMap) AddDependencyByUID(uid types.UID, r Relationship) {
 	if _, ok := m.DependenciesByUID[uid]; !ok {
 		m.DependenciesByUID[uid] = RelationshipSet{}
@@ -187,6 +275,30 @@ func (m *RelationshipMap) AddDependentBySelector(o ObjectSelector, r Relationshi
 	m.ObjectSelectors[k] = o
 }
 
+// AddDependentByReference adds the referenced object as a dependent. Unlike
+// AddDependentByKey, objects that don't exist in the cluster are included in
+// the relationship tree as synthetic objects marked as not found.
+func (m *RelationshipMap) AddDependentByReference(o ObjectReference, r Relationship) {
+	k := o.Key()
+	if _, ok := m.DependentsByReference[k]; !ok {
+		m.DependentsByReference[k] = RelationshipSet{}
+	}
+	m.DependentsByReference[k][r] = struct{}{}
+	m.References[k] = o
+}
+
+// AddDependentBySubject adds a subject (e.g. RBAC users & groups) which isn't
+// backed by any object in the cluster as a dependent. Subjects are included in
+// the relationship tree as synthetic objects.
+func (m *RelationshipMap) AddDependentBySubject(o ObjectReference, r Relationship) {
+	k := o.Key()
+	if _, ok := m.DependentsBySubject[k]; !ok {
+		m.DependentsBySubject[k] = RelationshipSet{}
+	}
+	m.DependentsBySubject[k][r] = struct{}{}
+	m.Subjects[k] = o
+}
+
 func (m *RelationshipMap) AddDependentByUID(uid types.UID, r Relationship) {
 	if _, ok := m.DependentsByUID[uid]; !ok {
 		m.DependentsByUID[uid] = RelationshipSet{}
@@ -209,6 +321,18 @@ type Node struct {
 	Dependencies    map[types.UID]RelationshipSet
 	Dependents      map[types.UID]RelationshipSet
 	Depth           uint
+	// PassThrough is true if the object doesn't match the filters applied to
+	// the relationship tree & is only kept to connect objects that do.
+	PassThrough bool
+	// NotFound is true if the object is referenced by another object but
+	// doesn't exist in the cluster.
+	NotFound bool
+	// HiddenCompletedPods is the number of completed Pods removed from the
+	// dependencies or dependents of the object.
+	HiddenCompletedPods int
+	// Cluster is the name of the cluster the object lives in, if it isn't the
+	// cluster the relationship tree was built from.
+	Cluster string
 }
 
 func (n *Node) AddDependency(uid types.UID, r Relationship) {
@@ -270,7 +394,7 @@ func (n NodeList) Len() int {
 }
 
 func (n NodeList) Less(i, j int) bool {
-	// Sort nodes in following order: Namespace, Kind, Group, Name
+	// Sort nodes in following order: Namespace, Kind, Group, Name, Version, UID
 	a, b := n[i], n[j]
 	if a.Namespace != b.Namespace {
 		return a.Namespace < b.Namespace
@@ -281,7 +405,13 @@ func (n NodeList) Less(i, j int) bool {
 	if a.Group != b.Group {
 		return a.Group < b.Group
 	}
-	return a.Name < b.Name
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	if a.Version != b.Version {
+		return a.Version < b.Version
+	}
+	return a.UID < b.UID
 }
 
 func (n NodeList) Swap(i, j int) {
@@ -291,22 +421,547 @@ func (n NodeList) Swap(i, j int) {
 // NodeMap contains a relationship tree stored as a map of nodes.
 type NodeMap map[types.UID]*Node
 
+// UnlimitedDepth is the maximum depth that includes every object of a
+// relationship tree, while a maximum depth of 0 only includes its root objects.
+const UnlimitedDepth = ^uint(0)
+
+// CountWithinDepth returns the number of objects in the relationship tree that
+// are within the provided maximum depth, see UnlimitedDepth.
+func (m NodeMap) CountWithinDepth(maxDepth uint) int {
+	count := 0
+	for _, node := range m {
+		if node.Depth <= maxDepth {
+			count++
+		}
+	}
+	return count
+}
+
+// ExcludeGroupKinds removes every object of the provided GroupKinds from the
+// relationship tree, except the root objects. The dependencies or dependents
+// of removed objects are reparented to their nearest remaining ancestor.
+func (m NodeMap) ExcludeGroupKinds(rootUIDs []types.UID, gkSet map[schema.GroupKind]struct{}, depsIsDependencies bool) {
+	if len(gkSet) == 0 {
+		return
+	}
+	rootUIDSet := newUIDSet(rootUIDs)
+	isExcluded := func(uid types.UID) bool {
+		node, ok := m[uid]
+		if !ok {
+			return false
+		}
+		if _, ok := rootUIDSet[uid]; ok {
+			return false
+		}
+		_, ok = gkSet[schema.GroupKind{Group: node.Group, Kind: node.Kind}]
+		return ok
+	}
+
+	// Find the remaining objects reachable from the provided node by passing
+	// through removed objects only
+	var collectFn func(node *Node, result map[types.UID]RelationshipSet, visited map[types.UID]struct{})
+	collectFn = func(node *Node, result map[types.UID]RelationshipSet, visited map[types.UID]struct{}) {
+		for depUID, rset := range node.GetDeps(depsIsDependencies) {
+			if !isExcluded(depUID) {
+				if _, ok := result[depUID]; !ok {
+					result[depUID] = RelationshipSet{}
+				}
+				for r := range rset {
+					result[depUID][r] = struct{}{}
+				}
+				continue
+			}
+			if _, ok := visited[depUID]; ok {
+				continue
+			}
+			visited[depUID] = struct{}{}
+			collectFn(m[depUID], result, visited)
+		}
+	}
+	newDepsMap := map[types.UID]map[types.UID]RelationshipSet{}
+	for uid, node := range m {
+		if isExcluded(uid) {
+			continue
+		}
+		deps := map[types.UID]RelationshipSet{}
+		collectFn(node, deps, map[types.UID]struct{}{})
+		delete(deps, uid)
+		newDepsMap[uid] = deps
+	}
+
+	for uid, node := range m {
+		deps, ok := newDepsMap[uid]
+		if !ok {
+			delete(m, uid)
+			continue
+		}
+		if depsIsDependencies {
+			node.Dependencies = deps
+		} else {
+			node.Dependents = deps
+		}
+	}
+}
+
+// ExcludeRelationships removes every relationship of the provided types from
+// the relationship tree, along with the objects that are no longer reachable
+// from any root object.
+func (m NodeMap) ExcludeRelationships(rootUIDs []types.UID, rset RelationshipSet, depsIsDependencies bool) {
+	if len(rset) == 0 {
+		return
+	}
+	for _, node := range m {
+		for _, deps := range []map[types.UID]RelationshipSet{node.Dependencies, node.Dependents} {
+			for depUID, rs := range deps {
+				for r := range rset {
+					delete(rs, r)
+				}
+				if len(rs) == 0 {
+					delete(deps, depUID)
+				}
+			}
+		}
+	}
+	m.removeUnreachable(rootUIDs, depsIsDependencies)
+}
+
+// RestrictToNamespaces removes every namespaced object that isn't in one of
+// the provided namespaces from the relationship tree, except the root objects,
+// along with the objects only reachable through them. Cluster-scoped objects
+// are kept.
+func (m NodeMap) RestrictToNamespaces(rootUIDs []types.UID, nsSet map[string]struct{}, depsIsDependencies bool) {
+	rootUIDSet := newUIDSet(rootUIDs)
+	removedSet := map[types.UID]struct{}{}
+	for uid, node := range m {
+		if _, isRoot := rootUIDSet[uid]; isRoot || !node.Namespaced {
+			continue
+		}
+		if _, ok := nsSet[node.Namespace]; !ok {
+			removedSet[uid] = struct{}{}
+		}
+	}
+	if len(removedSet) == 0 {
+		return
+	}
+	for uid := range removedSet {
+		delete(m, uid)
+	}
+	for _, node := range m {
+		for _, deps := range []map[types.UID]RelationshipSet{node.Dependencies, node.Dependents} {
+			for depUID := range deps {
+				if _, ok := removedSet[depUID]; ok {
+					delete(deps, depUID)
+				}
+			}
+		}
+	}
+	m.removeUnreachable(rootUIDs, depsIsDependencies)
+}
+
+// HideCompletedPods removes every Pod that ran to completion (ie. in the
+// "Succeeded" phase) from the relationship tree, except the root objects, along
+// with the objects only reachable through them. The number of Pods removed from
+// the dependencies or dependents of each object is recorded in its
+// HiddenCompletedPods field.
+func (m NodeMap) HideCompletedPods(rootUIDs []types.UID, depsIsDependencies bool) {
+	rootUIDSet := newUIDSet(rootUIDs)
+	hiddenSet := map[types.UID]struct{}{}
+	for uid, node := range m {
+		if _, isRoot := rootUIDSet[uid]; isRoot || node.Unstructured == nil {
+			continue
+		}
+		if node.Group == corev1.GroupName && node.Kind == "Pod" && node.GetNestedString("status", "phase") == string(corev1.PodSucceeded) {
+			hiddenSet[uid] = struct{}{}
+		}
+	}
+	if len(hiddenSet) == 0 {
+		return
+	}
+	for uid := range hiddenSet {
+		delete(m, uid)
+	}
+	for _, node := range m {
+		deps := node.GetDeps(depsIsDependencies)
+		for depUID := range deps {
+			if _, ok := hiddenSet[depUID]; ok {
+				delete(deps, depUID)
+				node.HiddenCompletedPods++
+			}
+		}
+	}
+
+	m.removeUnreachable(rootUIDs, depsIsDependencies)
+}
+
+// removeUnreachable removes every object that is no longer reachable from any
+// root object & updates the depth of the remaining objects, since the shortest
+// path to them may have been removed.
+func (m NodeMap) removeUnreachable(rootUIDs []types.UID, depsIsDependencies bool) {
+	depthMap, uidQueue := map[types.UID]uint{}, []types.UID{}
+	for _, uid := range rootUIDs {
+		if _, ok := m[uid]; ok {
+			depthMap[uid] = 0
+			uidQueue = append(uidQueue, uid)
+		}
+	}
+	for len(uidQueue) > 0 {
+		uid := uidQueue[0]
+		uidQueue = uidQueue[1:]
+		for depUID := range m[uid].GetDeps(depsIsDependencies) {
+			if _, ok := m[depUID]; !ok {
+				continue
+			}
+			if _, ok := depthMap[depUID]; ok {
+				continue
+			}
+			depthMap[depUID] = depthMap[uid] + 1
+			uidQueue = append(uidQueue, depUID)
+		}
+	}
+	for uid, node := range m {
+		depth, ok := depthMap[uid]
+		if !ok {
+			delete(m, uid)
+			continue
+		}
+		node.Depth = depth
+	}
+}
+
+// FilterByGroupKinds removes every object from the relationship tree that
+// isn't of the provided GroupKinds, unless it is a root object or a path from
+// a root object to a matching object passes through it. Objects kept only to
+// preserve such paths are marked as pass-through.
+func (m NodeMap) FilterByGroupKinds(rootUIDs []types.UID, gkSet map[schema.GroupKind]struct{}, depsIsDependencies bool) {
+	if len(gkSet) == 0 {
+		return
+	}
+	m.filter(rootUIDs, func(node *Node) bool {
+		_, ok := gkSet[schema.GroupKind{Group: node.Group, Kind: node.Kind}]
+		return ok
+	}, depsIsDependencies)
+}
+
+// FilterByLabelSelector removes every object from the relationship tree whose
+// labels don't match the provided selector, unless it is a root object or a
+// path from a root object to a matching object passes through it. Objects
+// kept only to preserve such paths are marked as pass-through.
+func (m NodeMap) FilterByLabelSelector(rootUIDs []types.UID, selector labels.Selector, depsIsDependencies bool) {
+	if selector == nil || selector.Empty() {
+		return
+	}
+	m.filter(rootUIDs, func(node *Node) bool {
+		return selector.Matches(labels.Set(node.GetLabels()))
+	}, depsIsDependencies)
+}
+
+// FilterByCreationTime removes every object from the relationship tree that
+// was created before the provided time, or at or after it if older is true,
+// unless it is a root object or a path from a root object to a matching object
+// passes through it. Objects kept only to preserve such paths are marked as
+// pass-through.
+func (m NodeMap) FilterByCreationTime(rootUIDs []types.UID, t time.Time, older bool, depsIsDependencies bool) {
+	if t.IsZero() {
+		return
+	}
+	m.filter(rootUIDs, func(node *Node) bool {
+		return node.GetCreationTimestamp().Time.Before(t) == older
+	}, depsIsDependencies)
+}
+
+// FilterByUIDs removes every object from the relationship tree that doesn't
+// have one of the provided UIDs, unless it is a root object or a path from a
+// root object to a matching object passes through it. Objects kept only to
+// preserve such paths are marked as pass-through.
+func (m NodeMap) FilterByUIDs(rootUIDs []types.UID, uids []types.UID, depsIsDependencies bool) {
+	uidSet := newUIDSet(uids)
+	m.filter(rootUIDs, func(node *Node) bool {
+		_, ok := uidSet[node.UID]
+		return ok
+	}, depsIsDependencies)
+}
+
+// filter removes every object from the relationship tree that doesn't match
+// the provided function, unless it is a root object or a path from a root
+// object to a matching object passes through it. Objects kept only to preserve
+// such paths are marked as pass-through.
+func (m NodeMap) filter(rootUIDs []types.UID, matchFn func(node *Node) bool, depsIsDependencies bool) {
+	// Track the reverse relationships of every object in the relationship tree
+	parents := map[types.UID][]types.UID{}
+	for uid, node := range m {
+		for depUID := range node.GetDeps(depsIsDependencies) {
+			parents[depUID] = append(parents[depUID], uid)
+		}
+	}
+
+	// Keep matching objects & every object that leads to them
+	rootUIDSet := newUIDSet(rootUIDs)
+	keepSet, uidQueue := newUIDSet(rootUIDs), []types.UID{}
+	for uid, node := range m {
+		if _, isRoot := rootUIDSet[uid]; !isRoot && matchFn(node) {
+			uidQueue = append(uidQueue, uid)
+		}
+	}
+	matchSet := map[types.UID]struct{}{}
+	for _, uid := range uidQueue {
+		matchSet[uid] = struct{}{}
+	}
+	for len(uidQueue) > 0 {
+		uid := uidQueue[0]
+		uidQueue = uidQueue[1:]
+		if _, ok := keepSet[uid]; ok {
+			continue
+		}
+		keepSet[uid] = struct{}{}
+		uidQueue = append(uidQueue, parents[uid]...)
+	}
+
+	for uid, node := range m {
+		if _, ok := keepSet[uid]; !ok {
+			delete(m, uid)
+			continue
+		}
+		_, isMatch := matchSet[uid]
+		if _, isRoot := rootUIDSet[uid]; !isMatch && !isRoot {
+			node.PassThrough = true
+		}
+	}
+	for _, node := range m {
+		deps := node.GetDeps(depsIsDependencies)
+		for depUID := range deps {
+			if _, ok := keepSet[depUID]; !ok {
+				delete(deps, depUID)
+			}
+		}
+	}
+}
+
+// ShortestPath returns the UIDs of the objects along the shortest path from the
+// object with the provided "from" UID to the object with the provided "to"
+// UID, following either the dependencies or dependents of each object. Both
+// objects are included in the returned path, which is nil if there's no such
+// path. Objects are visited in NodeList order, so that the same path is always
+// returned when there are several shortest paths.
+func (m NodeMap) ShortestPath(from, to types.UID, depsIsDependencies bool) []types.UID {
+	if _, ok := m[from]; !ok {
+		return nil
+	}
+	if _, ok := m[to]; !ok {
+		return nil
+	}
+
+	parents := map[types.UID]types.UID{from: ""}
+	uidQueue := []types.UID{from}
+	for len(uidQueue) > 0 && uidQueue[0] != to {
+		uid := uidQueue[0]
+		uidQueue = uidQueue[1:]
+		deps := NodeList{}
+		for depUID := range m[uid].GetDeps(depsIsDependencies) {
+			if depNode, ok := m[depUID]; ok {
+				deps = append(deps, depNode)
+			}
+		}
+		sort.Sort(deps)
+		for _, depNode := range deps {
+			if _, ok := parents[depNode.UID]; ok {
+				continue
+			}
+			parents[depNode.UID] = uid
+			uidQueue = append(uidQueue, depNode.UID)
+		}
+	}
+	if _, ok := parents[to]; !ok {
+		return nil
+	}
+
+	path := []types.UID{}
+	for uid := to; uid != from; uid = parents[uid] {
+		path = append(path, uid)
+	}
+	path = append(path, from)
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// newUIDSet returns a set of the provided UIDs.
+func newUIDSet(uids []types.UID) map[types.UID]struct{} {
+	s := make(map[types.UID]struct{}, len(uids))
+	for _, uid := range uids {
+		s[uid] = struct{}{}
+	}
+	return s
+}
+
+// ErrTooManyNodes is returned by ResolveDependencies & ResolveDependents when
+// the relationship tree has more objects than ResolveOptions.MaxNodes.
+var ErrTooManyNodes = errors.New("relationship tree has too many objects")
+
+// ResolveOptions configures how ResolveDependencies & ResolveDependents
+// discover relationships. The zero value only discovers relationships that
+// don't need to be configured & doesn't limit the size of the relationship
+// tree.
+type ResolveOptions struct {
+	// GitOpsTracking configures how the objects managed by GitOps tools are
+	// discovered.
+	GitOpsTracking GitOpsTracking
+	// IsListedFn returns true if every object of the provided resource type in
+	// the provided namespace (empty for cluster-scoped objects) was listed, so
+	// that objects of that type which aren't found are known not to exist.
+	// Every object is assumed to be listed if nil.
+	IsListedFn func(gk schema.GroupKind, ns string) bool
+	// MaxNodes is the maximum number of objects within MaxDepth of the
+	// relationship tree, resolving is aborted as soon as it's exceeded. No
+	// limit is applied if set to 0.
+	MaxNodes uint
+	// MaxDepth is the maximum depth of the objects counted towards MaxNodes,
+	// see UnlimitedDepth.
+	MaxDepth uint
+}
+
 // ResolveDependencies resolves all dependencies of the provided objects and
 // returns a relationship tree.
-func ResolveDependencies(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID) (NodeMap, error) {
-	return resolveDeps(m, objects, uids, true)
+func ResolveDependencies(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID, opts ResolveOptions) (NodeMap, error) {
+	return resolveDepsWithOptions(m, objects, uids, true, opts)
 }
 
 // ResolveDependents resolves all dependents of the provided objects and returns
 // a relationship tree.
-func ResolveDependents(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID) (NodeMap, error) {
-	return resolveDeps(m, objects, uids, false)
+func ResolveDependents(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID, opts ResolveOptions) (NodeMap, error) {
+	return resolveDepsWithOptions(m, objects, uids, false, opts)
+}
+
+// AttachEvents finds the Events of every object in the relationship tree from
+// the provided objects & attaches them to the object they reference. Events
+// are attached without any dependencies or dependents of their own.
+func (m NodeMap) AttachEvents(mapper meta.RESTMapper, objects []unstructuredv1.Unstructured, depsIsDependencies bool) error {
+	uids := make([]types.UID, 0, len(m))
+	for uid := range m {
+		uids = append(uids, uid)
+	}
+	dependentMap, err := resolveDeps(mapper, objects, uids, false)
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range uids {
+		node, dn := m[uid], dependentMap[uid]
+		if dn == nil {
+			continue
+		}
+		for evUID, rset := range dn.Dependents {
+			ev := dependentMap[evUID]
+			if ev == nil || ev.Kind != "Event" || (ev.Group != corev1.GroupName && ev.Group != eventsv1.GroupName) {
+				continue
+			}
+			if _, ok := m[evUID]; !ok {
+				ev.Dependencies = map[types.UID]RelationshipSet{}
+				ev.Dependents = map[types.UID]RelationshipSet{}
+				ev.Depth = node.Depth + 1
+				m[evUID] = ev
+			}
+			for r := range rset {
+				if depsIsDependencies {
+					node.AddDependency(evUID, r)
+				} else {
+					node.AddDependent(evUID, r)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// newSubjectNode returns a synthetic node representing the provided subject.
+// The node isn't backed by any object & uses the subject's reference key as its
+// UID. Its version is resolved with the provided REST mapper if the subject's
+// kind is an API resource of the cluster, or defaults to "v1" otherwise (e.g.
+// RBAC Users & Groups).
+func newSubjectNode(m meta.RESTMapper, o ObjectReference) *Node {
+	version, resource := "v1", ""
+	if len(o.Cluster) == 0 {
+		if mapping, err := m.RESTMapping(schema.GroupKind{Group: o.Group, Kind: o.Kind}); err == nil {
+			version, resource = mapping.Resource.Version, mapping.Resource.Resource
+		}
+	}
+	u := &unstructuredv1.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(schema.GroupVersion{Group: o.Group, Version: version}.String())
+	u.SetKind(o.Kind)
+	u.SetNamespace(o.Namespace)
+	u.SetName(o.Name)
+	u.SetUID(types.UID(o.Key()))
+	return &Node{
+		Unstructured: u,
+		UID:          u.GetUID(),
+		Group:        o.Group,
+		Version:      version,
+		Resource:     resource,
+		Kind:         o.Kind,
+		Namespaced:   o.Namespace != "",
+		Namespace:    o.Namespace,
+		Name:         o.Name,
+		Dependencies: map[types.UID]RelationshipSet{},
+		Dependents:   map[types.UID]RelationshipSet{},
+		Cluster:      o.Cluster,
+	}
+}
+
+// newNotFoundNode returns a synthetic node representing the provided object
+// reference which doesn't exist in the cluster.
+func newNotFoundNode(m meta.RESTMapper, o ObjectReference) *Node {
+	n := newSubjectNode(m, o)
+	n.NotFound = true
+	return n
+}
+
+// newNotFoundOwnerNode returns a synthetic node representing the provided
+// owner reference of the provided node, which doesn't exist in the cluster.
+// Returns nil if the owner's kind can't be mapped to an API resource.
+func newNotFoundOwnerNode(m meta.RESTMapper, node *Node, ref metav1.OwnerReference) *Node {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil
+	}
+	mapping, err := m.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: ref.Kind}, gv.Version)
+	if err != nil {
+		klog.V(4).Infof("Failed to map owner resource \"%s\" to GVR", gv.WithKind(ref.Kind))
+		return nil
+	}
+	// Owners must either be cluster-scoped or in the same namespace as their
+	// dependents
+	var ns string
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ns = node.Namespace
+	}
+	n := newNotFoundNode(m, ObjectReference{Group: gv.Group, Kind: ref.Kind, Namespace: ns, Name: ref.Name})
+	n.SetAPIVersion(ref.APIVersion)
+	n.SetUID(ref.UID)
+	n.UID = ref.UID
+	n.Version = gv.Version
+	n.Resource = mapping.Resource.Resource
+	return n
+}
+
+// isListed returns true if every object of the provided resource type in the
+// provided namespace was listed, see IsListedFn.
+func (opts ResolveOptions) isListed(gk schema.GroupKind, ns string) bool {
+	return opts.IsListedFn == nil || opts.IsListedFn(gk, ns)
 }
 
 // resolveDeps resolves all dependencies or dependents of the provided objects
-// and returns a relationship tree.
-//nolint:funlen,gocognit,gocyclo
+// and returns a relationship tree. The traversal only follows relationships in
+// one direction, so the owners of the provided objects (or their dependents
+// when resolving dependencies) & their other subtrees are never included.
 func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID, depsIsDependencies bool) (NodeMap, error) {
+	return resolveDepsWithOptions(m, objects, uids, depsIsDependencies, ResolveOptions{})
+}
+
+// resolveDepsWithOptions is like resolveDeps, but discovers relationships as
+// configured by the provided options.
+//nolint:funlen,gocognit,gocyclo
+func resolveDepsWithOptions(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID, depsIsDependencies bool, opts ResolveOptions) (NodeMap, error) {
 	if len(uids) == 0 {
 		return NodeMap{}, nil
 	}
@@ -358,13 +1013,35 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 		}
 	}
 
+	// Namespaces are automatically labeled with their name, so fallback to that
+	// label if the namespace object wasn't fetched
+	namespaceLabels := map[string]labels.Set{}
+	for _, n := range globalMapByUID {
+		if n.Group == corev1.GroupName && n.Kind == "Namespace" {
+			namespaceLabels[n.Name] = labels.Set(n.GetLabels())
+		}
+	}
+	getNamespaceLabels := func(ns string) labels.Set {
+		if lbls, ok := namespaceLabels[ns]; ok {
+			return lbls
+		}
+		return labels.Set{corev1.LabelMetadataName: ns}
+	}
 	resolveLabelSelectorToNodes := func(o ObjectLabelSelector) []*Node {
 		var result []*Node
 		for _, n := range globalMapByUID {
-			if n.Group == o.Group && n.Kind == o.Kind && n.Namespace == o.Namespace {
-				if ok := o.Selector.Matches(labels.Set(n.GetLabels())); ok {
-					result = append(result, n)
+			if n.Group != o.Group || n.Kind != o.Kind {
+				continue
+			}
+			if o.NamespaceSelector != nil {
+				if !o.NamespaceSelector.Matches(getNamespaceLabels(n.Namespace)) {
+					continue
 				}
+			} else if n.Namespace != o.Namespace {
+				continue
+			}
+			if ok := o.Selector.Matches(labels.Set(n.GetLabels())); ok {
+				result = append(result, n)
 			}
 		}
 		return result
@@ -453,25 +1130,116 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 				}
 			}
 		}
+		for k, rset := range rmap.DependenciesByReference {
+			n, ok := globalMapByKey[k]
+			if !ok {
+				// Skip references to objects which may exist but weren't listed
+				o, ok := rmap.References[k]
+				if !ok || !opts.isListed(schema.GroupKind{Group: o.Group, Kind: o.Kind}, o.Namespace) {
+					continue
+				}
+				klog.V(4).Infof("%s \"%s\" referenced by %s \"%s\" in namespace \"%s\" not found", o.Kind, o.Name, node.Kind, node.Name, node.Namespace)
+				n = newNotFoundNode(m, o)
+				globalMapByUID[n.UID] = n
+				globalMapByKey[k] = n
+			}
+			for r := range rset {
+				node.AddDependency(n.UID, r)
+				n.AddDependent(node.UID, r)
+			}
+		}
+		for k, rset := range rmap.DependentsByReference {
+			n, ok := globalMapByKey[k]
+			if !ok {
+				// Skip references to objects which may exist but weren't listed
+				o, ok := rmap.References[k]
+				if !ok || !opts.isListed(schema.GroupKind{Group: o.Group, Kind: o.Kind}, o.Namespace) {
+					continue
+				}
+				klog.V(4).Infof("%s \"%s\" referenced by %s \"%s\" in namespace \"%s\" not found", o.Kind, o.Name, node.Kind, node.Name, node.Namespace)
+				n = newNotFoundNode(m, o)
+				globalMapByUID[n.UID] = n
+				globalMapByKey[k] = n
+			}
+			for r := range rset {
+				n.AddDependency(node.UID, r)
+				node.AddDependent(n.UID, r)
+			}
+		}
+		for k, rset := range rmap.DependenciesBySubject {
+			n, ok := globalMapByKey[k]
+			if !ok {
+				o, ok := rmap.Subjects[k]
+				if !ok {
+					continue
+				}
+				n = newSubjectNode(m, o)
+				globalMapByUID[n.UID] = n
+				globalMapByKey[k] = n
+			}
+			for r := range rset {
+				node.AddDependency(n.UID, r)
+				n.AddDependent(node.UID, r)
+			}
+		}
+		for k, rset := range rmap.DependentsBySubject {
+			n, ok := globalMapByKey[k]
+			if !ok {
+				o, ok := rmap.Subjects[k]
+				if !ok {
+					continue
+				}
+				n = newSubjectNode(m, o)
+				globalMapByUID[n.UID] = n
+				globalMapByKey[k] = n
+			}
+			for r := range rset {
+				n.AddDependency(node.UID, r)
+				node.AddDependent(n.UID, r)
+			}
+		}
 	}
 
-	// Populate dependencies & dependents based on Owner-Dependent relationships
+	// Populate dependencies & dependents based on Owner-Dependent relationships.
+	// Owners that don't exist (e.g. deleted while the objects were being
+	// listed) are replaced with placeholders so that their dependents are
+	// still connected to the relationship tree, unless their resource type
+	// wasn't listed in full (e.g. excluded or forbidden from being listed)
+	nodes := make([]*Node, 0, len(globalMapByUID))
 	for _, node := range globalMapByUID {
+		nodes = append(nodes, node)
+	}
+	for _, node := range nodes {
 		for _, ref := range node.OwnerReferences {
-			if n, ok := globalMapByUID[ref.UID]; ok {
-				if ref.Controller != nil && *ref.Controller {
-					node.AddDependency(n.UID, RelationshipControllerRef)
-					n.AddDependent(node.UID, RelationshipControllerRef)
+			n, ok := globalMapByUID[ref.UID]
+			if !ok {
+				n = newNotFoundOwnerNode(m, node, ref)
+				if n == nil || !opts.isListed(schema.GroupKind{Group: n.Group, Kind: n.Kind}, n.Namespace) {
+					continue
 				}
-				node.AddDependency(n.UID, RelationshipOwnerRef)
-				n.AddDependent(node.UID, RelationshipOwnerRef)
+				klog.V(4).Infof("%s \"%s\" owning %s \"%s\" in namespace \"%s\" not found", n.Kind, n.Name, node.Kind, node.Name, node.Namespace)
+				globalMapByUID[n.UID] = n
+				globalMapByKey[n.GetObjectReferenceKey()] = n
+			}
+			if ref.Controller != nil && *ref.Controller {
+				node.AddDependency(n.UID, RelationshipControllerRef)
+				n.AddDependent(node.UID, RelationshipControllerRef)
 			}
+			node.AddDependency(n.UID, RelationshipOwnerRef)
+			n.AddDependent(node.UID, RelationshipOwnerRef)
 		}
 	}
 
+	// Subjects & objects that aren't found are added to the global maps while
+	// populating relationships, so iterate over a snapshot of the nodes since
+	// entries added to a map during iteration may or may not be visited
+	nodes = nodes[:0]
+	for _, node := range globalMapByUID {
+		nodes = append(nodes, node)
+	}
 	var rmap *RelationshipMap
 	var err error
-	for _, node := range globalMapByUID {
+	for _, node := range nodes {
 		switch {
 		// Populate dependencies & dependents based on PersistentVolume relationships
 		case node.Group == corev1.GroupName && node.Kind == "PersistentVolume":
@@ -494,6 +1262,13 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 				klog.V(4).Infof("Failed to get relationships for pod named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
 				continue
 			}
+		// Populate dependencies & dependents based on Secret relationships
+		case node.Group == corev1.GroupName && node.Kind == "Secret":
+			rmap, err = getSecretRelationships(node)
+			if err != nil {
+				klog.V(4).Infof("Failed to get relationships for secret named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
+				continue
+			}
 		// Populate dependencies & dependents based on Service relationships
 		case node.Group == corev1.GroupName && node.Kind == "Service":
 			rmap, err = getServiceRelationships(node)
@@ -543,6 +1318,13 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 				klog.V(4).Infof("Failed to get relationships for apiservice named \"%s\": %s", node.Name, err)
 				continue
 			}
+		// Populate dependencies & dependents based on CustomResourceDefinition relationships
+		case node.Group == apiextensionsv1.GroupName && node.Kind == "CustomResourceDefinition":
+			rmap, err = getCustomResourceDefinitionRelationships(node)
+			if err != nil {
+				klog.V(4).Infof("Failed to get relationships for customresourcedefinition named \"%s\": %s", node.Name, err)
+				continue
+			}
 		// Populate dependencies & dependents based on Event relationships
 		case (node.Group == eventsv1.GroupName || node.Group == corev1.GroupName) && node.Kind == "Event":
 			rmap, err = getEventRelationships(node)
@@ -550,6 +1332,20 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 				klog.V(4).Infof("Failed to get relationships for event named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
 				continue
 			}
+		// Populate dependencies & dependents based on Job relationships
+		case node.Group == batchv1.GroupName && node.Kind == "Job":
+			rmap, err = getJobRelationships(node)
+			if err != nil {
+				klog.V(4).Infof("Failed to get relationships for job named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
+				continue
+			}
+		// Populate dependencies & dependents based on HorizontalPodAutoscaler relationships
+		case node.Group == autoscalingv1.GroupName && node.Kind == "HorizontalPodAutoscaler":
+			rmap, err = getHorizontalPodAutoscalerRelationships(node)
+			if err != nil {
+				klog.V(4).Infof("Failed to get relationships for horizontalpodautoscaler named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
+				continue
+			}
 		// Populate dependencies & dependents based on Ingress relationships
 		case (node.Group == networkingv1.GroupName || node.Group == extensionsv1beta1.GroupName) && node.Kind == "Ingress":
 			rmap, err = getIngressRelationships(node)
@@ -634,12 +1430,100 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 				klog.V(4).Infof("Failed to get relationships for volumeattachment named \"%s\": %s: %s", node.Name, err)
 				continue
 			}
+		// Populate dependencies & dependents based on Gateway relationships
+		case node.Group == GatewayAPIGroupName && node.Kind == "Gateway":
+			rmap, err = getGatewayRelationships(node)
+			if err != nil {
+				klog.V(4).Infof("Failed to get relationships for gateway named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
+				continue
+			}
+		// Populate dependencies & dependents based on HTTPRoute relationships
+		case node.Group == GatewayAPIGroupName && node.Kind == "HTTPRoute":
+			rmap, err = getHTTPRouteRelationships(node)
+			if err != nil {
+				klog.V(4).Infof("Failed to get relationships for httproute named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
+				continue
+			}
+		// Populate dependencies & dependents based on Certificate relationships
+		case node.Group == CertManagerGroupName && node.Kind == "Certificate":
+			rmap, err = getCertificateRelationships(node)
+			if err != nil {
+				klog.V(4).Infof("Failed to get relationships for certificate named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
+				continue
+			}
+		// Populate dependencies & dependents based on CertificateRequest relationships
+		case node.Group == CertManagerGroupName && node.Kind == "CertificateRequest":
+			rmap, err = getCertificateRequestRelationships(node)
+			if err != nil {
+				klog.V(4).Infof("Failed to get relationships for certificaterequest named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
+				continue
+			}
+		// Populate dependencies & dependents based on Machine relationships
+		case node.Group == ClusterAPIGroupName && node.Kind == "Machine":
+			rmap, err = getMachineRelationships(node)
+			if err != nil {
+				klog.V(4).Infof("Failed to get relationships for machine named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
+				continue
+			}
 		default:
 			continue
 		}
 		updateRelationships(node, rmap)
 	}
 
+	// Populate Service-Pod relationships based on the Pods targeted by the
+	// endpoints of each Service, which also includes Pods that no longer match
+	// the Service's selector & Pods of Services with manually managed
+	// endpoints. EndpointSlices are preferred, Endpoints are only used for
+	// Services without any EndpointSlices (eg. on clusters that don't serve
+	// the EndpointSlice API)
+	addServiceEndpointPods := func(svcRef *ObjectReference, targets []ObjectReference) {
+		svc, ok := globalMapByKey[svcRef.Key()]
+		if !ok {
+			return
+		}
+		for _, t := range targets {
+			if pod, ok := globalMapByKey[t.Key()]; ok {
+				svc.AddDependency(pod.UID, RelationshipServiceEndpointPod)
+				pod.AddDependent(svc.UID, RelationshipServiceEndpointPod)
+			}
+		}
+	}
+	var endpoints []*Node
+	endpointSliceServices := map[ObjectReferenceKey]struct{}{}
+	for _, node := range globalMapByUID {
+		switch {
+		case node.Group == corev1.GroupName && node.Kind == "Endpoints":
+			endpoints = append(endpoints, node)
+		case node.Group == discoveryv1.GroupName && node.Kind == "EndpointSlice":
+			svcRef, targets, err := getEndpointSliceTargets(node)
+			if err != nil {
+				klog.V(4).Infof("Failed to get targets for endpointslice named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
+				continue
+			}
+			if svcRef == nil {
+				continue
+			}
+			endpointSliceServices[svcRef.Key()] = struct{}{}
+			addServiceEndpointPods(svcRef, targets)
+		}
+	}
+	for _, node := range endpoints {
+		svcRef, targets, err := getEndpointsTargets(node)
+		if err != nil {
+			klog.V(4).Infof("Failed to get targets for endpoints named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
+			continue
+		}
+		if _, ok := endpointSliceServices[svcRef.Key()]; ok {
+			continue
+		}
+		addServiceEndpointPods(svcRef, targets)
+	}
+
+	// Populate dependencies & dependents based on the tracking labels &
+	// annotations of the objects managed by GitOps tools
+	addGitOpsRelationships(globalMapByUID, globalMapByKey, opts.GitOpsTracking)
+
 	// Create submap containing the provided objects & either their dependencies
 	// or dependents from the global map
 	var depth uint
@@ -650,6 +1534,15 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 			uidQueue = append(uidQueue, uid)
 		}
 	}
+	// Objects are added to the submap in breadth-first order, so objects are
+	// added with their smallest depth & can be counted as soon as they're added
+	count := len(nodeMap)
+	tooManyNodes := func() bool {
+		return opts.MaxNodes > 0 && count > int(opts.MaxNodes)
+	}
+	if tooManyNodes() {
+		return nil, fmt.Errorf("%w, exceeding the maximum of %d objects", ErrTooManyNodes, opts.MaxNodes)
+	}
 	depth, uidQueue = 0, append(uidQueue, "")
 	for {
 		if len(uidQueue) <= 1 {
@@ -677,11 +1570,20 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 				node.Depth = depth
 			}
 			deps := node.GetDeps(depsIsDependencies)
-			depUIDs, ix := make([]types.UID, len(deps)), 0
+			depUIDs := make([]types.UID, 0, len(deps))
 			for depUID := range deps {
-				nodeMap[depUID] = globalMapByUID[depUID]
-				depUIDs[ix] = depUID
-				ix++
+				n := globalMapByUID[depUID]
+				if n == nil {
+					continue
+				}
+				if _, ok := nodeMap[depUID]; !ok && depth < opts.MaxDepth {
+					count++
+				}
+				nodeMap[depUID] = n
+				depUIDs = append(depUIDs, depUID)
+			}
+			if tooManyNodes() {
+				return nil, fmt.Errorf("%w, exceeding the maximum of %d objects", ErrTooManyNodes, opts.MaxNodes)
 			}
 			uidQueue = append(uidQueue[1:], depUIDs...)
 		}
@@ -0,0 +1,1584 @@
+package graph
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newTestRESTMapper() meta.RESTMapper {
+	m := meta.NewDefaultRESTMapper(nil)
+	m.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Endpoints"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Event"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Node"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "PersistentVolume"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "PersistentVolumeClaim"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "acme.cert-manager.io", Version: "v1", Kind: "Challenge"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "acme.cert-manager.io", Version: "v1", Kind: "Order"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "apiregistration.k8s.io", Version: "v1", Kind: "APIService"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Application"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "autoscaling", Version: "v1", Kind: "HorizontalPodAutoscaler"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "CertificateRequest"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ServiceAccount"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "MutatingWebhookConfiguration"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Machine"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "coordination.k8s.io", Version: "v1", Kind: "Lease"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "discovery.k8s.io", Version: "v1", Kind: "EndpointSlice"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "Gateway"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "GatewayClass"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "HTTPRoute"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "helm.toolkit.fluxcd.io", Version: "v2beta1", Kind: "HelmRelease"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1beta2", Kind: "Kustomization"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "IngressClass"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "storage.k8s.io", Version: "v1", Kind: "CSIDriver"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "storage.k8s.io", Version: "v1", Kind: "CSINode"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "storage.k8s.io", Version: "v1", Kind: "StorageClass"}, meta.RESTScopeRoot)
+	m.Add(schema.GroupVersionKind{Group: "storage.k8s.io", Version: "v1", Kind: "VolumeAttachment"}, meta.RESTScopeRoot)
+	return m
+}
+
+func newTestObject(apiVersion, kind, ns, name string, spec map[string]interface{}, owners ...metav1.OwnerReference) unstructuredv1.Unstructured {
+	u := unstructuredv1.Unstructured{Object: map[string]interface{}{}}
+	if spec != nil {
+		u.Object["spec"] = spec
+	}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(ns)
+	u.SetName(name)
+	u.SetUID(types.UID("uid-" + name))
+	u.SetOwnerReferences(owners)
+	return u
+}
+
+// newTestPod returns a Pod with the provided labels, see newTestObject.
+func newTestPod(ns, name string, lbls map[string]string, owners ...metav1.OwnerReference) unstructuredv1.Unstructured {
+	u := newTestObject("v1", "Pod", ns, name, nil, owners...)
+	u.SetLabels(lbls)
+	return u
+}
+
+// newCrossNamespaceTestObjects returns objects with relationships spanning
+// across namespaces & cluster-scoped objects.
+func newCrossNamespaceTestObjects() []unstructuredv1.Unstructured {
+	controller := true
+	return []unstructuredv1.Unstructured{
+		// Cluster-scoped owner of a namespaced object
+		newTestObject("v1", "Node", "", "node", nil),
+		newTestObject("coordination.k8s.io/v1", "Lease", "kube-node-lease", "lease", nil,
+			metav1.OwnerReference{APIVersion: "v1", Kind: "Node", Name: "node", UID: "uid-node"}),
+		// Cluster-scoped object related to namespaced objects
+		newTestObject("v1", "PersistentVolume", "", "pv", map[string]interface{}{
+			"claimRef": map[string]interface{}{"kind": "PersistentVolumeClaim", "namespace": "team-a", "name": "pvc"},
+		}),
+		newTestObject("v1", "PersistentVolumeClaim", "team-a", "pvc", map[string]interface{}{
+			"volumeName": "pv",
+		}),
+		newTestObject("v1", "Pod", "team-a", "pod", map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "app"}},
+			"volumes": []interface{}{
+				map[string]interface{}{
+					"name":                  "data",
+					"persistentVolumeClaim": map[string]interface{}{"claimName": "pvc"},
+				},
+			},
+		}),
+		// Owner in a different namespace than its dependent
+		newTestObject("apps/v1", "Deployment", "team-a", "deploy", nil),
+		newTestObject("v1", "ConfigMap", "team-b", "cm", nil,
+			metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "deploy", UID: "uid-deploy", Controller: &controller}),
+	}
+}
+
+func nodeMapUIDs(m NodeMap) []string {
+	uids := make([]string, 0, len(m))
+	for uid := range m {
+		uids = append(uids, string(uid))
+	}
+	sort.Strings(uids)
+	return uids
+}
+
+func TestResolveDepsAcrossNamespaces(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"cluster-scoped owner", "uid-node", false, []string{"uid-lease", "uid-node"}},
+		{"cluster-scoped dependents", "uid-pv", false, []string{"uid-pod", "uid-pv", "uid-pvc"}},
+		{"cluster-scoped dependencies", "uid-pod", true, []string{"uid-pod", "uid-pv", "uid-pvc"}},
+		{"cross-namespace owner", "uid-deploy", false, []string{"uid-cm", "uid-deploy"}},
+		{"cross-namespace dependent", "uid-cm", true, []string{"uid-cm", "uid-deploy"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), newCrossNamespaceTestObjects(), []types.UID{tt.rootUID}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestRestrictToNamespaces(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		namespace          string
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"cluster-scoped owner", "uid-node", "default", false, []string{"uid-node"}},
+		{"cluster-scoped dependencies", "uid-pod", "team-a", true, []string{"uid-pod", "uid-pv", "uid-pvc"}},
+		{"cross-namespace owner", "uid-deploy", "team-a", false, []string{"uid-deploy"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), newCrossNamespaceTestObjects(), []types.UID{tt.rootUID}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		nodeMap.RestrictToNamespaces([]types.UID{tt.rootUID}, map[string]struct{}{tt.namespace: {}}, tt.depsIsDependencies)
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestResolveDepsDirection(t *testing.T) {
+	t.Parallel()
+
+	ownedBy := func(kind, name string) metav1.OwnerReference {
+		return metav1.OwnerReference{APIVersion: "apps/v1", Kind: kind, Name: name, UID: types.UID("uid-" + name)}
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("apps/v1", "Deployment", "default", "deploy", nil),
+		newTestObject("apps/v1", "ReplicaSet", "default", "rs-a", nil, ownedBy("Deployment", "deploy")),
+		newTestObject("apps/v1", "ReplicaSet", "default", "rs-b", nil, ownedBy("Deployment", "deploy")),
+		newTestObject("v1", "Pod", "default", "pod-a", nil, ownedBy("ReplicaSet", "rs-a")),
+		newTestObject("v1", "Pod", "default", "pod-b", nil, ownedBy("ReplicaSet", "rs-b")),
+	}
+
+	tests := []struct {
+		name               string
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"dependents exclude owners & siblings", false, []string{"uid-pod-a", "uid-rs-a"}},
+		{"dependencies exclude dependents & siblings", true, []string{"uid-deploy", "uid-rs-a"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-rs-a"}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestResolveDepsNotFoundOwners(t *testing.T) {
+	t.Parallel()
+
+	ownedBy := func(kind, name string) metav1.OwnerReference {
+		return metav1.OwnerReference{APIVersion: "apps/v1", Kind: kind, Name: name, UID: types.UID("uid-" + name)}
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("v1", "Pod", "default", "pod-a", nil, ownedBy("ReplicaSet", "rs")),
+		newTestObject("v1", "Pod", "default", "pod-b", nil, ownedBy("ReplicaSet", "rs")),
+		newTestObject("v1", "Pod", "default", "pod-c", nil, ownedBy("Unknown", "unknown")),
+	}
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-pod-a"}, true)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	if expected, output := []string{"uid-pod-a", "uid-rs"}, nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	owner := nodeMap["uid-rs"]
+	if !owner.NotFound || owner.Kind != "ReplicaSet" || owner.Namespace != "default" || owner.Name != "rs" {
+		t.Fatalf("expected not found ReplicaSet \"rs\" in namespace \"default\", got %+v", owner)
+	}
+
+	// Unmapped owner kinds are skipped
+	nodeMap, err = resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-pod-c"}, true)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	if expected, output := []string{"uid-pod-c"}, nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	rootUIDs := []types.UID{"uid-pod"}
+	objects := newCrossNamespaceTestObjects()
+	objects[4].Object["status"] = map[string]interface{}{"containerStatuses": []interface{}{
+		map[string]interface{}{"name": "app", "restartCount": int64(3)},
+	}}
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, rootUIDs, true)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	nodeMap["uid-pvc"].PassThrough = true
+
+	var buf bytes.Buffer
+	if err := nodeMap.WriteSnapshot(&buf, rootUIDs, true); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	output, outputRootUIDs, depsIsDependencies, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(outputRootUIDs, rootUIDs) || !depsIsDependencies {
+		t.Fatalf("expected roots \"%v\" & dependencies got roots \"%v\" & dependencies %t", rootUIDs, outputRootUIDs, depsIsDependencies)
+	}
+	if uids, expected := nodeMapUIDs(output), nodeMapUIDs(nodeMap); !reflect.DeepEqual(uids, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, uids)
+	}
+	for uid, expected := range nodeMap {
+		node := output[uid]
+		if !reflect.DeepEqual(node.UnstructuredContent(), expected.UnstructuredContent()) {
+			t.Fatalf("%s: expected object \"%v\" got \"%v\"", uid, expected.UnstructuredContent(), node.UnstructuredContent())
+		}
+		if !reflect.DeepEqual(node.Dependencies, expected.Dependencies) {
+			t.Fatalf("%s: expected dependencies \"%v\" got \"%v\"", uid, expected.Dependencies, node.Dependencies)
+		}
+		if node.Kind != expected.Kind || node.Resource != expected.Resource || node.Depth != expected.Depth || node.PassThrough != expected.PassThrough {
+			t.Fatalf("%s: expected node \"%+v\" got \"%+v\"", uid, expected, node)
+		}
+	}
+
+	if _, _, _, err := ReadSnapshot(bytes.NewBufferString(`{"version": 0}`)); err == nil {
+		t.Fatalf("expected error reading snapshot of unsupported version")
+	}
+}
+
+func TestExcludeGroupKinds(t *testing.T) {
+	t.Parallel()
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), newCrossNamespaceTestObjects(), []types.UID{"uid-pv"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	gkSet := map[schema.GroupKind]struct{}{{Kind: "PersistentVolumeClaim"}: {}}
+	nodeMap.ExcludeGroupKinds([]types.UID{"uid-pv"}, gkSet, false)
+
+	expected := []string{"uid-pod", "uid-pv"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	expectedDeps := map[types.UID]RelationshipSet{"uid-pod": {RelationshipPodVolume: {}}}
+	if output := nodeMap["uid-pv"].Dependents; !reflect.DeepEqual(output, expectedDeps) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expectedDeps, output)
+	}
+}
+
+func TestFilterByGroupKinds(t *testing.T) {
+	t.Parallel()
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), newCrossNamespaceTestObjects(), []types.UID{"uid-pv"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	gkSet := map[schema.GroupKind]struct{}{{Kind: "Pod"}: {}}
+	nodeMap.FilterByGroupKinds([]types.UID{"uid-pv"}, gkSet, false)
+
+	expected := []string{"uid-pod", "uid-pv", "uid-pvc"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	if !nodeMap["uid-pvc"].PassThrough || nodeMap["uid-pod"].PassThrough || nodeMap["uid-pv"].PassThrough {
+		t.Fatalf("expected only \"uid-pvc\" to be marked as pass-through")
+	}
+}
+
+func TestFilterByLabelSelector(t *testing.T) {
+	t.Parallel()
+
+	objects := newCrossNamespaceTestObjects()
+	for ix := range objects {
+		if objects[ix].GetName() == "pod" {
+			objects[ix].SetLabels(map[string]string{"app": "web"})
+		}
+	}
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-pv"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	// Empty selectors leave the relationship tree untouched
+	nodeMap.FilterByLabelSelector([]types.UID{"uid-pv"}, labels.Everything(), false)
+	expected := []string{"uid-pod", "uid-pv", "uid-pvc"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	if nodeMap["uid-pvc"].PassThrough {
+		t.Fatalf("expected \"uid-pvc\" not to be marked as pass-through")
+	}
+
+	nodeMap.FilterByLabelSelector([]types.UID{"uid-pv"}, labels.SelectorFromSet(labels.Set{"app": "web"}), false)
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	if !nodeMap["uid-pvc"].PassThrough || nodeMap["uid-pod"].PassThrough || nodeMap["uid-pv"].PassThrough {
+		t.Fatalf("expected only \"uid-pvc\" to be marked as pass-through")
+	}
+
+	// Only the root objects are kept if no objects match
+	nodeMap.FilterByLabelSelector([]types.UID{"uid-pv"}, labels.SelectorFromSet(labels.Set{"app": "db"}), false)
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, []string{"uid-pv"}) {
+		t.Fatalf("expected \"[uid-pv]\" got \"%v\"", output)
+	}
+}
+
+func TestFilterByUIDs(t *testing.T) {
+	t.Parallel()
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), newCrossNamespaceTestObjects(), []types.UID{"uid-pv"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	nodeMap.FilterByUIDs([]types.UID{"uid-pv"}, []types.UID{"uid-pod"}, false)
+
+	expected := []string{"uid-pod", "uid-pv", "uid-pvc"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	if !nodeMap["uid-pvc"].PassThrough || nodeMap["uid-pod"].PassThrough {
+		t.Fatalf("expected only \"uid-pvc\" to be marked as pass-through")
+	}
+
+	// Only the root objects are kept if no objects match
+	nodeMap.FilterByUIDs([]types.UID{"uid-pv"}, nil, false)
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, []string{"uid-pv"}) {
+		t.Fatalf("expected \"[uid-pv]\" got \"%v\"", output)
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	t.Parallel()
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), newCrossNamespaceTestObjects(), []types.UID{"uid-pv"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	tests := []struct {
+		name     string
+		from, to types.UID
+		expected []types.UID
+	}{
+		{"path to dependent", "uid-pv", "uid-pod", []types.UID{"uid-pv", "uid-pvc", "uid-pod"}},
+		{"same object", "uid-pv", "uid-pv", []types.UID{"uid-pv"}},
+		{"no path against the direction", "uid-pod", "uid-pv", nil},
+		{"object not in the tree", "uid-pv", "uid-unknown", nil},
+	}
+	for _, tt := range tests {
+		if output := nodeMap.ShortestPath(tt.from, tt.to, false); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestFilterByCreationTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	tests := []struct {
+		name     string
+		older    bool
+		expected []string
+	}{
+		{"newer objects", false, []string{"uid-pod", "uid-pv", "uid-pvc"}},
+		{"older objects", true, []string{"uid-pv", "uid-pvc"}},
+	}
+	for _, tt := range tests {
+		objects := newCrossNamespaceTestObjects()
+		for ix := range objects {
+			created := now.Add(-time.Hour)
+			if objects[ix].GetName() == "pod" {
+				created = now.Add(-time.Minute)
+			}
+			objects[ix].SetCreationTimestamp(metav1.NewTime(created))
+		}
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-pv"}, false)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		nodeMap.FilterByCreationTime([]types.UID{"uid-pv"}, now.Add(-10*time.Minute), tt.older, false)
+
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+		if nodeMap["uid-pvc"].PassThrough == tt.older {
+			t.Fatalf("%s: expected \"uid-pvc\" pass-through to be %t", tt.name, !tt.older)
+		}
+	}
+}
+
+func TestAttachEvents(t *testing.T) {
+	t.Parallel()
+
+	event := newTestObject("v1", "Event", "team-a", "event", nil)
+	event.Object["involvedObject"] = map[string]interface{}{"kind": "PersistentVolumeClaim", "name": "pvc", "uid": "uid-pvc"}
+	objects := append(newCrossNamespaceTestObjects(), event)
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-pod"}, true)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	if err := nodeMap.AttachEvents(newTestRESTMapper(), objects, true); err != nil {
+		t.Fatalf("failed to attach events: %v", err)
+	}
+
+	expected := []string{"uid-event", "uid-pod", "uid-pv", "uid-pvc"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	if _, ok := nodeMap["uid-pvc"].Dependencies["uid-event"]; !ok {
+		t.Fatalf("expected \"uid-event\" to be attached to \"uid-pvc\"")
+	}
+	if ev := nodeMap["uid-event"]; len(ev.Dependencies) != 0 || len(ev.Dependents) != 0 {
+		t.Fatalf("expected \"uid-event\" to have no dependencies or dependents")
+	}
+}
+
+func TestInferOwnerReferences(t *testing.T) {
+	t.Parallel()
+
+	selector := func(lbls map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"selector": map[string]interface{}{"matchLabels": lbls}}
+	}
+	newObject := func(apiVersion, kind, name string, spec map[string]interface{}, lbls map[string]string) unstructuredv1.Unstructured {
+		u := newTestObject(apiVersion, kind, "default", name, spec)
+		u.SetLabels(lbls)
+		return u
+	}
+	objects := []unstructuredv1.Unstructured{
+		newObject("apps/v1", "Deployment", "web", selector(map[string]interface{}{"app": "web"}), nil),
+		newObject("apps/v1", "ReplicaSet", "web-abc", selector(map[string]interface{}{"app": "web", "pod-template-hash": "abc"}),
+			map[string]string{"app": "web", "pod-template-hash": "abc"}),
+		newObject("v1", "Pod", "web-abc-x", nil, map[string]string{"app": "web", "pod-template-hash": "abc"}),
+		// Pod matching multiple ReplicaSets shouldn't have its owner inferred
+		newObject("apps/v1", "ReplicaSet", "api-def", selector(map[string]interface{}{"pod-template-hash": "def"}),
+			map[string]string{"pod-template-hash": "def"}),
+		newObject("apps/v1", "ReplicaSet", "job-def", selector(map[string]interface{}{"pod-template-hash": "def"}),
+			map[string]string{"pod-template-hash": "def"}),
+		newObject("v1", "Pod", "api-def-x", nil, map[string]string{"pod-template-hash": "def"}),
+	}
+	InferOwnerReferences(objects)
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-web"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	expected := []string{"uid-web", "uid-web-abc", "uid-web-abc-x"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	if refs := objects[5].GetOwnerReferences(); len(refs) != 0 {
+		t.Fatalf("expected no owner references to be inferred for ambiguous pod, got \"%v\"", refs)
+	}
+}
+
+func TestResolveJobRelationships(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("batch/v1", "CronJob", "default", "backup", nil),
+		// Job orphaned from its CronJob
+		newTestObject("batch/v1", "Job", "default", "backup-27400000", nil),
+		newTestPod("default", "backup-27400000-x", map[string]string{JobNameLabel: "backup-27400000"}),
+		// Job not created by a CronJob
+		newTestObject("batch/v1", "Job", "default", "backup-manual", nil),
+		newTestPod("default", "backup-manual-x", map[string]string{JobNameLabel: "backup-manual"}),
+	}
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"cronjob", "uid-backup", false, []string{"uid-backup", "uid-backup-27400000", "uid-backup-27400000-x"}},
+		{"job", "uid-backup-manual", false, []string{"uid-backup-manual", "uid-backup-manual-x"}},
+		{"pod", "uid-backup-27400000-x", true, []string{"uid-backup", "uid-backup-27400000", "uid-backup-27400000-x"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{tt.rootUID}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestResolveHorizontalPodAutoscalerScaleTarget(t *testing.T) {
+	t.Parallel()
+
+	scaleTargetRef := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": name},
+		}
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("autoscaling/v1", "HorizontalPodAutoscaler", "default", "hpa", scaleTargetRef("web")),
+		newTestObject("autoscaling/v1", "HorizontalPodAutoscaler", "default", "hpa-missing", scaleTargetRef("missing")),
+		newTestObject("apps/v1", "Deployment", "default", "web", nil),
+		newTestObject("apps/v1", "ReplicaSet", "default", "web-abc", nil,
+			metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "uid-web"}),
+	}
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-hpa"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	expected := []string{"uid-hpa", "uid-web", "uid-web-abc"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	if nodeMap["uid-web"].NotFound {
+		t.Fatalf("expected existing scale target to not be marked as not found")
+	}
+
+	nodeMap, err = resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-hpa-missing"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	if len(nodeMap) != 2 {
+		t.Fatalf("expected 2 nodes got \"%v\"", nodeMapUIDs(nodeMap))
+	}
+	for _, n := range nodeMap {
+		if n.UID == "uid-hpa-missing" {
+			continue
+		}
+		if !n.NotFound || n.Kind != "Deployment" || n.Name != "missing" {
+			t.Fatalf("expected not found Deployment \"missing\" got %s \"%s\" (not found: %t)", n.Kind, n.Name, n.NotFound)
+		}
+	}
+}
+
+func TestResolveDepsNotFoundReferences(t *testing.T) {
+	t.Parallel()
+
+	// Serve Deployments from a version other than "v1" to ensure the version of
+	// objects that aren't found is resolved from the REST mapper
+	m := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Group: "apps", Version: "v1beta2"}})
+	m.Add(schema.GroupVersionKind{Group: "apps", Version: "v1beta2", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "autoscaling", Version: "v1", Kind: "HorizontalPodAutoscaler"}, meta.RESTScopeNamespace)
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("autoscaling/v1", "HorizontalPodAutoscaler", "default", "hpa", map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"apiVersion": "apps/v1beta2", "kind": "Deployment", "name": "missing"},
+		}),
+	}
+	deploy := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	tests := []struct {
+		name             string
+		isListedFn       func(gk schema.GroupKind, ns string) bool
+		expectedNotFound bool
+	}{
+		{"every object listed", nil, true},
+		{"type listed in namespace", func(gk schema.GroupKind, ns string) bool { return ns == "default" }, true},
+		{"type not listed", func(gk schema.GroupKind, ns string) bool { return gk != deploy }, false},
+		{"type listed in other namespace", func(gk schema.GroupKind, ns string) bool { return ns == "other" }, false},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDepsWithOptions(m, objects, []types.UID{"uid-hpa"}, false, ResolveOptions{IsListedFn: tt.isListedFn})
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		var notFound *Node
+		for _, n := range nodeMap {
+			if n.NotFound {
+				notFound = n
+			}
+		}
+		if (notFound != nil) != tt.expectedNotFound {
+			t.Fatalf("%s: expected not found Deployment %t got \"%v\"", tt.name, tt.expectedNotFound, nodeMapUIDs(nodeMap))
+		}
+		if notFound == nil {
+			continue
+		}
+		if notFound.Version != "v1beta2" || notFound.GetAPIVersion() != "apps/v1beta2" || notFound.Resource != "deployments" {
+			t.Fatalf("%s: expected not found Deployment of \"deployments.v1beta2.apps\" got \"%s.%s\" (apiVersion: %s)", tt.name, notFound.Resource, notFound.Version, notFound.GetAPIVersion())
+		}
+	}
+}
+
+func TestResolveAPIServiceServices(t *testing.T) {
+	t.Parallel()
+
+	apiService := func(svcName string) map[string]interface{} {
+		spec := map[string]interface{}{"group": "metrics.k8s.io", "version": "v1beta1"}
+		if len(svcName) > 0 {
+			spec["service"] = map[string]interface{}{"namespace": "kube-system", "name": svcName}
+		}
+		return spec
+	}
+	pod := newTestPod("kube-system", "metrics-server-x", map[string]string{"k8s-app": "metrics-server"}, metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "metrics-server-5d8f", UID: "uid-metrics-server-5d8f"})
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("apiregistration.k8s.io/v1", "APIService", "", "v1beta1.metrics.k8s.io", apiService("metrics-server")),
+		newTestObject("apiregistration.k8s.io/v1", "APIService", "", "v1beta1.custom.metrics.k8s.io", apiService("prometheus-adapter")),
+		newTestObject("apiregistration.k8s.io/v1", "APIService", "", "v1.apps", apiService("")),
+		newTestObject("v1", "Service", "kube-system", "metrics-server", map[string]interface{}{
+			"selector": map[string]interface{}{"k8s-app": "metrics-server"},
+		}),
+		pod,
+		newTestObject("apps/v1", "ReplicaSet", "kube-system", "metrics-server-5d8f", nil, metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "metrics-server", UID: "uid-metrics-server-deploy"}),
+		newTestObject("apps/v1", "Deployment", "kube-system", "metrics-server", nil),
+	}
+	// newTestObject derives UIDs from names, so give the Deployment its own UID
+	objects[6].SetUID("uid-metrics-server-deploy")
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"apiservice dependencies", "uid-v1beta1.metrics.k8s.io", true, []string{"uid-metrics-server", "uid-metrics-server-5d8f", "uid-metrics-server-deploy", "uid-metrics-server-x", "uid-v1beta1.metrics.k8s.io"}},
+		{"deployment dependents", "uid-metrics-server-deploy", false, []string{"uid-metrics-server", "uid-metrics-server-5d8f", "uid-metrics-server-deploy", "uid-metrics-server-x", "uid-v1beta1.metrics.k8s.io"}},
+		{"local apiservice", "uid-v1.apps", true, []string{"uid-v1.apps"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{tt.rootUID}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+
+	// Services of APIServices that don't exist are kept as not found objects
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-v1beta1.custom.metrics.k8s.io"}, true)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	if len(nodeMap) != 2 {
+		t.Fatalf("expected 2 nodes got \"%v\"", nodeMapUIDs(nodeMap))
+	}
+	for _, n := range nodeMap {
+		if n.UID == "uid-v1beta1.custom.metrics.k8s.io" {
+			continue
+		}
+		if !n.NotFound || n.Kind != "Service" || n.Namespace != "kube-system" || n.Name != "prometheus-adapter" {
+			t.Fatalf("expected not found Service \"kube-system/prometheus-adapter\" got %s \"%s/%s\" (not found: %t)", n.Kind, n.Namespace, n.Name, n.NotFound)
+		}
+	}
+}
+
+func TestResolveVolumeAttachments(t *testing.T) {
+	t.Parallel()
+
+	volumeAttachment := func(attacher, pv, node string) map[string]interface{} {
+		return map[string]interface{}{
+			"attacher": attacher,
+			"nodeName": node,
+			"source":   map[string]interface{}{"persistentVolumeName": pv},
+		}
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("v1", "Node", "", "node-a", nil),
+		newTestObject("v1", "PersistentVolume", "", "pv", nil),
+		newTestObject("storage.k8s.io/v1", "CSIDriver", "", "ebs.csi.aws.com", nil),
+		newTestObject("storage.k8s.io/v1", "CSINode", "", "node-a", map[string]interface{}{
+			"drivers": []interface{}{
+				map[string]interface{}{"name": "ebs.csi.aws.com", "nodeID": "i-0123"},
+			},
+		}),
+		newTestObject("storage.k8s.io/v1", "VolumeAttachment", "", "va", volumeAttachment("ebs.csi.aws.com", "pv", "node-a")),
+		newTestObject("storage.k8s.io/v1", "VolumeAttachment", "", "va-stuck", volumeAttachment("efs.csi.aws.com", "pv-deleted", "node-deleted")),
+	}
+	// newTestObject derives UIDs from names, so give the CSINode its own UID
+	objects[3].SetUID("uid-csinode-a")
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"pv dependencies", "uid-pv", true, []string{"uid-csinode-a", "uid-ebs.csi.aws.com", "uid-node-a", "uid-pv", "uid-va"}},
+		{"node dependents", "uid-node-a", false, []string{"uid-csinode-a", "uid-ebs.csi.aws.com", "uid-node-a", "uid-pv", "uid-va"}},
+		{"attachment dependents", "uid-va", false, []string{"uid-pv", "uid-va"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{tt.rootUID}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+
+	// References of attachments that outlived their PersistentVolume & Node are
+	// kept as not found objects
+	for _, depsIsDependencies := range []bool{false, true} {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-va-stuck"}, depsIsDependencies)
+		if err != nil {
+			t.Fatalf("failed to resolve relationships: %v", err)
+		}
+		if len(nodeMap) != 2 {
+			t.Fatalf("expected 2 nodes got \"%v\"", nodeMapUIDs(nodeMap))
+		}
+		expectedKind, expectedName := "PersistentVolume", "pv-deleted"
+		if depsIsDependencies {
+			expectedKind, expectedName = "Node", "node-deleted"
+		}
+		for _, n := range nodeMap {
+			if n.UID == "uid-va-stuck" {
+				continue
+			}
+			if !n.NotFound || n.Kind != expectedKind || n.Name != expectedName {
+				t.Fatalf("expected not found %s \"%s\" got %s \"%s\" (not found: %t)", expectedKind, expectedName, n.Kind, n.Name, n.NotFound)
+			}
+		}
+	}
+}
+
+func TestResolveStorageClassVolumes(t *testing.T) {
+	t.Parallel()
+
+	legacyPV := newTestObject("v1", "PersistentVolume", "", "pv-legacy", nil)
+	legacyPV.SetAnnotations(map[string]string{"volume.beta.kubernetes.io/storage-class": "fast"})
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("storage.k8s.io/v1", "StorageClass", "", "fast", nil),
+		newTestObject("storage.k8s.io/v1", "StorageClass", "", "slow", nil),
+		newTestObject("v1", "PersistentVolume", "", "pv", map[string]interface{}{
+			"claimRef":         map[string]interface{}{"kind": "PersistentVolumeClaim", "namespace": "default", "name": "pvc"},
+			"storageClassName": "fast",
+		}),
+		legacyPV,
+		newTestObject("v1", "PersistentVolume", "", "pv-slow", map[string]interface{}{
+			"storageClassName": "slow",
+		}),
+		newTestObject("v1", "PersistentVolumeClaim", "default", "pvc", map[string]interface{}{
+			"storageClassName": "fast",
+			"volumeName":       "pv",
+		}),
+		newTestObject("v1", "PersistentVolumeClaim", "default", "pvc-pending", map[string]interface{}{
+			"storageClassName": "fast",
+		}),
+		newTestObject("v1", "Pod", "default", "pod", map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "app"}},
+			"volumes": []interface{}{
+				map[string]interface{}{
+					"name":                  "data",
+					"persistentVolumeClaim": map[string]interface{}{"claimName": "pvc"},
+				},
+			},
+		}),
+	}
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-fast"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	expected := []string{"uid-fast", "uid-pod", "uid-pv", "uid-pv-legacy", "uid-pvc", "uid-pvc-pending"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestResolveCertManagerRelationships(t *testing.T) {
+	t.Parallel()
+
+	ownerRef := func(apiVersion, kind, name string) metav1.OwnerReference {
+		controller := true
+		return metav1.OwnerReference{APIVersion: apiVersion, Kind: kind, Name: name, UID: types.UID("uid-" + name), Controller: &controller}
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("cert-manager.io/v1", "ClusterIssuer", "", "letsencrypt", nil),
+		newTestObject("cert-manager.io/v1", "Issuer", "default", "ca", nil),
+		newTestObject("cert-manager.io/v1", "Certificate", "default", "web", map[string]interface{}{
+			"secretName": "web-tls",
+			"issuerRef":  map[string]interface{}{"kind": "ClusterIssuer", "name": "letsencrypt"},
+		}),
+		newTestObject("cert-manager.io/v1", "Certificate", "default", "internal", map[string]interface{}{
+			"secretName": "internal-tls",
+			"issuerRef":  map[string]interface{}{"name": "ca"},
+		}),
+		newTestObject("cert-manager.io/v1", "CertificateRequest", "default", "web-1", map[string]interface{}{
+			"issuerRef": map[string]interface{}{"kind": "ClusterIssuer", "name": "letsencrypt"},
+		}, ownerRef("cert-manager.io/v1", "Certificate", "web")),
+		newTestObject("acme.cert-manager.io/v1", "Order", "default", "web-1-order", nil,
+			ownerRef("cert-manager.io/v1", "CertificateRequest", "web-1")),
+		newTestObject("acme.cert-manager.io/v1", "Challenge", "default", "web-1-challenge", nil,
+			ownerRef("acme.cert-manager.io/v1", "Order", "web-1-order")),
+		newTestObject("v1", "Secret", "default", "web-tls", nil),
+		newTestObject("v1", "Secret", "default", "internal-tls", nil),
+	}
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"certificate dependents", "uid-web", false, []string{"uid-web", "uid-web-1", "uid-web-1-challenge", "uid-web-1-order", "uid-web-tls"}},
+		{"certificate dependencies", "uid-internal", true, []string{"uid-ca", "uid-internal"}},
+		{"clusterissuer dependents", "uid-letsencrypt", false, []string{"uid-letsencrypt", "uid-web", "uid-web-1", "uid-web-1-challenge", "uid-web-1-order", "uid-web-tls"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{tt.rootUID}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestResolveGitOpsRelationships(t *testing.T) {
+	t.Parallel()
+
+	withMetadata := func(o unstructuredv1.Unstructured, lbls, annotations map[string]string) unstructuredv1.Unstructured {
+		o.SetLabels(lbls)
+		o.SetAnnotations(annotations)
+		return o
+	}
+	withUID := func(o unstructuredv1.Unstructured, uid types.UID) unstructuredv1.Unstructured {
+		o.SetUID(uid)
+		return o
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("argoproj.io/v1alpha1", "Application", "argocd", "web", nil),
+		withUID(newTestObject("argoproj.io/v1alpha1", "Application", "team-a", "web", nil), "uid-team-a-web"),
+		newTestObject("kustomize.toolkit.fluxcd.io/v1beta2", "Kustomization", "flux-system", "infra", nil),
+		newTestObject("helm.toolkit.fluxcd.io/v2beta1", "HelmRelease", "flux-system", "redis", nil),
+		withMetadata(newTestObject("v1", "Service", "default", "web-svc", nil),
+			map[string]string{"app.kubernetes.io/instance": "web"}, nil),
+		withMetadata(newTestObject("apps/v1", "Deployment", "default", "web-deploy", nil),
+			nil, map[string]string{"argocd.argoproj.io/tracking-id": "web:apps/Deployment:default/web-deploy"}),
+		withMetadata(newTestObject("v1", "ConfigMap", "default", "web-copied", nil),
+			nil, map[string]string{"argocd.argoproj.io/tracking-id": "web:apps/Deployment:default/web-deploy"}),
+		withMetadata(newTestObject("v1", "ConfigMap", "default", "web-custom", nil),
+			map[string]string{"example.com/app": "web"}, nil),
+		withMetadata(newTestObject("v1", "ConfigMap", "team-a", "team-a-config", nil),
+			nil, map[string]string{"argocd.argoproj.io/tracking-id": "team-a_web:/ConfigMap:team-a/team-a-config"}),
+		withMetadata(newTestObject("v1", "ConfigMap", "default", "infra-config", nil),
+			map[string]string{"kustomize.toolkit.fluxcd.io/name": "infra", "kustomize.toolkit.fluxcd.io/namespace": "flux-system"}, nil),
+		withMetadata(newTestObject("v1", "Service", "default", "redis-svc", nil),
+			map[string]string{"helm.toolkit.fluxcd.io/name": "redis", "helm.toolkit.fluxcd.io/namespace": "flux-system"}, nil),
+	}
+	defaultTracking := GitOpsTracking{
+		ArgoCDNamespace:          DefaultArgoCDNamespace,
+		ArgoCDTrackingAnnotation: DefaultArgoCDTrackingAnnotation,
+	}
+	labelTracking := GitOpsTracking{
+		ArgoCDInstanceLabel:      "app.kubernetes.io/instance",
+		ArgoCDNamespace:          DefaultArgoCDNamespace,
+		ArgoCDTrackingAnnotation: DefaultArgoCDTrackingAnnotation,
+	}
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		depsIsDependencies bool
+		tracking           GitOpsTracking
+		expected           []string
+	}{
+		{"application dependents", "uid-web", false, defaultTracking, []string{"uid-web", "uid-web-deploy"}},
+		{"application dependents with label", "uid-web", false, labelTracking, []string{"uid-web", "uid-web-deploy", "uid-web-svc"}},
+		{"application dependents with custom label", "uid-web", false, GitOpsTracking{ArgoCDInstanceLabel: "example.com/app", ArgoCDNamespace: DefaultArgoCDNamespace}, []string{"uid-web", "uid-web-custom"}},
+		{"application dependents without tracking", "uid-web", false, GitOpsTracking{}, []string{"uid-web"}},
+		{"application dependents outside argocd namespace", "uid-team-a-web", false, labelTracking, []string{"uid-team-a-config", "uid-team-a-web"}},
+		{"kustomization dependents", "uid-infra", false, GitOpsTracking{}, []string{"uid-infra", "uid-infra-config"}},
+		{"helmrelease dependents", "uid-redis", false, GitOpsTracking{}, []string{"uid-redis", "uid-redis-svc"}},
+		{"managed object dependencies", "uid-web-deploy", true, defaultTracking, []string{"uid-web", "uid-web-deploy"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDepsWithOptions(newTestRESTMapper(), objects, []types.UID{tt.rootUID}, tt.depsIsDependencies, ResolveOptions{GitOpsTracking: tt.tracking})
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestResolveGatewayAPIRelationships(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("gateway.networking.k8s.io/v1beta1", "GatewayClass", "", "gwc", nil),
+		newTestObject("gateway.networking.k8s.io/v1beta1", "Gateway", "infra", "gw", map[string]interface{}{
+			"gatewayClassName": "gwc",
+		}),
+		newTestObject("gateway.networking.k8s.io/v1beta1", "HTTPRoute", "default", "route", map[string]interface{}{
+			"parentRefs": []interface{}{
+				map[string]interface{}{"namespace": "infra", "name": "gw"},
+			},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{"name": "web", "port": int64(80)},
+					},
+				},
+			},
+		}),
+		newTestObject("v1", "Service", "default", "web", nil),
+		newTestObject("v1", "Service", "infra", "web", nil),
+	}
+	objects[4].SetUID("uid-web-infra")
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"gatewayclass dependents", "uid-gwc", false, []string{"uid-gw", "uid-gwc", "uid-route"}},
+		{"httproute dependencies", "uid-route", true, []string{"uid-gw", "uid-gwc", "uid-route", "uid-web"}},
+		{"service dependents", "uid-web", false, []string{"uid-route", "uid-web"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{tt.rootUID}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestResolveIngressClassIngresses(t *testing.T) {
+	t.Parallel()
+
+	legacyIngress := newTestObject("networking.k8s.io/v1", "Ingress", "default", "legacy", nil)
+	legacyIngress.SetAnnotations(map[string]string{"kubernetes.io/ingress.class": "nginx"})
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("networking.k8s.io/v1", "IngressClass", "", "nginx", nil),
+		newTestObject("networking.k8s.io/v1", "Ingress", "default", "web", map[string]interface{}{
+			"ingressClassName": "nginx",
+		}),
+		legacyIngress,
+		newTestObject("networking.k8s.io/v1", "Ingress", "default", "other", map[string]interface{}{
+			"ingressClassName": "traefik",
+		}),
+	}
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-nginx"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	expected := []string{"uid-legacy", "uid-nginx", "uid-web"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestResolvePodDisruptionBudgetPods(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("policy/v1", "PodDisruptionBudget", "default", "pdb", map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+		}),
+		newTestPod("default", "web", map[string]string{"app": "web"}),
+		newTestPod("default", "api", map[string]string{"app": "api"}),
+	}
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"pods protected by pdb", "uid-pdb", true, []string{"uid-pdb", "uid-web"}},
+		{"pdb applying to pod", "uid-web", false, []string{"uid-pdb", "uid-web"}},
+		{"pod not selected by pdb", "uid-api", false, []string{"uid-api"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{tt.rootUID}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestResolvePodNodes(t *testing.T) {
+	t.Parallel()
+
+	ownedBy := func(kind, name string) metav1.OwnerReference {
+		return metav1.OwnerReference{APIVersion: "apps/v1", Kind: kind, Name: name, UID: types.UID("uid-" + name)}
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("v1", "Node", "", "node-a", nil),
+		newTestObject("v1", "Node", "", "node-b", nil),
+		newTestObject("apps/v1", "ReplicaSet", "default", "rs", nil),
+		newTestObject("v1", "Pod", "default", "pod-a", map[string]interface{}{"nodeName": "node-a"}, ownedBy("ReplicaSet", "rs")),
+		newTestObject("v1", "Pod", "kube-system", "pod-b", map[string]interface{}{"nodeName": "node-a"}),
+		newTestObject("v1", "Pod", "default", "pod-c", map[string]interface{}{"nodeName": "node-b"}),
+		newTestObject("v1", "Pod", "default", "pod-pending", nil),
+	}
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"node dependents", "uid-node-a", false, []string{"uid-node-a", "uid-pod-a", "uid-pod-b"}},
+		{"pod dependencies", "uid-pod-a", true, []string{"uid-node-a", "uid-pod-a", "uid-rs"}},
+		{"unscheduled pod dependencies", "uid-pod-pending", true, []string{"uid-pod-pending"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{tt.rootUID}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-pod-a"}, true)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	if rset := nodeMap["uid-pod-a"].Dependencies["uid-node-a"]; !reflect.DeepEqual(rset.List(), []string{string(RelationshipPodNode)}) {
+		t.Fatalf("expected Pod to depend on its Node through \"%s\", got \"%v\"", RelationshipPodNode, rset.List())
+	}
+}
+
+func TestResolveServiceAccountSecrets(t *testing.T) {
+	t.Parallel()
+
+	sa := newTestObject("v1", "ServiceAccount", "default", "builder", nil)
+	sa.Object["secrets"] = []interface{}{map[string]interface{}{"name": "builder-token-listed"}}
+	sa.Object["imagePullSecrets"] = []interface{}{map[string]interface{}{"name": "registry"}}
+	newSecret := func(name, secretType string, annotations map[string]string) unstructuredv1.Unstructured {
+		u := newTestObject("v1", "Secret", "default", name, nil)
+		u.Object["type"] = secretType
+		u.SetAnnotations(annotations)
+		return u
+	}
+	objects := []unstructuredv1.Unstructured{
+		sa,
+		newSecret("builder-token-listed", "kubernetes.io/service-account-token", nil),
+		newSecret("builder-token-name", "kubernetes.io/service-account-token", map[string]string{
+			"kubernetes.io/service-account.name": "builder",
+		}),
+		newSecret("builder-token-uid", "kubernetes.io/service-account-token", map[string]string{
+			"kubernetes.io/service-account.name": "builder",
+			"kubernetes.io/service-account.uid":  "uid-builder",
+		}),
+		newSecret("builder-token-stale", "kubernetes.io/service-account-token", map[string]string{
+			"kubernetes.io/service-account.name": "builder",
+			"kubernetes.io/service-account.uid":  "uid-deleted",
+		}),
+		newSecret("opaque", "Opaque", map[string]string{
+			"kubernetes.io/service-account.name": "builder",
+		}),
+		newSecret("registry", "kubernetes.io/dockerconfigjson", nil),
+	}
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"token secrets of serviceaccount", "uid-builder", false, []string{"uid-builder", "uid-builder-token-listed", "uid-builder-token-name", "uid-builder-token-uid"}},
+		{"serviceaccount of token secret", "uid-builder-token-uid", true, []string{"uid-builder", "uid-builder-token-uid", "uid-registry"}},
+		{"stale token secret", "uid-builder-token-stale", true, []string{"uid-builder-token-stale"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{tt.rootUID}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestResolveServiceEndpointPods(t *testing.T) {
+	t.Parallel()
+
+	newTargetRef := func(name string) map[string]interface{} {
+		return map[string]interface{}{"kind": "Pod", "name": name, "namespace": "default"}
+	}
+	newEndpointSlice := func(name, svc string, pods ...string) unstructuredv1.Unstructured {
+		u := newTestObject("discovery.k8s.io/v1", "EndpointSlice", "default", name, nil)
+		u.SetLabels(map[string]string{"kubernetes.io/service-name": svc})
+		endpoints := []interface{}{}
+		for _, p := range pods {
+			endpoints = append(endpoints, map[string]interface{}{"addresses": []interface{}{"10.0.0.1"}, "targetRef": newTargetRef(p)})
+		}
+		u.Object["endpoints"] = endpoints
+		return u
+	}
+	newEndpoints := func(name string, pods ...string) unstructuredv1.Unstructured {
+		u := newTestObject("v1", "Endpoints", "default", name, nil)
+		u.SetUID(types.UID("uid-endpoints-" + name))
+		addresses := []interface{}{}
+		for _, p := range pods {
+			addresses = append(addresses, map[string]interface{}{"ip": "10.0.0.1", "targetRef": newTargetRef(p)})
+		}
+		u.Object["subsets"] = []interface{}{map[string]interface{}{"addresses": addresses}}
+		return u
+	}
+	objects := []unstructuredv1.Unstructured{
+		// Service whose EndpointSlice targets a pod that no longer matches its
+		// selector, its Endpoints are ignored in favor of its EndpointSlices
+		newTestObject("v1", "Service", "default", "web", map[string]interface{}{
+			"selector": map[string]interface{}{"app": "web"},
+		}),
+		newEndpointSlice("web-abcde", "web", "web-1", "web-2"),
+		newEndpoints("web", "web-1", "web-3"),
+		newTestPod("default", "web-1", map[string]string{"app": "web"}),
+		newTestPod("default", "web-2", map[string]string{"app": "debug"}),
+		newTestPod("default", "web-3", map[string]string{"app": "debug"}),
+		// Service with manually managed Endpoints & no EndpointSlices
+		newTestObject("v1", "Service", "default", "db", map[string]interface{}{}),
+		newEndpoints("db", "db-1"),
+		newTestPod("default", "db-1", nil),
+	}
+
+	tests := []struct {
+		name               string
+		rootUID            types.UID
+		depsIsDependencies bool
+		expected           []string
+	}{
+		{"pods targeted by endpointslices", "uid-web", true, []string{"uid-web", "uid-web-1", "uid-web-2"}},
+		{"pods targeted by endpoints", "uid-db", true, []string{"uid-db", "uid-db-1", "uid-endpoints-db"}},
+		{"service targeting pod", "uid-web-2", false, []string{"uid-web", "uid-web-2"}},
+		{"pod only targeted by ignored endpoints", "uid-web-3", false, []string{"uid-web-3"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{tt.rootUID}, tt.depsIsDependencies)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestResolveWebhookConfigurationServices(t *testing.T) {
+	t.Parallel()
+
+	url := "https://webhook.example.com/mutate"
+	mwc := newTestObject("admissionregistration.k8s.io/v1", "MutatingWebhookConfiguration", "", "webhooks", nil)
+	mwc.Object["webhooks"] = []interface{}{
+		map[string]interface{}{
+			"name":         "service.example.com",
+			"clientConfig": map[string]interface{}{"service": map[string]interface{}{"namespace": "team-a", "name": "svc"}},
+		},
+		map[string]interface{}{
+			"name":         "url.example.com",
+			"clientConfig": map[string]interface{}{"url": url},
+		},
+	}
+	pod := newTestPod("team-a", "pod", map[string]string{"app": "webhook"})
+	objects := []unstructuredv1.Unstructured{
+		mwc,
+		newTestObject("v1", "Service", "team-a", "svc", map[string]interface{}{
+			"selector": map[string]interface{}{"app": "webhook"},
+		}),
+		pod,
+	}
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-webhooks"}, true)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	ref := newExternalEndpointReference(url)
+	expected := []string{string(ref.Key()), "uid-pod", "uid-svc", "uid-webhooks"}
+	sort.Strings(expected)
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	if n := nodeMap[types.UID(ref.Key())]; n.Kind != ExternalEndpointKind || n.Name != url {
+		t.Fatalf("expected synthetic %s \"%s\" got %s \"%s\"", ExternalEndpointKind, url, n.Kind, n.Name)
+	}
+	if n := nodeMap[types.UID(ref.Key())]; n.GetAPIVersion() != "external/v1" {
+		t.Fatalf("expected synthetic %s apiVersion \"external/v1\" got \"%s\"", ExternalEndpointKind, n.GetAPIVersion())
+	}
+}
+
+func TestHideCompletedPods(t *testing.T) {
+	t.Parallel()
+
+	newPod := func(name, phase string, owner string) unstructuredv1.Unstructured {
+		u := newTestPod("default", name, nil,
+			metav1.OwnerReference{APIVersion: "batch/v1", Kind: "Job", Name: owner, UID: types.UID("uid-" + owner)})
+		u.Object["status"] = map[string]interface{}{"phase": phase}
+		return u
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("batch/v1", "Job", "default", "job", nil),
+		newPod("pod-succeeded-a", "Succeeded", "job"),
+		newPod("pod-succeeded-b", "Succeeded", "job"),
+		newPod("pod-running", "Running", "job"),
+		// Objects only related to hidden pods are removed along with them
+		newTestObject("v1", "ConfigMap", "default", "cm", nil,
+			metav1.OwnerReference{APIVersion: "v1", Kind: "Pod", Name: "pod-succeeded-a", UID: "uid-pod-succeeded-a"}),
+	}
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-job"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	nodeMap.HideCompletedPods([]types.UID{"uid-job"}, false)
+	expected := []string{"uid-job", "uid-pod-running"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	if n := nodeMap["uid-job"].HiddenCompletedPods; n != 2 {
+		t.Fatalf("expected 2 hidden completed pods got %d", n)
+	}
+	if _, ok := nodeMap["uid-job"].Dependents["uid-pod-succeeded-a"]; ok {
+		t.Fatalf("expected hidden pod to be removed from the dependents of its owner")
+	}
+}
+
+func TestExcludeRelationships(t *testing.T) {
+	t.Parallel()
+
+	pod := newTestPod("default", "web", map[string]string{"app": "web"})
+	objects := []unstructuredv1.Unstructured{
+		pod,
+		newTestObject("v1", "Service", "default", "web-svc", map[string]interface{}{
+			"selector": map[string]interface{}{"app": "web"},
+		}),
+		newTestObject("v1", "ConfigMap", "default", "web-config", nil,
+			metav1.OwnerReference{APIVersion: "v1", Kind: "Pod", Name: "web", UID: "uid-web"}),
+		// Objects only related through excluded relationships are removed
+		newTestObject("v1", "ServiceAccount", "default", "web-svc-sa", nil,
+			metav1.OwnerReference{APIVersion: "v1", Kind: "Service", Name: "web-svc", UID: "uid-web-svc"}),
+	}
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-web"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	rset, err := ResolveRelationshipCategories([]string{string(RelationshipCategorySelector)})
+	if err != nil {
+		t.Fatalf("failed to resolve relationship categories: %v", err)
+	}
+	nodeMap.ExcludeRelationships([]types.UID{"uid-web"}, rset, false)
+	expected := []string{"uid-web", "uid-web-config"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	if _, ok := nodeMap["uid-web"].Dependents["uid-web-svc"]; ok {
+		t.Fatalf("expected excluded relationship to be removed from the dependents of the pod")
+	}
+
+	rset, err = ResolveRelationshipCategories([]string{string(RelationshipCategoryVolume)})
+	if err != nil {
+		t.Fatalf("failed to resolve relationship categories: %v", err)
+	}
+	for _, r := range []Relationship{RelationshipPersistentVolumeClaimStorageClass, RelationshipPersistentVolumeStorageClass} {
+		if _, ok := rset[r]; !ok {
+			t.Fatalf("expected %s category to include relationship \"%s\"", RelationshipCategoryVolume, r)
+		}
+	}
+
+	if _, err := ResolveRelationshipCategories([]string{"unknown"}); err == nil {
+		t.Fatalf("expected error for unknown relationship category")
+	}
+}
+
+// baseRelationships are the relationships to objects referenced by name in
+// manifests, which don't belong to any relationship category.
+var baseRelationships = RelationshipSet{
+	RelationshipAPIService:                           {},
+	RelationshipCSINodeDriver:                        {},
+	RelationshipCSINodeNode:                          {},
+	RelationshipCSIStorageCapacityStorageClass:       {},
+	RelationshipCertificateIssuer:                    {},
+	RelationshipCertificateRequestIssuer:             {},
+	RelationshipCertificateSecret:                    {},
+	RelationshipClusterRoleBindingRole:               {},
+	RelationshipClusterRoleBindingSubject:            {},
+	RelationshipClusterRolePolicyRule:                {},
+	RelationshipCustomResourceDefinition:             {},
+	RelationshipGatewayClass:                         {},
+	RelationshipHTTPRouteBackend:                     {},
+	RelationshipHTTPRouteParentRef:                   {},
+	RelationshipHelmRelease:                          {},
+	RelationshipHelmStorage:                          {},
+	RelationshipHorizontalPodAutoscalerScaleTarget:   {},
+	RelationshipIngressClass:                         {},
+	RelationshipIngressClassParameters:               {},
+	RelationshipIngressResource:                      {},
+	RelationshipIngressService:                       {},
+	RelationshipIngressTLSSecret:                     {},
+	RelationshipJobCronJob:                           {},
+	RelationshipMachineNodeRef:                       {},
+	RelationshipPodContainerEnv:                      {},
+	RelationshipPodImagePullSecret:                   {},
+	RelationshipPodNode:                              {},
+	RelationshipPodPriorityClass:                     {},
+	RelationshipPodRuntimeClass:                      {},
+	RelationshipPodSecurityPolicy:                    {},
+	RelationshipPodSecurityPolicyAllowedCSIDriver:    {},
+	RelationshipPodSecurityPolicyAllowedRuntimeClass: {},
+	RelationshipPodSecurityPolicyDefaultRuntimeClass: {},
+	RelationshipPodServiceAccount:                    {},
+	RelationshipRoleBindingRole:                      {},
+	RelationshipRoleBindingSubject:                   {},
+	RelationshipRolePolicyRule:                       {},
+	RelationshipServiceAccountImagePullSecret:        {},
+	RelationshipServiceAccountSecret:                 {},
+	RelationshipStorageClassProvisioner:              {},
+	RelationshipVolumeAttachmentAttacher:             {},
+	RelationshipVolumeAttachmentNode:                 {},
+	RelationshipWebhookConfigurationService:          {},
+	RelationshipWebhookConfigurationURL:              {},
+}
+
+func TestRelationshipCategoriesCoverRelationships(t *testing.T) {
+	t.Parallel()
+
+	categorized := RelationshipSet{}
+	for _, rset := range relationshipCategories {
+		for r := range rset {
+			categorized[r] = struct{}{}
+		}
+	}
+
+	// Every relationship type declared in the package must either be a base
+	// relationship or belong to a category, so that new relationship types
+	// can't be left out of --exclude-relationships
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to parse package: %v", err)
+	}
+	for _, file := range pkgs["graph"].Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if ident, ok := vs.Type.(*ast.Ident); !ok || ident.Name != "Relationship" {
+					continue
+				}
+				for ix, name := range vs.Names {
+					lit, ok := vs.Values[ix].(*ast.BasicLit)
+					if !ok {
+						t.Fatalf("expected relationship %s to be a string literal", name.Name)
+					}
+					r := Relationship(strings.Trim(lit.Value, "\""))
+					_, isBase := baseRelationships[r]
+					_, isCategorized := categorized[r]
+					if !isBase && !isCategorized {
+						t.Fatalf("expected relationship %s to belong to a relationship category", name.Name)
+					}
+					if isBase && isCategorized {
+						t.Fatalf("expected base relationship %s not to belong to any relationship category", name.Name)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestResolveRBACSubjects(t *testing.T) {
+	t.Parallel()
+
+	crb := newTestObject("rbac.authorization.k8s.io/v1", "ClusterRoleBinding", "", "binding", nil)
+	crb.Object["roleRef"] = map[string]interface{}{"apiGroup": "rbac.authorization.k8s.io", "kind": "ClusterRole", "name": "role"}
+	crb.Object["subjects"] = []interface{}{
+		map[string]interface{}{"kind": "ServiceAccount", "namespace": "team-a", "name": "sa"},
+		map[string]interface{}{"apiGroup": "rbac.authorization.k8s.io", "kind": "User", "name": "alice"},
+		map[string]interface{}{"apiGroup": "rbac.authorization.k8s.io", "kind": "Group", "name": "devs"},
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "role", nil),
+		crb,
+		newTestObject("v1", "ServiceAccount", "team-a", "sa", nil),
+	}
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-role"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	kinds := []string{}
+	for _, node := range nodeMap {
+		kinds = append(kinds, node.Kind+"/"+node.Name)
+	}
+	sort.Strings(kinds)
+	expected := []string{"ClusterRole/role", "ClusterRoleBinding/binding", "Group/devs", "ServiceAccount/sa", "User/alice"}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, kinds)
+	}
+}
+
+func TestResolveNetworkPolicyPeers(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("networking.k8s.io/v1", "NetworkPolicy", "team-a", "netpol", map[string]interface{}{
+			"podSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "db"}},
+			"ingress": []interface{}{
+				map[string]interface{}{"from": []interface{}{
+					map[string]interface{}{"podSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}}},
+					map[string]interface{}{"ipBlock": map[string]interface{}{"cidr": "10.0.0.0/8"}},
+				}},
+			},
+			"egress": []interface{}{
+				map[string]interface{}{"to": []interface{}{
+					map[string]interface{}{"namespaceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"kubernetes.io/metadata.name": "team-b"}}},
+				}},
+			},
+		}),
+		newTestPod("team-a", "db", map[string]string{"app": "db"}),
+		newTestPod("team-a", "web", map[string]string{"app": "web"}),
+		newTestPod("team-b", "dns", map[string]string{"app": "dns"}),
+		newTestPod("team-c", "other", map[string]string{"app": "web"}),
+	}
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-netpol"}, true)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	expected := map[types.UID]RelationshipSet{
+		"uid-db":  {RelationshipNetworkPolicy: {}},
+		"uid-web": {RelationshipNetworkPolicyIngressPeer: {}},
+		"uid-dns": {RelationshipNetworkPolicyEgressPeer: {}},
+	}
+	if output := nodeMap["uid-netpol"].Dependencies; !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestResolveCustomResourceDefinitionInstances(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "widgets.example.com", map[string]interface{}{
+			"group": "example.com",
+			"names": map[string]interface{}{"kind": "Widget", "plural": "widgets"},
+			"scope": "Namespaced",
+		}),
+		newTestObject("example.com/v1", "Widget", "team-a", "widget-a", nil),
+		newTestObject("example.com/v1", "Widget", "team-b", "widget-b", nil),
+		newTestObject("v1", "ConfigMap", "team-a", "cm", nil),
+	}
+
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-widgets.example.com"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	expected := []string{"uid-widget-a", "uid-widget-b", "uid-widgets.example.com"}
+	if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestResolveMachineNodeRefs(t *testing.T) {
+	t.Parallel()
+
+	machine := func(name, nodeName string) unstructuredv1.Unstructured {
+		u := newTestObject("cluster.x-k8s.io/v1beta1", "Machine", "default", name, map[string]interface{}{"clusterName": "workload-1"})
+		if len(nodeName) > 0 {
+			u.Object["status"] = map[string]interface{}{
+				"nodeRef": map[string]interface{}{"apiVersion": "v1", "kind": "Node", "name": nodeName},
+			}
+		}
+		return u
+	}
+	objects := []unstructuredv1.Unstructured{
+		machine("md-0-abc", "worker-1"),
+		machine("md-0-def", ""),
+		// A Node of the management cluster with the same name as the Node of
+		// the workload cluster
+		newTestObject("v1", "Node", "", "worker-1", nil),
+	}
+	ref := ObjectReference{Kind: "Node", Name: "worker-1", Cluster: "workload-1"}
+	nodeUID := string(ref.Key())
+
+	tests := []struct {
+		name     string
+		rootUID  types.UID
+		expected []string
+	}{
+		{"machine with a node", "uid-md-0-abc", []string{"uid-md-0-abc", nodeUID}},
+		{"machine without a node", "uid-md-0-def", []string{"uid-md-0-def"}},
+	}
+	for _, tt := range tests {
+		nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{tt.rootUID}, false)
+		if err != nil {
+			t.Fatalf("%s: failed to resolve relationships: %v", tt.name, err)
+		}
+		if output := nodeMapUIDs(nodeMap); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, output)
+		}
+	}
+
+	// Nodes of workload clusters are tagged with the name of their cluster
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{"uid-md-0-abc"}, false)
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	node := nodeMap[types.UID(nodeUID)]
+	if node.Cluster != "workload-1" || node.Kind != "Node" || node.Name != "worker-1" {
+		t.Fatalf("expected Node \"worker-1\" of cluster \"workload-1\" got %s \"%s\" of cluster \"%s\"", node.Kind, node.Name, node.Cluster)
+	}
+}
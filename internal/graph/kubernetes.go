@@ -4,7 +4,11 @@ import (
 	"strings"
 
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	eventsv1 "k8s.io/api/events/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -15,14 +19,19 @@ import (
 	schedulingv1 "k8s.io/api/scheduling/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authentication/serviceaccount"
 	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
 	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	storageutil "k8s.io/kubectl/pkg/util/storage"
 )
 
 // Well-known labels & annotations.
@@ -31,6 +40,21 @@ const (
 	// as "kubernetes.io/psp" so we don't need import the entire k8s.io/kubernetes
 	// package.
 	ValidatedPSPAnnotation = "kubernetes.io/psp"
+
+	// ExternalEndpointGroup & ExternalEndpointKind are the group & kind of
+	// synthetic objects representing endpoints outside of the cluster (eg.
+	// webhooks configured with a URL). The group isn't a valid API group name so
+	// they can't be mistaken for objects served by the API server.
+	ExternalEndpointGroup = "external"
+	ExternalEndpointKind  = "ExternalEndpoint"
+
+	// IngressClassAnnotation is the deprecated annotation used to specify the
+	// class of an Ingress before the "spec.ingressClassName" field existed.
+	IngressClassAnnotation = "kubernetes.io/ingress.class"
+
+	// JobNameLabel is the label set by the Job controller on the Pods it
+	// creates.
+	JobNameLabel = "job-name"
 )
 
 const (
@@ -48,14 +72,21 @@ const (
 
 	// Kubernetes CSINode relationships.
 	RelationshipCSINodeDriver Relationship = "CSINodeDriver"
+	RelationshipCSINodeNode   Relationship = "CSINodeNode"
 
 	// Kubernetes CSIStorageCapacity relationships.
 	RelationshipCSIStorageCapacityStorageClass Relationship = "CSIStorageCapacityStorageClass"
 
+	// Kubernetes CustomResourceDefinition relationships.
+	RelationshipCustomResourceDefinition Relationship = "CustomResourceDefinition"
+
 	// Kubernetes Event relationships.
 	RelationshipEventRegarding Relationship = "EventRegarding"
 	RelationshipEventRelated   Relationship = "EventRelated"
 
+	// Kubernetes HorizontalPodAutoscaler relationships.
+	RelationshipHorizontalPodAutoscalerScaleTarget Relationship = "HorizontalPodAutoscalerScaleTarget"
+
 	// Kubernetes Ingress & IngressClass relationships.
 	RelationshipIngressClass           Relationship = "IngressClass"
 	RelationshipIngressClassParameters Relationship = "IngressClassParameters"
@@ -63,21 +94,29 @@ const (
 	RelationshipIngressService         Relationship = "IngressService"
 	RelationshipIngressTLSSecret       Relationship = "IngressTLSSecret"
 
+	// Kubernetes Job relationships.
+	RelationshipJob        Relationship = "Job"
+	RelationshipJobCronJob Relationship = "JobCronJob"
+
 	// Kubernetes MutatingWebhookConfiguration & ValidatingWebhookConfiguration relationships.
 	RelationshipWebhookConfigurationService Relationship = "WebhookConfigurationService"
+	RelationshipWebhookConfigurationURL     Relationship = "WebhookConfigurationURL"
 
 	// Kubernetes RelationshipNetworkPolicy relationships.
-	RelationshipNetworkPolicy Relationship = "NetworkPolicy"
+	RelationshipNetworkPolicy            Relationship = "NetworkPolicy"
+	RelationshipNetworkPolicyEgressPeer  Relationship = "NetworkPolicyEgressPeer"
+	RelationshipNetworkPolicyIngressPeer Relationship = "NetworkPolicyIngressPeer"
 
 	// Kubernetes Owner-Dependent relationships.
 	RelationshipControllerRef Relationship = "ControllerReference"
 	RelationshipOwnerRef      Relationship = "OwnerReference"
 
 	// Kubernetes PersistentVolume & PersistentVolumeClaim relationships.
-	RelationshipPersistentVolumeClaim           Relationship = "PersistentVolumeClaim"
-	RelationshipPersistentVolumeCSIDriver       Relationship = "PersistentVolumeCSIDriver"
-	RelationshipPersistentVolumeCSIDriverSecret Relationship = "PersistentVolumeCSIDriverSecret"
-	RelationshipPersistentVolumeStorageClass    Relationship = "PersistentVolumeStorageClass"
+	RelationshipPersistentVolumeClaim             Relationship = "PersistentVolumeClaim"
+	RelationshipPersistentVolumeClaimStorageClass Relationship = "PersistentVolumeClaimStorageClass"
+	RelationshipPersistentVolumeCSIDriver         Relationship = "PersistentVolumeCSIDriver"
+	RelationshipPersistentVolumeCSIDriverSecret   Relationship = "PersistentVolumeCSIDriverSecret"
+	RelationshipPersistentVolumeStorageClass      Relationship = "PersistentVolumeStorageClass"
 
 	// Kubernetes Pod relationships.
 	RelationshipPodContainerEnv          Relationship = "PodContainerEnvironment"
@@ -103,7 +142,10 @@ const (
 	RelationshipRuntimeClass Relationship = "RuntimeClass"
 
 	// Kubernetes Service relationships.
-	RelationshipService Relationship = "Service"
+	RelationshipService              Relationship = "Service"
+	RelationshipServiceEndpointPod   Relationship = "ServiceEndpointPod"
+	RelationshipServiceEndpoints     Relationship = "ServiceEndpoints"
+	RelationshipServiceEndpointSlice Relationship = "ServiceEndpointSlice"
 
 	// Kubernetes ServiceAccount relationships.
 	RelationshipServiceAccountImagePullSecret Relationship = "ServiceAccountImagePullSecret"
@@ -122,6 +164,69 @@ const (
 	RelationshipVolumeAttachmentSourceVolumeStorageClass    Relationship = "VolumeAttachmentSourceVolumeStorageClass"
 )
 
+// Relationship categories.
+const (
+	RelationshipCategoryEvent    RelationshipCategory = "event"
+	RelationshipCategoryGitOps   RelationshipCategory = "gitops"
+	RelationshipCategoryOwnerRef RelationshipCategory = "ownerref"
+	RelationshipCategorySelector RelationshipCategory = "selector"
+	RelationshipCategoryVolume   RelationshipCategory = "volume"
+)
+
+// relationshipCategories maps each relationship category to its relationship
+// types.
+var relationshipCategories = map[RelationshipCategory]RelationshipSet{
+	// Relationships between Events & the objects they're about
+	RelationshipCategoryEvent: {
+		RelationshipEventRegarding: {},
+		RelationshipEventRelated:   {},
+	},
+	// Relationships between GitOps resources & the objects they manage
+	RelationshipCategoryGitOps: {
+		RelationshipArgoCDApplication: {},
+		RelationshipFluxHelmRelease:   {},
+		RelationshipFluxKustomization: {},
+	},
+	// Relationships declared in the owner references of objects
+	RelationshipCategoryOwnerRef: {
+		RelationshipControllerRef: {},
+		RelationshipOwnerRef:      {},
+	},
+	// Relationships inferred from label selectors, along with the Endpoints,
+	// EndpointSlices & Pods backing Services
+	RelationshipCategorySelector: {
+		RelationshipClusterRoleAggregationRule: {},
+		RelationshipJob:                        {},
+		RelationshipNetworkPolicy:              {},
+		RelationshipNetworkPolicyEgressPeer:    {},
+		RelationshipNetworkPolicyIngressPeer:   {},
+		RelationshipPodDisruptionBudget:        {},
+		RelationshipRuntimeClass:               {},
+		RelationshipService:                    {},
+		RelationshipServiceEndpointPod:         {},
+		RelationshipServiceEndpoints:           {},
+		RelationshipServiceEndpointSlice:       {},
+	},
+	// Relationships between Pods or VolumeAttachments & the volumes (or CSI
+	// drivers) they use, along with the bindings between PersistentVolumes &
+	// their claims and the StorageClasses they're provisioned from
+	RelationshipCategoryVolume: {
+		RelationshipPersistentVolumeCSIDriver:                   {},
+		RelationshipPersistentVolumeCSIDriverSecret:             {},
+		RelationshipPersistentVolumeClaim:                       {},
+		RelationshipPersistentVolumeClaimStorageClass:           {},
+		RelationshipPersistentVolumeStorageClass:                {},
+		RelationshipPodVolume:                                   {},
+		RelationshipPodVolumeCSIDriver:                          {},
+		RelationshipPodVolumeCSIDriverSecret:                    {},
+		RelationshipVolumeAttachmentSourceVolume:                {},
+		RelationshipVolumeAttachmentSourceVolumeCSIDriver:       {},
+		RelationshipVolumeAttachmentSourceVolumeCSIDriverSecret: {},
+		RelationshipVolumeAttachmentSourceVolumeClaim:           {},
+		RelationshipVolumeAttachmentSourceVolumeStorageClass:    {},
+	},
+}
+
 // getAPIServiceRelationships returns a map of relationships that this
 // APIService has with other objects, based on what was referenced in its
 // manifest.
@@ -136,9 +241,12 @@ func getAPIServiceRelationships(n *Node) (*RelationshipMap, error) {
 	result := newRelationshipMap()
 
 	// RelationshipAPIService
-	if svc := apisvc.Spec.Service; svc != nil {
+	// Local APIServices are served by the kube-apiserver itself & have no
+	// service, while missing Services are kept to show why an aggregated API
+	// is unavailable
+	if svc := apisvc.Spec.Service; svc != nil && len(svc.Name) > 0 {
 		ref = ObjectReference{Kind: "Service", Namespace: svc.Namespace, Name: svc.Name}
-		result.AddDependencyByKey(ref.Key(), RelationshipAPIService)
+		result.AddDependencyByReference(ref, RelationshipAPIService)
 	}
 
 	return &result, nil
@@ -229,6 +337,10 @@ func getClusterRoleBindingRelationships(n *Node) (*RelationshipMap, error) {
 					sns := s.Name[len(serviceaccount.ServiceAccountGroupPrefix):]
 					os = ObjectSelector{Kind: "ServiceAccount", Namespaces: sets.NewString(sns)}
 					result.AddDependentBySelector(os, RelationshipClusterRoleBindingSubject)
+				// Any other group isn't backed by objects in the cluster
+				default:
+					ref = ObjectReference{Group: rbacv1.GroupName, Kind: rbacv1.GroupKind, Name: s.Name}
+					result.AddDependentBySubject(ref, RelationshipClusterRoleBindingSubject)
 				}
 			}
 		case rbacv1.UserKind:
@@ -237,6 +349,11 @@ func getClusterRoleBindingRelationships(n *Node) (*RelationshipMap, error) {
 				if err == nil {
 					ref = ObjectReference{Kind: "ServiceAccount", Namespace: ns, Name: sa}
 					result.AddDependentByKey(ref.Key(), RelationshipClusterRoleBindingSubject)
+				} else {
+					// Users that aren't ServiceAccounts aren't backed by objects in
+					// the cluster
+					ref = ObjectReference{Group: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: s.Name}
+					result.AddDependentBySubject(ref, RelationshipClusterRoleBindingSubject)
 				}
 			}
 		}
@@ -264,6 +381,10 @@ func getCSINodeRelationships(n *Node) (*RelationshipMap, error) {
 	var ref ObjectReference
 	result := newRelationshipMap()
 
+	// RelationshipCSINodeNode
+	ref = ObjectReference{Kind: "Node", Name: csin.Name}
+	result.AddDependencyByKey(ref.Key(), RelationshipCSINodeNode)
+
 	// RelationshipCSINodeDriver
 	for _, d := range csin.Spec.Drivers {
 		ref = ObjectReference{Group: storagev1.GroupName, Kind: "CSIDriver", Name: d.Name}
@@ -295,6 +416,28 @@ func getCSIStorageCapacityRelationships(n *Node) (*RelationshipMap, error) {
 	return &result, nil
 }
 
+// getCustomResourceDefinitionRelationships returns a map of relationships that
+// this CustomResourceDefinition has with other objects, based on what was
+// referenced in its manifest.
+func getCustomResourceDefinitionRelationships(n *Node) (*RelationshipMap, error) {
+	var crd apiextensionsv1.CustomResourceDefinition
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &crd)
+	if err != nil {
+		return nil, err
+	}
+
+	var os ObjectSelector
+	result := newRelationshipMap()
+
+	// RelationshipCustomResourceDefinition
+	if len(crd.Spec.Group) > 0 && len(crd.Spec.Names.Kind) > 0 {
+		os = ObjectSelector{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}
+		result.AddDependentBySelector(os, RelationshipCustomResourceDefinition)
+	}
+
+	return &result, nil
+}
+
 // getEventRelationships returns a map of relationships that this Event has with
 // other objects, based on what was referenced in its manifest.
 //nolint:unparam
@@ -303,7 +446,7 @@ func getEventRelationships(n *Node) (*RelationshipMap, error) {
 	switch n.Group {
 	case corev1.GroupName:
 		// RelationshipEventRegarding
-		regUID := types.UID(n.GetNestedString("involvedobject", "uid"))
+		regUID := types.UID(n.GetNestedString("involvedObject", "uid"))
 		result.AddDependencyByUID(regUID, RelationshipEventRegarding)
 	case eventsv1.GroupName:
 		// RelationshipEventRegarding
@@ -317,6 +460,33 @@ func getEventRelationships(n *Node) (*RelationshipMap, error) {
 	return &result, nil
 }
 
+// getHorizontalPodAutoscalerRelationships returns a map of relationships that
+// this HorizontalPodAutoscaler has with other objects, based on what was
+// referenced in its manifest.
+func getHorizontalPodAutoscalerRelationships(n *Node) (*RelationshipMap, error) {
+	var hpa autoscalingv1.HorizontalPodAutoscaler
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &hpa)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	ns := hpa.Namespace
+	result := newRelationshipMap()
+
+	// RelationshipHorizontalPodAutoscalerScaleTarget
+	if t := hpa.Spec.ScaleTargetRef; len(t.Kind) > 0 && len(t.Name) > 0 {
+		gv, err := schema.ParseGroupVersion(t.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+		ref = ObjectReference{Group: gv.Group, Kind: t.Kind, Name: t.Name, Namespace: ns}
+		result.AddDependentByReference(ref, RelationshipHorizontalPodAutoscalerScaleTarget)
+	}
+
+	return &result, nil
+}
+
 // getIngressRelationships returns a map of relationships that this Ingress has
 // with other objects, based on what was referenced in its manifest.
 //nolint:funlen,gocognit
@@ -324,8 +494,11 @@ func getIngressRelationships(n *Node) (*RelationshipMap, error) {
 	var ref ObjectReference
 	ns := n.Namespace
 	result := newRelationshipMap()
-	switch n.Group {
-	case extensionsv1beta1.GroupName:
+	switch {
+	// Both "extensions/v1beta1" & "networking.k8s.io/v1beta1" Ingresses share
+	// the same schema
+	case n.Group == extensionsv1beta1.GroupName,
+		n.Group == networkingv1.GroupName && n.Version == "v1beta1":
 		var ing extensionsv1beta1.Ingress
 		err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &ing)
 		if err != nil {
@@ -333,8 +506,8 @@ func getIngressRelationships(n *Node) (*RelationshipMap, error) {
 		}
 
 		// RelationshipIngressClass
-		if ingc := ing.Spec.IngressClassName; ingc != nil && len(*ingc) > 0 {
-			ref = ObjectReference{Group: networkingv1.GroupName, Kind: "IngressClass", Name: *ingc}
+		if ingc := getIngressClassName(ing.Spec.IngressClassName, ing.Annotations); len(ingc) > 0 {
+			ref = ObjectReference{Group: networkingv1.GroupName, Kind: "IngressClass", Name: ingc}
 			result.AddDependencyByKey(ref.Key(), RelationshipIngressClass)
 		}
 
@@ -368,10 +541,13 @@ func getIngressRelationships(n *Node) (*RelationshipMap, error) {
 
 		// RelationshipIngressTLSSecret
 		for _, tls := range ing.Spec.TLS {
+			if len(tls.SecretName) == 0 {
+				continue
+			}
 			ref = ObjectReference{Kind: "Secret", Name: tls.SecretName, Namespace: ns}
-			result.AddDependencyByKey(ref.Key(), RelationshipIngressClass)
+			result.AddDependencyByKey(ref.Key(), RelationshipIngressTLSSecret)
 		}
-	case networkingv1.GroupName:
+	case n.Group == networkingv1.GroupName:
 		var ing networkingv1.Ingress
 		err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &ing)
 		if err != nil {
@@ -379,8 +555,8 @@ func getIngressRelationships(n *Node) (*RelationshipMap, error) {
 		}
 
 		// RelationshipIngressClass
-		if ingc := ing.Spec.IngressClassName; ingc != nil && len(*ingc) > 0 {
-			ref = ObjectReference{Group: networkingv1.GroupName, Kind: "IngressClass", Name: *ingc}
+		if ingc := getIngressClassName(ing.Spec.IngressClassName, ing.Annotations); len(ingc) > 0 {
+			ref = ObjectReference{Group: networkingv1.GroupName, Kind: "IngressClass", Name: ingc}
 			result.AddDependencyByKey(ref.Key(), RelationshipIngressClass)
 		}
 
@@ -414,14 +590,27 @@ func getIngressRelationships(n *Node) (*RelationshipMap, error) {
 
 		// RelationshipIngressTLSSecret
 		for _, tls := range ing.Spec.TLS {
+			if len(tls.SecretName) == 0 {
+				continue
+			}
 			ref = ObjectReference{Kind: "Secret", Name: tls.SecretName, Namespace: ns}
-			result.AddDependencyByKey(ref.Key(), RelationshipIngressClass)
+			result.AddDependencyByKey(ref.Key(), RelationshipIngressTLSSecret)
 		}
 	}
 
 	return &result, nil
 }
 
+// getIngressClassName returns the class of an Ingress, falling back to the
+// deprecated "kubernetes.io/ingress.class" annotation if the
+// "spec.ingressClassName" field isn't set.
+func getIngressClassName(ingressClassName *string, annotations map[string]string) string {
+	if ingressClassName != nil && len(*ingressClassName) > 0 {
+		return *ingressClassName
+	}
+	return annotations[IngressClassAnnotation]
+}
+
 // getIngressClassRelationships returns a map of relationships that this
 // IngressClass has with other objects, based on what was referenced in its
 // manifest.
@@ -452,6 +641,49 @@ func getIngressClassRelationships(n *Node) (*RelationshipMap, error) {
 	return &result, nil
 }
 
+// getJobRelationships returns a map of relationships that this Job has with
+// other objects, based on what was referenced in its manifest.
+func getJobRelationships(n *Node) (*RelationshipMap, error) {
+	var job batchv1.Job
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &job)
+	if err != nil {
+		return nil, err
+	}
+
+	var ols ObjectLabelSelector
+	var ref ObjectReference
+	ns := job.Namespace
+	result := newRelationshipMap()
+
+	// RelationshipJob
+	selector, err := labels.ValidatedSelectorFromSet(labels.Set{JobNameLabel: job.Name})
+	if err != nil {
+		return nil, err
+	}
+	ols = ObjectLabelSelector{Kind: "Pod", Namespace: ns, Selector: selector}
+	result.AddDependentByLabelSelector(ols, RelationshipJob)
+
+	// Jobs created by a CronJob are named "<cronjob-name>-<scheduled-time>",
+	// so fallback to that if the Job isn't controlled by any object (eg. its
+	// owner reference was removed when orphaning it)
+	// RelationshipJobCronJob
+	if metav1.GetControllerOfNoCopy(&job) == nil {
+		if ix := strings.LastIndex(job.Name, "-"); ix > 0 && isDigits(job.Name[ix+1:]) {
+			ref = ObjectReference{Group: batchv1.GroupName, Kind: "CronJob", Name: job.Name[:ix], Namespace: ns}
+			result.AddDependencyByKey(ref.Key(), RelationshipJobCronJob)
+		}
+	}
+
+	return &result, nil
+}
+
+// newExternalEndpointReference returns a reference to the synthetic object
+// representing the provided URL. Webhooks configured with a URL aren't backed
+// by objects in the cluster, so we represent them as synthetic objects.
+func newExternalEndpointReference(url string) ObjectReference {
+	return ObjectReference{Group: ExternalEndpointGroup, Kind: ExternalEndpointKind, Name: url}
+}
+
 // getMutatingWebhookConfigurationRelationships returns a map of relationships
 // that this MutatingWebhookConfiguration has with other objects, based on what
 // was referenced in its manifest.
@@ -473,6 +705,14 @@ func getMutatingWebhookConfigurationRelationships(n *Node) (*RelationshipMap, er
 		}
 	}
 
+	// RelationshipWebhookConfigurationURL
+	for _, wh := range mwc.Webhooks {
+		if url := wh.ClientConfig.URL; url != nil && len(*url) > 0 {
+			ref = newExternalEndpointReference(*url)
+			result.AddDependencyBySubject(ref, RelationshipWebhookConfigurationURL)
+		}
+	}
+
 	return &result, nil
 }
 
@@ -498,9 +738,62 @@ func getNetworkPolicyRelationships(n *Node) (*RelationshipMap, error) {
 	ols = ObjectLabelSelector{Kind: "Pod", Namespace: ns, Selector: selector}
 	result.AddDependencyByLabelSelector(ols, RelationshipNetworkPolicy)
 
+	// RelationshipNetworkPolicyIngressPeer
+	for _, rule := range netpol.Spec.Ingress {
+		for _, peer := range rule.From {
+			ols, ok, err := networkPolicyPeerToSelector(peer, ns)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				result.AddDependencyByLabelSelector(ols, RelationshipNetworkPolicyIngressPeer)
+			}
+		}
+	}
+
+	// RelationshipNetworkPolicyEgressPeer
+	for _, rule := range netpol.Spec.Egress {
+		for _, peer := range rule.To {
+			ols, ok, err := networkPolicyPeerToSelector(peer, ns)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				result.AddDependencyByLabelSelector(ols, RelationshipNetworkPolicyEgressPeer)
+			}
+		}
+	}
+
 	return &result, nil
 }
 
+// networkPolicyPeerToSelector converts the provided NetworkPolicyPeer into a
+// selector for the Pods it selects. Returns false if the peer doesn't select
+// any Pods (i.e. it's an IPBlock).
+func networkPolicyPeerToSelector(peer networkingv1.NetworkPolicyPeer, ns string) (ObjectLabelSelector, bool, error) {
+	if peer.PodSelector == nil && peer.NamespaceSelector == nil {
+		return ObjectLabelSelector{}, false, nil
+	}
+	// A nil PodSelector selects all Pods in the selected namespaces
+	selector := labels.Everything()
+	if peer.PodSelector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(peer.PodSelector)
+		if err != nil {
+			return ObjectLabelSelector{}, false, err
+		}
+	}
+	ols := ObjectLabelSelector{Kind: "Pod", Namespace: ns, Selector: selector}
+	if peer.NamespaceSelector != nil {
+		nsSelector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+		if err != nil {
+			return ObjectLabelSelector{}, false, err
+		}
+		ols.NamespaceSelector = nsSelector
+	}
+	return ols, true, nil
+}
+
 // getPersistentVolumeRelationships returns a map of relationships that this
 // PersistentVolume has with other objects, based on what was referenced in its
 // manifest.
@@ -512,12 +805,11 @@ func getPersistentVolumeRelationships(n *Node) (*RelationshipMap, error) {
 	}
 
 	var ref ObjectReference
-	ns := pv.Namespace
 	result := newRelationshipMap()
 
 	// RelationshipPersistentVolumeClaim
 	if pvcRef := pv.Spec.ClaimRef; pvcRef != nil {
-		ref = ObjectReference{Kind: "PersistentVolumeClaim", Name: pvcRef.Name, Namespace: ns}
+		ref = ObjectReference{Kind: "PersistentVolumeClaim", Name: pvcRef.Name, Namespace: pvcRef.Namespace}
 		result.AddDependentByKey(ref.Key(), RelationshipPersistentVolumeClaim)
 	}
 
@@ -550,7 +842,7 @@ func getPersistentVolumeRelationships(n *Node) (*RelationshipMap, error) {
 	}
 
 	// RelationshipPersistentVolumeStorageClass
-	if sc := pv.Spec.StorageClassName; len(sc) > 0 {
+	if sc := storageutil.GetPersistentVolumeClass(&pv); len(sc) > 0 {
 		ref = ObjectReference{Group: storagev1.GroupName, Kind: "StorageClass", Name: sc}
 		result.AddDependencyByKey(ref.Key(), RelationshipPersistentVolumeStorageClass)
 	}
@@ -577,6 +869,12 @@ func getPersistentVolumeClaimRelationships(n *Node) (*RelationshipMap, error) {
 		result.AddDependencyByKey(ref.Key(), RelationshipPersistentVolumeClaim)
 	}
 
+	// RelationshipPersistentVolumeClaimStorageClass
+	if sc := storageutil.GetPersistentVolumeClaimClass(&pvc); len(sc) > 0 {
+		ref = ObjectReference{Group: storagev1.GroupName, Kind: "StorageClass", Name: sc}
+		result.AddDependencyByKey(ref.Key(), RelationshipPersistentVolumeClaimStorageClass)
+	}
+
 	return &result, nil
 }
 
@@ -598,6 +896,9 @@ func getPodRelationships(n *Node) (*RelationshipMap, error) {
 	var cList []corev1.Container
 	cList = append(cList, pod.Spec.InitContainers...)
 	cList = append(cList, pod.Spec.Containers...)
+	for _, ec := range pod.Spec.EphemeralContainers {
+		cList = append(cList, corev1.Container(ec.EphemeralContainerCommon))
+	}
 	for _, c := range cList {
 		for _, env := range c.EnvFrom {
 			switch {
@@ -631,8 +932,10 @@ func getPodRelationships(n *Node) (*RelationshipMap, error) {
 	}
 
 	// RelationshipPodNode
-	ref = ObjectReference{Kind: "Node", Name: pod.Spec.NodeName}
-	result.AddDependencyByKey(ref.Key(), RelationshipPodNode)
+	if node := pod.Spec.NodeName; len(node) != 0 {
+		ref = ObjectReference{Kind: "Node", Name: node}
+		result.AddDependencyByKey(ref.Key(), RelationshipPodNode)
+	}
 
 	// RelationshipPodPriorityClass
 	if pc := pod.Spec.PriorityClassName; len(pc) != 0 {
@@ -832,6 +1135,10 @@ func getRoleBindingRelationships(n *Node) (*RelationshipMap, error) {
 					sns := s.Name[len(serviceaccount.ServiceAccountGroupPrefix):]
 					os = ObjectSelector{Kind: "ServiceAccount", Namespaces: sets.NewString(sns)}
 					result.AddDependentBySelector(os, RelationshipRoleBindingSubject)
+				// Any other group isn't backed by objects in the cluster
+				default:
+					ref = ObjectReference{Group: rbacv1.GroupName, Kind: rbacv1.GroupKind, Name: s.Name}
+					result.AddDependentBySubject(ref, RelationshipRoleBindingSubject)
 				}
 			}
 		case rbacv1.UserKind:
@@ -840,6 +1147,11 @@ func getRoleBindingRelationships(n *Node) (*RelationshipMap, error) {
 				if err == nil {
 					ref = ObjectReference{Kind: "ServiceAccount", Namespace: ns, Name: sa}
 					result.AddDependentByKey(ref.Key(), RelationshipRoleBindingSubject)
+				} else {
+					// Users that aren't ServiceAccounts aren't backed by objects in
+					// the cluster
+					ref = ObjectReference{Group: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: s.Name}
+					result.AddDependentBySubject(ref, RelationshipRoleBindingSubject)
 				}
 			}
 		}
@@ -886,6 +1198,30 @@ func getRuntimeClassRelationships(n *Node) (*RelationshipMap, error) {
 	return &result, nil
 }
 
+// getSecretRelationships returns a map of relationships that this Secret has
+// with other objects, based on what was referenced in its manifest. Token
+// Secrets are referenced by the ServiceAccount they belong to through
+// annotations, since they aren't necessarily listed in the ServiceAccount's
+// secrets (eg. Secrets created manually for Kubernetes v1.24 & above).
+//nolint:unparam
+func getSecretRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipServiceAccountSecret
+	if n.GetNestedString("type") == string(corev1.SecretTypeServiceAccountToken) {
+		annotations := n.GetAnnotations()
+		if uid := annotations[corev1.ServiceAccountUIDKey]; len(uid) > 0 {
+			result.AddDependencyByUID(types.UID(uid), RelationshipServiceAccountSecret)
+		} else if sa := annotations[corev1.ServiceAccountNameKey]; len(sa) > 0 {
+			ref = ObjectReference{Kind: "ServiceAccount", Name: sa, Namespace: n.Namespace}
+			result.AddDependencyByKey(ref.Key(), RelationshipServiceAccountSecret)
+		}
+	}
+
+	return &result, nil
+}
+
 // getServiceRelationships returns a map of relationships that this
 // Service has with other objects, based on what was referenced in its
 // manifest.
@@ -897,20 +1233,102 @@ func getServiceRelationships(n *Node) (*RelationshipMap, error) {
 	}
 
 	var ols ObjectLabelSelector
+	var ref ObjectReference
 	ns := svc.Namespace
 	result := newRelationshipMap()
 
 	// RelationshipService
-	selector, err := labels.ValidatedSelectorFromSet(labels.Set(svc.Spec.Selector))
+	if len(svc.Spec.Selector) > 0 {
+		selector, err := labels.ValidatedSelectorFromSet(labels.Set(svc.Spec.Selector))
+		if err != nil {
+			return nil, err
+		}
+		ols = ObjectLabelSelector{Kind: "Pod", Namespace: ns, Selector: selector}
+		result.AddDependencyByLabelSelector(ols, RelationshipService)
+		return &result, nil
+	}
+
+	// Services without selectors have their endpoints managed manually or by
+	// an external controller
+	// RelationshipServiceEndpoints
+	ref = ObjectReference{Kind: "Endpoints", Name: svc.Name, Namespace: ns}
+	result.AddDependencyByKey(ref.Key(), RelationshipServiceEndpoints)
+
+	// RelationshipServiceEndpointSlice
+	selector, err := labels.ValidatedSelectorFromSet(labels.Set{discoveryv1.LabelServiceName: svc.Name})
 	if err != nil {
 		return nil, err
 	}
-	ols = ObjectLabelSelector{Kind: "Pod", Namespace: ns, Selector: selector}
-	result.AddDependencyByLabelSelector(ols, RelationshipService)
+	ols = ObjectLabelSelector{Group: discoveryv1.GroupName, Kind: "EndpointSlice", Namespace: ns, Selector: selector}
+	result.AddDependencyByLabelSelector(ols, RelationshipServiceEndpointSlice)
 
 	return &result, nil
 }
 
+// getEndpointSliceTargets returns a reference to the Service that this
+// EndpointSlice belongs to & references to the Pods targeted by its endpoints.
+// A nil Service reference is returned if the EndpointSlice doesn't belong to
+// any Service.
+func getEndpointSliceTargets(n *Node) (*ObjectReference, []ObjectReference, error) {
+	var eps discoveryv1.EndpointSlice
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &eps)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	svcName, ok := eps.Labels[discoveryv1.LabelServiceName]
+	if !ok || len(svcName) == 0 {
+		return nil, nil, nil
+	}
+	ns := eps.Namespace
+	svc := ObjectReference{Kind: "Service", Name: svcName, Namespace: ns}
+	var targets []ObjectReference
+	for _, e := range eps.Endpoints {
+		if ref := getEndpointPodReference(e.TargetRef, ns); ref != nil {
+			targets = append(targets, *ref)
+		}
+	}
+
+	return &svc, targets, nil
+}
+
+// getEndpointsTargets returns a reference to the Service that this Endpoints
+// belongs to & references to the Pods targeted by its addresses.
+func getEndpointsTargets(n *Node) (*ObjectReference, []ObjectReference, error) {
+	var ep corev1.Endpoints
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &ep)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ns := ep.Namespace
+	svc := ObjectReference{Kind: "Service", Name: ep.Name, Namespace: ns}
+	var targets []ObjectReference
+	for _, s := range ep.Subsets {
+		for _, addrs := range [][]corev1.EndpointAddress{s.Addresses, s.NotReadyAddresses} {
+			for _, a := range addrs {
+				if ref := getEndpointPodReference(a.TargetRef, ns); ref != nil {
+					targets = append(targets, *ref)
+				}
+			}
+		}
+	}
+
+	return &svc, targets, nil
+}
+
+// getEndpointPodReference returns a reference to the Pod targeted by an
+// endpoint, or nil if the endpoint doesn't target a Pod.
+func getEndpointPodReference(ref *corev1.ObjectReference, ns string) *ObjectReference {
+	if ref == nil || ref.Kind != "Pod" || len(ref.Name) == 0 {
+		return nil
+	}
+	if len(ref.Namespace) > 0 {
+		ns = ref.Namespace
+	}
+	return &ObjectReference{Kind: "Pod", Name: ref.Name, Namespace: ns}
+}
+
 // getServiceAccountRelationships returns a map of relationships that this
 // ServiceAccount has with other objects, based on what was referenced in its
 // manifest.
@@ -983,6 +1401,14 @@ func getValidatingWebhookConfigurationRelationships(n *Node) (*RelationshipMap,
 		}
 	}
 
+	// RelationshipWebhookConfigurationURL
+	for _, wh := range vwc.Webhooks {
+		if url := wh.ClientConfig.URL; url != nil && len(*url) > 0 {
+			ref = newExternalEndpointReference(*url)
+			result.AddDependencyBySubject(ref, RelationshipWebhookConfigurationURL)
+		}
+	}
+
 	return &result, nil
 }
 
@@ -1007,15 +1433,18 @@ func getVolumeAttachmentRelationships(n *Node) (*RelationshipMap, error) {
 	}
 
 	// RelationshipVolumeAttachmentNode
+	// Attachments may outlive the Node they're attached to, so missing Nodes
+	// are kept to show which attachments are stuck
 	if n := va.Spec.NodeName; len(n) > 0 {
 		ref = ObjectReference{Kind: "Node", Name: n}
-		result.AddDependencyByKey(ref.Key(), RelationshipVolumeAttachmentNode)
+		result.AddDependencyByReference(ref, RelationshipVolumeAttachmentNode)
 	}
 
 	// RelationshipVolumeAttachmentSourceVolume
+	// Attachments may outlive their PersistentVolume as well
 	if pvName := va.Spec.Source.PersistentVolumeName; pvName != nil && len(*pvName) > 0 {
 		ref = ObjectReference{Kind: "PersistentVolume", Name: *pvName}
-		result.AddDependentByKey(ref.Key(), RelationshipVolumeAttachmentSourceVolume)
+		result.AddDependentByReference(ref, RelationshipVolumeAttachmentSourceVolume)
 	}
 
 	if iv := va.Spec.Source.InlineVolumeSpec; iv != nil {
@@ -1061,6 +1490,113 @@ func getVolumeAttachmentRelationships(n *Node) (*RelationshipMap, error) {
 	return &result, nil
 }
 
+// InferOwnerReferences adds owner references to ReplicaSets & Pods that aren't
+// controlled by any object, inferring their owners from the
+// "pod-template-hash" label set by the Deployment controller:
+//   - A ReplicaSet named "<name>-<pod-template-hash>" is owned by the
+//     Deployment named "<name>" in the same namespace, if the Deployment's
+//     selector matches the ReplicaSet's labels.
+//   - A Pod is owned by the ReplicaSet in the same namespace with the same
+//     "pod-template-hash" label, if the ReplicaSet's selector matches the Pod's
+//     labels.
+// Owners are only inferred when exactly one candidate matches, to avoid
+// creating false relationships between objects sharing the same labels.
+//nolint:funlen,gocognit
+func InferOwnerReferences(objects []unstructuredv1.Unstructured) {
+	deployments := map[string]*appsv1.Deployment{}
+	replicaSets := map[string][]*appsv1.ReplicaSet{}
+	for ix := range objects {
+		o := &objects[ix]
+		if o.GroupVersionKind().Group != appsv1.GroupName {
+			continue
+		}
+		switch o.GetKind() {
+		case "Deployment":
+			var d appsv1.Deployment
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &d); err == nil {
+				deployments[d.Namespace+"/"+d.Name] = &d
+			}
+		case "ReplicaSet":
+			var rs appsv1.ReplicaSet
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &rs); err == nil {
+				replicaSets[rs.Namespace] = append(replicaSets[rs.Namespace], &rs)
+			}
+		}
+	}
+	hasControllerRef := func(o *unstructuredv1.Unstructured) bool {
+		for _, ref := range o.GetOwnerReferences() {
+			if ref.Controller != nil && *ref.Controller {
+				return true
+			}
+		}
+		return false
+	}
+	addOwnerRef := func(o *unstructuredv1.Unstructured, owner metav1.Object, apiVersion, kind string) {
+		klog.V(4).Infof("Inferred %s \"%s\" as the owner of %s \"%s\" in namespace \"%s\"", kind, owner.GetName(), o.GetKind(), o.GetName(), o.GetNamespace())
+		o.SetOwnerReferences(append(o.GetOwnerReferences(), metav1.OwnerReference{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Name:       owner.GetName(),
+			UID:        owner.GetUID(),
+		}))
+	}
+	selectorMatches := func(ls *metav1.LabelSelector, lbls map[string]string) bool {
+		if ls == nil {
+			return false
+		}
+		selector, err := metav1.LabelSelectorAsSelector(ls)
+		if err != nil || selector.Empty() {
+			return false
+		}
+		return selector.Matches(labels.Set(lbls))
+	}
+
+	for ix := range objects {
+		o := &objects[ix]
+		hash := o.GetLabels()[appsv1.DefaultDeploymentUniqueLabelKey]
+		if len(hash) == 0 || hasControllerRef(o) {
+			continue
+		}
+		switch gvk := o.GroupVersionKind(); {
+		case gvk.Group == appsv1.GroupName && gvk.Kind == "ReplicaSet":
+			if !strings.HasSuffix(o.GetName(), "-"+hash) {
+				continue
+			}
+			name := strings.TrimSuffix(o.GetName(), "-"+hash)
+			d, ok := deployments[o.GetNamespace()+"/"+name]
+			if !ok || !selectorMatches(d.Spec.Selector, o.GetLabels()) {
+				continue
+			}
+			addOwnerRef(o, d, appsv1.SchemeGroupVersion.String(), "Deployment")
+		case gvk.Group == corev1.GroupName && gvk.Kind == "Pod":
+			var candidates []*appsv1.ReplicaSet
+			for _, rs := range replicaSets[o.GetNamespace()] {
+				if rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey] == hash && selectorMatches(rs.Spec.Selector, o.GetLabels()) {
+					candidates = append(candidates, rs)
+				}
+			}
+			if len(candidates) != 1 {
+				continue
+			}
+			addOwnerRef(o, candidates[0], appsv1.SchemeGroupVersion.String(), "ReplicaSet")
+		}
+	}
+}
+
+// isDigits returns true if the provided string is non-empty & only consists of
+// decimal digits.
+func isDigits(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // podSecurityPolicyMatches returns true if PolicyRule matches "policy" APIGroup,
 // "podsecuritypolicies" resource & "use" verb.
 func podSecurityPolicyMatches(r rbacv1.PolicyRule) bool {
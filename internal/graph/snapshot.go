@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// snapshotVersion is the version of the snapshot format written by
+// WriteSnapshot.
+const snapshotVersion = 1
+
+// snapshot is the serialized form of a relationship tree.
+type snapshot struct {
+	Version      int            `json:"version"`
+	Dependencies bool           `json:"dependencies"`
+	RootUIDs     []types.UID    `json:"rootUIDs"`
+	Nodes        []snapshotNode `json:"nodes"`
+}
+
+// snapshotNode is the serialized form of a node, it captures the content of the
+// object along with its relationships with other nodes.
+type snapshotNode struct {
+	Object              json.RawMessage         `json:"object,omitempty"`
+	UID                 types.UID               `json:"uid"`
+	Group               string                  `json:"group,omitempty"`
+	Version             string                  `json:"version,omitempty"`
+	Kind                string                  `json:"kind,omitempty"`
+	Resource            string                  `json:"resource,omitempty"`
+	Namespaced          bool                    `json:"namespaced,omitempty"`
+	Namespace           string                  `json:"namespace,omitempty"`
+	Name                string                  `json:"name"`
+	OwnerReferences     []metav1.OwnerReference `json:"ownerReferences,omitempty"`
+	Dependencies        map[types.UID][]string  `json:"dependencies,omitempty"`
+	Dependents          map[types.UID][]string  `json:"dependents,omitempty"`
+	Depth               uint                    `json:"depth"`
+	PassThrough         bool                    `json:"passThrough,omitempty"`
+	NotFound            bool                    `json:"notFound,omitempty"`
+	HiddenCompletedPods int                     `json:"hiddenCompletedPods,omitempty"`
+	Cluster             string                  `json:"cluster,omitempty"`
+}
+
+// WriteSnapshot writes the relationship tree of the provided root objects as
+// JSON, so that it can be read by ReadSnapshot & printed in any output format
+// without access to the cluster.
+func (m NodeMap) WriteSnapshot(w io.Writer, rootUIDs []types.UID, depsIsDependencies bool) error {
+	s := snapshot{
+		Version:      snapshotVersion,
+		Dependencies: depsIsDependencies,
+		RootUIDs:     rootUIDs,
+		Nodes:        make([]snapshotNode, 0, len(m)),
+	}
+	for _, node := range m {
+		var obj json.RawMessage
+		if node.Unstructured != nil {
+			var err error
+			obj, err = json.Marshal(node.UnstructuredContent())
+			if err != nil {
+				return fmt.Errorf("failed to encode object (uid: %s): %w", node.UID, err)
+			}
+		}
+		s.Nodes = append(s.Nodes, snapshotNode{
+			Object:              obj,
+			UID:                 node.UID,
+			Group:               node.Group,
+			Version:             node.Version,
+			Kind:                node.Kind,
+			Resource:            node.Resource,
+			Namespaced:          node.Namespaced,
+			Namespace:           node.Namespace,
+			Name:                node.Name,
+			OwnerReferences:     node.OwnerReferences,
+			Dependencies:        m.relationshipSetsToLists(node.Dependencies),
+			Dependents:          m.relationshipSetsToLists(node.Dependents),
+			Depth:               node.Depth,
+			PassThrough:         node.PassThrough,
+			NotFound:            node.NotFound,
+			HiddenCompletedPods: node.HiddenCompletedPods,
+			Cluster:             node.Cluster,
+		})
+	}
+	// Sort nodes by UID so that snapshots of the same relationship tree are
+	// identical
+	sort.Slice(s.Nodes, func(i, j int) bool {
+		return s.Nodes[i].UID < s.Nodes[j].UID
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// ReadSnapshot reads a relationship tree written by WriteSnapshot, returning
+// the relationship tree along with the UIDs of its root objects & whether it
+// contains the dependencies instead of the dependents of the root objects.
+func ReadSnapshot(r io.Reader) (NodeMap, []types.UID, bool, error) {
+	var s snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, nil, false, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if s.Version != snapshotVersion {
+		return nil, nil, false, fmt.Errorf("unsupported snapshot version %d, expected version %d", s.Version, snapshotVersion)
+	}
+
+	nodeMap := make(NodeMap, len(s.Nodes))
+	for _, n := range s.Nodes {
+		// Decode objects with the apimachinery JSON decoder so that integers are
+		// decoded as int64 instead of float64, like objects fetched from the
+		// cluster
+		var u *unstructuredv1.Unstructured
+		if n.Object != nil {
+			var obj map[string]interface{}
+			if err := utiljson.Unmarshal(n.Object, &obj); err != nil {
+				return nil, nil, false, fmt.Errorf("failed to decode object (uid: %s): %w", n.UID, err)
+			}
+			u = &unstructuredv1.Unstructured{Object: obj}
+		}
+		nodeMap[n.UID] = &Node{
+			Unstructured:        u,
+			UID:                 n.UID,
+			Group:               n.Group,
+			Version:             n.Version,
+			Kind:                n.Kind,
+			Resource:            n.Resource,
+			Namespaced:          n.Namespaced,
+			Namespace:           n.Namespace,
+			Name:                n.Name,
+			OwnerReferences:     n.OwnerReferences,
+			Dependencies:        listsToRelationshipSets(n.Dependencies),
+			Dependents:          listsToRelationshipSets(n.Dependents),
+			Depth:               n.Depth,
+			PassThrough:         n.PassThrough,
+			NotFound:            n.NotFound,
+			HiddenCompletedPods: n.HiddenCompletedPods,
+			Cluster:             n.Cluster,
+		}
+	}
+	for _, uid := range s.RootUIDs {
+		if _, ok := nodeMap[uid]; !ok {
+			return nil, nil, false, fmt.Errorf("root object (uid: %s) not found in snapshot", uid)
+		}
+	}
+
+	return nodeMap, s.RootUIDs, s.Dependencies, nil
+}
+
+// relationshipSetsToLists converts the provided relationships into lists of
+// relationships, dropping the relationships with objects outside of the
+// relationship tree since they aren't included in the snapshot.
+func (m NodeMap) relationshipSetsToLists(deps map[types.UID]RelationshipSet) map[types.UID][]string {
+	result := map[types.UID][]string{}
+	for uid, rset := range deps {
+		if _, ok := m[uid]; ok {
+			result[uid] = rset.List()
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// listsToRelationshipSets converts the provided lists of relationships back
+// into relationship sets.
+func listsToRelationshipSets(deps map[types.UID][]string) map[types.UID]RelationshipSet {
+	result := make(map[types.UID]RelationshipSet, len(deps))
+	for uid, rs := range deps {
+		rset := RelationshipSet{}
+		for _, r := range rs {
+			rset[Relationship(r)] = struct{}{}
+		}
+		result[uid] = rset
+	}
+	return result
+}
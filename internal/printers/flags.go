@@ -1,24 +1,62 @@
 package printers
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
 
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
 	"github.com/tohjustin/kube-lineage/internal/client"
+	"github.com/tohjustin/kube-lineage/internal/graph"
 )
 
+// FlagOutputFile is the name of the flag setting the file to write the output
+// to, see Flags.PrintOutput.
+const FlagOutputFile = "output-file"
+
 const (
 	flagOutputFormat          = "output"
 	flagOutputFormatShorthand = "o"
+	flagQuiet                 = "quiet"
+	flagQuietShorthand        = "q"
+	flagSortBy                = "sort-by"
+	flagStatusConditionType   = "status-condition-type"
+	flagStatusConfig          = "status-config"
+	flagSummary               = "summary"
+)
+
+// List of supported sort keys.
+const (
+	sortByAge    = "age"
+	sortByKind   = "kind"
+	sortByName   = "name"
+	sortByStatus = "status"
 )
 
 // Flags composes common printer flag structs used in the command.
 type Flags struct {
-	HumanReadableFlags *HumanPrintFlags
-	OutputFormat       *string
+	CSVFlags            *CSVPrintFlags
+	CustomColumnsFlags  *CustomColumnsPrintFlags
+	GraphFlags          *GraphPrintFlags
+	HumanReadableFlags  *HumanPrintFlags
+	JSONYamlFlags       *JSONYamlPrintFlags
+	NameFlags           *NamePrintFlags
+	OutputFile          *string
+	OutputFormat        *string
+	Quiet               *bool
+	SortBy              *string
+	StatusConditionType *string
+	StatusConfig        *string
+	Summary             *bool
+	TemplateFlags       *TemplatePrintFlags
 }
 
 // AddFlags receives a *pflag.FlagSet reference and binds flags related to
@@ -26,18 +64,47 @@ type Flags struct {
 func (f *Flags) AddFlags(flags *pflag.FlagSet) {
 	f.HumanReadableFlags.AddFlags(flags)
 
+	if f.OutputFile != nil {
+		flags.StringVar(f.OutputFile, FlagOutputFile, *f.OutputFile, "If non-empty, write the output to the provided file instead of stdout. The file is only written once the entire output is printed successfully.")
+	}
 	if f.OutputFormat != nil {
 		flags.StringVarP(f.OutputFormat, flagOutputFormat, flagOutputFormatShorthand, *f.OutputFormat, fmt.Sprintf("Output format. One of: %s.", strings.Join(f.AllowedFormats(), "|")))
 	}
+	if f.Quiet != nil {
+		flags.BoolVarP(f.Quiet, flagQuiet, flagQuietShorthand, *f.Quiet, "If present, only print the objects of the relationship tree to stdout, without headers, summaries or messages. Warnings are still printed to stderr.")
+	}
+	if f.SortBy != nil {
+		flags.StringVar(f.SortBy, flagSortBy, *f.SortBy, fmt.Sprintf("If non-empty, sort the dependencies or dependents of each object by one of: %s. Prefix with '-' to sort in descending order (eg. -%s lists the newest objects first).", strings.Join(f.AllowedSortKeys(), "|"), sortByAge))
+	}
+	if f.StatusConditionType != nil {
+		flags.StringVar(f.StatusConditionType, flagStatusConditionType, *f.StatusConditionType, "The type of the status condition used to determine the ready & status value of objects without a kind-specific rule. Objects without such condition fall back to using their phase as their status.")
+	}
+	if f.StatusConfig != nil {
+		flags.StringVar(f.StatusConfig, flagStatusConfig, *f.StatusConfig, "If non-empty, a YAML file configuring the status condition type or the JSON paths used to determine the ready & status value of objects of specific kinds. Kinds that aren't configured use the default rules.")
+	}
+	if f.Summary != nil {
+		flags.BoolVar(f.Summary, flagSummary, *f.Summary, "If present, print a summary of the number of objects in the relationship tree by kind & by status after the table.")
+	}
 }
 
 // AllowedFormats is the list of formats in which data can be displayed.
 func (f *Flags) AllowedFormats() []string {
 	formats := []string{}
 	formats = append(formats, f.HumanReadableFlags.AllowedFormats()...)
+	formats = append(formats, f.JSONYamlFlags.AllowedFormats()...)
+	formats = append(formats, f.NameFlags.AllowedFormats()...)
+	formats = append(formats, f.CSVFlags.AllowedFormats()...)
+	formats = append(formats, f.GraphFlags.AllowedFormats()...)
+	formats = append(formats, f.CustomColumnsFlags.AllowedFormats()...)
+	formats = append(formats, f.TemplateFlags.AllowedFormats()...)
 	return formats
 }
 
+// AllowedSortKeys is the list of keys by which objects can be sorted.
+func (f *Flags) AllowedSortKeys() []string {
+	return []string{sortByAge, sortByKind, sortByName, sortByStatus}
+}
+
 // Copy returns a copy of Flags for mutation.
 func (f *Flags) Copy() Flags {
 	printFlags := *f
@@ -50,6 +117,48 @@ func (f *Flags) EnsureWithGroup() {
 	f.HumanReadableFlags.EnsureWithGroup()
 }
 
+// IsCSVOutputFormat returns true if provided output format is a
+// delimiter-separated values output format.
+func (f *Flags) IsCSVOutputFormat(outputFormat string) bool {
+	return f.CSVFlags.IsSupportedOutputFormat(outputFormat)
+}
+
+// IsCustomColumnsOutputFormat returns true if provided output format is a
+// custom columns output format.
+func (f *Flags) IsCustomColumnsOutputFormat(outputFormat string) bool {
+	return f.CustomColumnsFlags.IsSupportedOutputFormat(outputFormat)
+}
+
+// IsGraphOutputFormat returns true if provided output format is a graph output
+// format.
+func (f *Flags) IsGraphOutputFormat(outputFormat string) bool {
+	return f.GraphFlags.IsSupportedOutputFormat(outputFormat)
+}
+
+// IsJSONYamlOutputFormat returns true if provided output format is a structured
+// output format.
+func (f *Flags) IsJSONYamlOutputFormat(outputFormat string) bool {
+	return f.JSONYamlFlags.IsSupportedOutputFormat(outputFormat)
+}
+
+// IsNameOutputFormat returns true if provided output format is a name output
+// format.
+func (f *Flags) IsNameOutputFormat(outputFormat string) bool {
+	return f.NameFlags.IsSupportedOutputFormat(outputFormat)
+}
+
+// IsTemplateOutputFormat returns true if provided output format is a template
+// output format.
+func (f *Flags) IsTemplateOutputFormat(outputFormat string) bool {
+	return f.TemplateFlags.IsSupportedOutputFormat(outputFormat)
+}
+
+// IsQuiet returns true if only the objects of the relationship tree should be
+// printed to stdout.
+func (f *Flags) IsQuiet() bool {
+	return f.Quiet != nil && *f.Quiet
+}
+
 // IsTableOutputFormat returns true if provided output format is a table format.
 func (f *Flags) IsTableOutputFormat(outputFormat string) bool {
 	return f.HumanReadableFlags.IsSupportedOutputFormat(outputFormat)
@@ -63,19 +172,158 @@ func (f *Flags) SetShowNamespace(b bool) {
 
 // ToPrinter returns a printer based on current flag values.
 func (f *Flags) ToPrinter(client client.Interface) (Interface, error) {
+	// Quiet output never includes headers, so print it the same way as if
+	// --no-headers is provided
+	if f.IsQuiet() {
+		if s := f.Summary; s != nil && *s {
+			return nil, fmt.Errorf("--%s must not be specified together with --%s", flagSummary, flagQuiet)
+		}
+		noHeaders := true
+		humanReadableFlags := *f.HumanReadableFlags
+		humanReadableFlags.NoHeaders = &noHeaders
+		printFlags := f.Copy()
+		printFlags.HumanReadableFlags = &humanReadableFlags
+		f = &printFlags
+	}
+
 	outputFormat := ""
 	if f.OutputFormat != nil {
 		outputFormat = *f.OutputFormat
 	}
 
+	sortBy := ""
+	if f.SortBy != nil {
+		sortBy = *f.SortBy
+	}
+	if sortKey := strings.TrimPrefix(sortBy, "-"); len(sortBy) > 0 && !sets.NewString(f.AllowedSortKeys()...).Has(sortKey) {
+		return nil, fmt.Errorf("invalid --%s value \"%s\", must be one of: %s", flagSortBy, sortBy, strings.Join(f.AllowedSortKeys(), "|"))
+	}
+
+	if c := f.HumanReadableFlags.Color; c != nil && !sets.NewString(f.HumanReadableFlags.AllowedColorModes()...).Has(*c) {
+		return nil, fmt.Errorf("invalid --%s value \"%s\", must be one of: %s", flagColor, *c, strings.Join(f.HumanReadableFlags.AllowedColorModes(), "|"))
+	}
+
+	if cc := f.HumanReadableFlags.ConditionColumns; cc != nil {
+		for _, ct := range *cc {
+			if _, _, err := newConditionJSONPaths(ct); err != nil {
+				return nil, fmt.Errorf("invalid --%s value: %w", flagConditionColumns, err)
+			}
+		}
+	}
+
+	ageFormat := ageFormatHuman
+	if af := f.HumanReadableFlags.AgeFormat; af != nil {
+		ageFormat = *af
+	}
+	if !sets.NewString(f.HumanReadableFlags.AllowedAgeFormats()...).Has(ageFormat) {
+		return nil, fmt.Errorf("invalid --%s value \"%s\", must be one of: %s", flagAgeFormat, ageFormat, strings.Join(f.HumanReadableFlags.AllowedAgeFormats(), "|"))
+	}
+
+	readyStatusFn, err := f.toReadyStatusFn()
+	if err != nil {
+		return nil, err
+	}
+
 	var printer Interface
 	switch {
 	case f.IsTableOutputFormat(outputFormat), outputFormat == "":
 		configFlags := f.Copy()
+		conditionType := conditionTypeReady
+		if ct := f.StatusConditionType; ct != nil {
+			conditionType = *ct
+		}
 		printer = &tablePrinter{
-			configFlags:  configFlags.HumanReadableFlags,
-			outputFormat: outputFormat,
-			client:       client,
+			configFlags:   configFlags.HumanReadableFlags,
+			conditionType: conditionType,
+			outputFormat:  outputFormat,
+			readyStatusFn: readyStatusFn,
+			sortBy:        sortBy,
+			client:        client,
+		}
+	case f.IsJSONYamlOutputFormat(outputFormat):
+		printer = &jsonYamlPrinter{
+			outputFormat:  outputFormat,
+			readyStatusFn: readyStatusFn,
+			sortBy:        sortBy,
+		}
+	case f.IsNameOutputFormat(outputFormat):
+		printer = &namePrinter{
+			outputFormat:  outputFormat,
+			readyStatusFn: readyStatusFn,
+			sortBy:        sortBy,
+		}
+	case f.IsCSVOutputFormat(outputFormat):
+		noHeaders, showAPIVersion, showGroup := false, false, false
+		if nh := f.HumanReadableFlags.NoHeaders; nh != nil {
+			noHeaders = *nh
+		}
+		if sv := f.HumanReadableFlags.ShowAPIVersion; sv != nil {
+			showAPIVersion = *sv
+		}
+		if sg := f.HumanReadableFlags.ShowGroup; sg != nil {
+			showGroup = *sg
+		}
+		columnLabels := []string{}
+		if cl := f.HumanReadableFlags.ColumnLabels; cl != nil {
+			columnLabels = *cl
+		}
+		printer = &csvPrinter{
+			ageFormat:      ageFormat,
+			columnLabels:   columnLabels,
+			noHeaders:      noHeaders,
+			outputFormat:   outputFormat,
+			readyStatusFn:  readyStatusFn,
+			showAPIVersion: showAPIVersion,
+			showGroup:      showGroup,
+			sortBy:         sortBy,
+		}
+	case f.IsGraphOutputFormat(outputFormat):
+		showAPIVersion, showGroup := false, false
+		if sv := f.HumanReadableFlags.ShowAPIVersion; sv != nil {
+			showAPIVersion = *sv
+		}
+		if sg := f.HumanReadableFlags.ShowGroup; sg != nil {
+			showGroup = *sg
+		}
+		printer = &graphPrinter{
+			outputFormat:   outputFormat,
+			readyStatusFn:  readyStatusFn,
+			showAPIVersion: showAPIVersion,
+			showGroup:      showGroup,
+			sortBy:         sortBy,
+		}
+	case f.IsCustomColumnsOutputFormat(outputFormat):
+		columns, err := f.CustomColumnsFlags.ToColumns(outputFormat)
+		if err != nil {
+			return nil, err
+		}
+		ascii, noHeaders, showAPIVersion, showGroup := false, false, false, false
+		if a := f.HumanReadableFlags.ASCII; a != nil {
+			ascii = *a
+		}
+		if nh := f.HumanReadableFlags.NoHeaders; nh != nil {
+			noHeaders = *nh
+		}
+		if sv := f.HumanReadableFlags.ShowAPIVersion; sv != nil {
+			showAPIVersion = *sv
+		}
+		if sg := f.HumanReadableFlags.ShowGroup; sg != nil {
+			showGroup = *sg
+		}
+		printer, err = newCustomColumnsPrinter(columns, ascii, noHeaders, showAPIVersion, showGroup, ageFormat, sortBy, readyStatusFn)
+		if err != nil {
+			return nil, err
+		}
+	case f.IsTemplateOutputFormat(outputFormat):
+		t, err := f.TemplateFlags.ToTemplate(outputFormat)
+		if err != nil {
+			return nil, err
+		}
+		printer = &templatePrinter{
+			ageFormat:     ageFormat,
+			readyStatusFn: readyStatusFn,
+			sortBy:        sortBy,
+			template:      t,
 		}
 	default:
 		return nil, genericclioptions.NoCompatiblePrinterError{
@@ -84,16 +332,132 @@ func (f *Flags) ToPrinter(client client.Interface) (Interface, error) {
 		}
 	}
 
+	if s := f.Summary; s != nil && *s {
+		if len(outputFormat) > 0 && !f.IsTableOutputFormat(outputFormat) && !f.IsCustomColumnsOutputFormat(outputFormat) {
+			return nil, fmt.Errorf("--%s is only supported with table & custom-columns output formats", flagSummary)
+		}
+		printer = &summaryPrinter{printer: printer, readyStatusFn: readyStatusFn}
+	}
+
 	return printer, nil
 }
 
+// NotReadyObjects returns the objects in the relationship tree within the
+// provided maximum depth that aren't ready, based on the same ready & status
+// values that are printed. Only objects of the provided GroupKinds are
+// considered, unless no GroupKinds are provided.
+func (f *Flags) NotReadyObjects(nodeMap graph.NodeMap, maxDepth uint, gkSet map[schema.GroupKind]struct{}) ([]*graph.Node, error) {
+	readyStatusFn, err := f.toReadyStatusFn()
+	if err != nil {
+		return nil, err
+	}
+
+	result := graph.NodeList{}
+	for _, node := range nodeMap {
+		if node.Depth > maxDepth {
+			continue
+		}
+		if len(gkSet) > 0 {
+			if _, ok := gkSet[schema.GroupKind{Group: node.Group, Kind: node.Kind}]; !ok {
+				continue
+			}
+		}
+		if ready, status := readyStatusFn(node); getReadiness(ready, status) == readinessNotReady {
+			result = append(result, node)
+		}
+	}
+	sort.Sort(result)
+
+	return result, nil
+}
+
+// FilterByNotReady keeps only the objects in the relationship tree within the
+// provided maximum depth that aren't ready (see NotReadyObjects) & the objects
+// leading to them from the provided root objects.
+func (f *Flags) FilterByNotReady(nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool) error {
+	notReady, err := f.NotReadyObjects(nodeMap, maxDepth, nil)
+	if err != nil {
+		return err
+	}
+	uids := make([]types.UID, 0, len(notReady))
+	for _, node := range notReady {
+		uids = append(uids, node.UID)
+	}
+	nodeMap.FilterByUIDs(rootUIDs, uids, depsIsDependencies)
+	return nil
+}
+
+// PrintOutput prints the provided relationship tree with the provided printer
+// to the output file if any, or to the provided writer otherwise. The output is
+// printed in full before writing the file, so that the file isn't left
+// partially written if printing fails.
+func (f *Flags) PrintOutput(w io.Writer, printer Interface, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool) error {
+	var path string
+	if f.OutputFile != nil {
+		path = *f.OutputFile
+	}
+	if len(path) == 0 {
+		return printer.Print(w, nodeMap, rootUIDs, maxDepth, depsIsDependencies)
+	}
+	var buf bytes.Buffer
+	if err := printer.Print(&buf, nodeMap, rootUIDs, maxDepth, depsIsDependencies); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	if !f.IsQuiet() {
+		fmt.Fprintf(w, "Wrote relationship tree of %d object(s) to %s\n", nodeMap.CountWithinDepth(maxDepth), path)
+	}
+	return nil
+}
+
+// toReadyStatusFn returns a function that computes the ready & status value of
+// objects based on the provided status condition type & status config.
+func (f *Flags) toReadyStatusFn() (func(node *graph.Node) (string, string), error) {
+	conditionType := conditionTypeReady
+	if ct := f.StatusConditionType; ct != nil {
+		conditionType = *ct
+	}
+	readyStatusFn, err := createReadyStatusFn(conditionType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s value \"%s\"", flagStatusConditionType, conditionType)
+	}
+	if sc := f.StatusConfig; sc != nil && len(*sc) > 0 {
+		rules, err := loadStatusConfig(*sc, conditionType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s value: %w", flagStatusConfig, err)
+		}
+		readyStatusFn = withStatusRules(rules, readyStatusFn)
+	}
+	return readyStatusFn, nil
+}
+
 // NewFlags returns flags associated with human-readable printing, with default
 // values set.
 func NewFlags() *Flags {
+	outputFile := ""
 	outputFormat := ""
+	quiet := false
+	sortBy := ""
+	statusConditionType := conditionTypeReady
+	statusConfig := ""
+	summary := false
 
 	return &Flags{
-		OutputFormat:       &outputFormat,
-		HumanReadableFlags: NewHumanPrintFlags(),
+		CSVFlags:            NewCSVPrintFlags(),
+		CustomColumnsFlags:  NewCustomColumnsPrintFlags(),
+		GraphFlags:          NewGraphPrintFlags(),
+		OutputFile:          &outputFile,
+		OutputFormat:        &outputFormat,
+		HumanReadableFlags:  NewHumanPrintFlags(),
+		JSONYamlFlags:       NewJSONYamlPrintFlags(),
+		NameFlags:           NewNamePrintFlags(),
+		Quiet:               &quiet,
+		SortBy:              &sortBy,
+		StatusConditionType: &statusConditionType,
+		StatusConfig:        &statusConfig,
+		Summary:             &summary,
+		TemplateFlags:       NewTemplatePrintFlags(),
 	}
 }
@@ -0,0 +1,35 @@
+package printers
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// List of supported delimiter-separated values output formats.
+const (
+	outputFormatCSV = "csv"
+	outputFormatTSV = "tsv"
+)
+
+// CSVPrintFlags provides default flags necessary for printing the relationship
+// tree as delimiter-separated values.
+type CSVPrintFlags struct{}
+
+// AllowedFormats returns the list of delimiter-separated values output
+// formats.
+func (f *CSVPrintFlags) AllowedFormats() []string {
+	return []string{
+		outputFormatCSV,
+		outputFormatTSV,
+	}
+}
+
+// IsSupportedOutputFormat returns true if provided output format is supported.
+func (f *CSVPrintFlags) IsSupportedOutputFormat(outputFormat string) bool {
+	return sets.NewString(f.AllowedFormats()...).Has(outputFormat)
+}
+
+// NewCSVPrintFlags returns flags associated with delimiter-separated values
+// printing, with default values set.
+func NewCSVPrintFlags() *CSVPrintFlags {
+	return &CSVPrintFlags{}
+}
@@ -0,0 +1,75 @@
+package printers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/kubectl/pkg/cmd/get"
+)
+
+// List of supported custom columns output formats.
+const (
+	outputFormatCustomColumns     = "custom-columns"
+	outputFormatCustomColumnsFile = "custom-columns-file"
+)
+
+// CustomColumnsPrintFlags provides default flags necessary for printing the
+// relationship tree with user-specified columns.
+type CustomColumnsPrintFlags struct{}
+
+// AllowedFormats returns the list of custom columns output formats.
+func (f *CustomColumnsPrintFlags) AllowedFormats() []string {
+	return []string{
+		outputFormatCustomColumns + "=",
+		outputFormatCustomColumnsFile + "=",
+	}
+}
+
+// IsSupportedOutputFormat returns true if provided output format is supported.
+func (f *CustomColumnsPrintFlags) IsSupportedOutputFormat(outputFormat string) bool {
+	format := strings.SplitN(outputFormat, "=", 2)[0]
+	return sets.NewString(outputFormatCustomColumns, outputFormatCustomColumnsFile).Has(format)
+}
+
+// ToColumns parses the custom columns specification from the provided output
+// format & returns the list of columns to print.
+func (f *CustomColumnsPrintFlags) ToColumns(outputFormat string) ([]get.Column, error) {
+	parts := strings.SplitN(outputFormat, "=", 2)
+	spec := ""
+	if len(parts) == 2 {
+		spec = parts[1]
+	}
+
+	switch parts[0] {
+	case outputFormatCustomColumns:
+		p, err := get.NewCustomColumnsPrinterFromSpec(spec, nil, false)
+		if err != nil {
+			return nil, err
+		}
+		return p.Columns, nil
+	case outputFormatCustomColumnsFile:
+		if len(spec) == 0 {
+			return nil, fmt.Errorf("%s format specified but no custom columns file given", outputFormatCustomColumnsFile)
+		}
+		file, err := os.Open(spec)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template %s, %w", spec, err)
+		}
+		defer file.Close()
+		p, err := get.NewCustomColumnsPrinterFromTemplate(file, nil)
+		if err != nil {
+			return nil, err
+		}
+		return p.Columns, nil
+	default:
+		return nil, fmt.Errorf("output format \"%s\" not supported", outputFormat)
+	}
+}
+
+// NewCustomColumnsPrintFlags returns flags associated with custom columns
+// printing, with default values set.
+func NewCustomColumnsPrintFlags() *CustomColumnsPrintFlags {
+	return &CustomColumnsPrintFlags{}
+}
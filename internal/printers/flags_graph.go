@@ -0,0 +1,34 @@
+package printers
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// List of supported graph output formats.
+const (
+	outputFormatDOT     = "dot"
+	outputFormatMermaid = "mermaid"
+)
+
+// GraphPrintFlags provides default flags necessary for printing the
+// relationship tree as a graph description.
+type GraphPrintFlags struct{}
+
+// AllowedFormats returns the list of graph output formats.
+func (f *GraphPrintFlags) AllowedFormats() []string {
+	return []string{
+		outputFormatDOT,
+		outputFormatMermaid,
+	}
+}
+
+// IsSupportedOutputFormat returns true if provided output format is supported.
+func (f *GraphPrintFlags) IsSupportedOutputFormat(outputFormat string) bool {
+	return sets.NewString(f.AllowedFormats()...).Has(outputFormat)
+}
+
+// NewGraphPrintFlags returns flags associated with graph printing, with
+// default values set.
+func NewGraphPrintFlags() *GraphPrintFlags {
+	return &GraphPrintFlags{}
+}
@@ -1,38 +1,84 @@
 package printers
 
 import (
+	"fmt"
+	"io"
+	"strings"
+
 	"github.com/spf13/pflag"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/kubectl/pkg/util/term"
 )
 
 const (
+	flagAgeFormat             = "age-format"
+	flagASCII                 = "ascii"
+	flagCollapse              = "collapse"
+	flagColor                 = "color"
 	flagColumnLabels          = "label-columns"
 	flagColumnLabelsShorthand = "L"
+	flagConditionColumns      = "condition-columns"
+	flagGroupByKind           = "group-by-kind"
+	flagMaxNameWidth          = "max-name-width"
 	flagNoHeaders             = "no-headers"
+	flagReverse               = "reverse"
+	flagShowAPIVersion        = "show-apiversion"
+	flagShowConditionAge      = "show-condition-age"
 	flagShowGroup             = "show-group"
 	flagShowLabels            = "show-labels"
+	flagShowNode              = "show-node"
+	flagShowOwner             = "show-owner"
 	flagShowNamespace         = "show-namespace"
+	flagWidth                 = "width"
 )
 
 // List of supported table output formats.
 const (
+	outputFormatFlat      = "flat"
 	outputFormatWide      = "wide"
 	outputFormatSplit     = "split"
 	outputFormatSplitWide = "split-wide"
 )
 
+// List of supported age formats.
+const (
+	ageFormatHuman   = "human"
+	ageFormatISO8601 = "iso8601"
+	ageFormatSeconds = "seconds"
+)
+
+// List of supported color modes.
+const (
+	colorAlways = "always"
+	colorAuto   = "auto"
+	colorNever  = "never"
+)
+
 // HumanPrintFlags provides default flags necessary for printing. Given the
 // following flag values, a printer can be requested that knows how to handle
 // printing based on these values.
 type HumanPrintFlags struct {
-	ColumnLabels  *[]string
-	NoHeaders     *bool
-	ShowGroup     *bool
-	ShowLabels    *bool
-	ShowNamespace *bool
+	AgeFormat        *string
+	ASCII            *bool
+	Collapse         *bool
+	Color            *string
+	ColumnLabels     *[]string
+	ConditionColumns *[]string
+	GroupByKind      *bool
+	MaxNameWidth     *uint
+	NoHeaders        *bool
+	Reverse          *bool
+	ShowAPIVersion   *bool
+	ShowConditionAge *bool
+	ShowGroup        *bool
+	ShowLabels       *bool
+	ShowNamespace    *bool
+	ShowNode         *bool
+	ShowOwner        *bool
+	Width            *uint
 }
 
 // EnsureWithGroup sets the "ShowGroup" human-readable option to true.
@@ -50,16 +96,64 @@ func (f *HumanPrintFlags) SetShowNamespace(b bool) {
 func (f *HumanPrintFlags) AllowedFormats() []string {
 	return []string{
 		outputFormatWide,
+		outputFormatFlat,
 		outputFormatSplit,
 		outputFormatSplitWide,
 	}
 }
 
+// AllowedAgeFormats returns the list of supported age formats.
+func (f *HumanPrintFlags) AllowedAgeFormats() []string {
+	return []string{ageFormatHuman, ageFormatISO8601, ageFormatSeconds}
+}
+
+// AllowedColorModes returns the list of supported color modes.
+func (f *HumanPrintFlags) AllowedColorModes() []string {
+	return []string{colorAlways, colorAuto, colorNever}
+}
+
+// IsColorEnabled returns true if output written to the provided writer should
+// be colorized.
+func (f *HumanPrintFlags) IsColorEnabled(w io.Writer) bool {
+	color := colorAuto
+	if f.Color != nil {
+		color = *f.Color
+	}
+	switch color {
+	case colorAlways:
+		return true
+	case colorAuto:
+		return term.IsTerminal(w)
+	default:
+		return false
+	}
+}
+
+// GetWidth returns the width that tables written to the provided writer should
+// fit within, which defaults to the width of the terminal. Tables written to
+// anything else than a terminal aren't truncated unless a width is provided, as
+// signaled by returning 0.
+func (f *HumanPrintFlags) GetWidth(w io.Writer) uint {
+	if f.Width != nil && *f.Width > 0 {
+		return *f.Width
+	}
+	if size := (term.TTY{Out: w}).GetSize(); size != nil {
+		return uint(size.Width)
+	}
+	return 0
+}
+
 // IsSupportedOutputFormat returns true if provided output format is supported.
 func (f *HumanPrintFlags) IsSupportedOutputFormat(outputFormat string) bool {
 	return sets.NewString(f.AllowedFormats()...).Has(outputFormat)
 }
 
+// IsFlatOutputFormat returns true if provided output format is a flat table
+// format.
+func (f *HumanPrintFlags) IsFlatOutputFormat(outputFormat string) bool {
+	return outputFormat == outputFormatFlat
+}
+
 // IsSplitOutputFormat returns true if provided output format is a split table
 // format.
 func (f *HumanPrintFlags) IsSplitOutputFormat(outputFormat string) bool {
@@ -118,12 +212,42 @@ func (f *HumanPrintFlags) ToPrinter(outputFormat string) (printers.ResourcePrint
 // AddFlags receives a *pflag.FlagSet reference and binds flags related to
 // human-readable printing to it.
 func (f *HumanPrintFlags) AddFlags(flags *pflag.FlagSet) {
+	if f.AgeFormat != nil {
+		flags.StringVar(f.AgeFormat, flagAgeFormat, *f.AgeFormat, fmt.Sprintf("Format of the age of objects, either the elapsed time in a human-readable form, the creation timestamp or the number of elapsed seconds. One of: %s.", strings.Join(f.AllowedAgeFormats(), "|")))
+	}
+	if f.ASCII != nil {
+		flags.BoolVar(f.ASCII, flagASCII, *f.ASCII, "If present, draw the relationship tree using only ASCII characters (e.g. for terminals without UTF-8 support)")
+	}
+	if f.Collapse != nil {
+		flags.BoolVar(f.Collapse, flagCollapse, *f.Collapse, "When using the default or wide output format, collapse the Pods of each object sharing the same ready & status value into a single row, along with the number of Pods it summarizes. Pods with a different ready or status value are still listed separately")
+	}
+	if f.Color != nil {
+		flags.StringVar(f.Color, flagColor, *f.Color, fmt.Sprintf("When using the default or wide output format, colorize the ready state of objects. One of: %s.", strings.Join(f.AllowedColorModes(), "|")))
+	}
 	if f.ColumnLabels != nil {
 		flags.StringSliceVarP(f.ColumnLabels, flagColumnLabels, flagColumnLabelsShorthand, *f.ColumnLabels, "Accepts a comma separated list of labels that are going to be presented as columns. Names are case-sensitive. You can also use multiple flag options like -L label1 -L label2...")
 	}
+	if f.ConditionColumns != nil {
+		flags.StringSliceVar(f.ConditionColumns, flagConditionColumns, *f.ConditionColumns, "When using the default or wide output format, accepts a comma separated list of status condition types that are going to be presented as columns showing the status of each condition (e.g. Available,Progressing)")
+	}
+	if f.GroupByKind != nil {
+		flags.BoolVar(f.GroupByKind, flagGroupByKind, *f.GroupByKind, "When using the default or wide output format, group the dependencies or dependents of each object under a header by kind, along with the number of objects of each kind")
+	}
+	if f.MaxNameWidth != nil {
+		flags.UintVar(f.MaxNameWidth, flagMaxNameWidth, *f.MaxNameWidth, "When using the default or wide output format, truncate the names of objects longer than the provided width with an ellipsis, 0 means no truncation")
+	}
 	if f.NoHeaders != nil {
 		flags.BoolVar(f.NoHeaders, flagNoHeaders, *f.NoHeaders, "When using the default output format, don't print headers (default print headers)")
 	}
+	if f.Reverse != nil {
+		flags.BoolVar(f.Reverse, flagReverse, *f.Reverse, "When using the default or wide output format, print the relationship tree bottom-up, listing the root objects last")
+	}
+	if f.ShowAPIVersion != nil {
+		flags.BoolVar(f.ShowAPIVersion, flagShowAPIVersion, *f.ShowAPIVersion, "If present, include the full API version of each object in its name (e.g. apps/v1/Deployment/name)")
+	}
+	if f.ShowConditionAge != nil {
+		flags.BoolVar(f.ShowConditionAge, flagShowConditionAge, *f.ShowConditionAge, "When using the default or wide output format, show the time since the status condition of the --status-condition-type type last transitioned for each object as a column after the age (e.g. to tell how long an object hasn't been ready)")
+	}
 	if f.ShowGroup != nil {
 		flags.BoolVar(f.ShowGroup, flagShowGroup, *f.ShowGroup, "If present, include the resource group for the requested object(s)")
 	}
@@ -133,22 +257,57 @@ func (f *HumanPrintFlags) AddFlags(flags *pflag.FlagSet) {
 	if f.ShowNamespace != nil {
 		flags.BoolVar(f.ShowNamespace, flagShowNamespace, *f.ShowNamespace, "When printing, show namespace as the first column (default hide namespace column if all objects are in the same namespace)")
 	}
+	if f.ShowNode != nil {
+		flags.BoolVar(f.ShowNode, flagShowNode, *f.ShowNode, "When using the default output format, show the node each Pod is scheduled on as a column, along with the number of nodes missing the Pods of each DaemonSet (shown in the wide output format by default)")
+	}
+	if f.ShowOwner != nil {
+		flags.BoolVar(f.ShowOwner, flagShowOwner, *f.ShowOwner, "When using the default or wide output format, show the controller of each object declared in its owner references as a column (e.g. to tell declared relationships apart from inferred ones)")
+	}
+	if f.Width != nil {
+		flags.UintVar(f.Width, flagWidth, *f.Width, "When using the default, wide or flat output format, truncate the status & then the names of objects with an ellipsis so that the table fits within the provided width. Defaults to the width of the terminal, output that isn't written to a terminal isn't truncated if set to 0")
+	}
 }
 
 // NewHumanPrintFlags returns flags associated with human-readable printing,
 // with default values set.
 func NewHumanPrintFlags() *HumanPrintFlags {
+	ageFormat := ageFormatHuman
+	ascii := false
+	collapse := false
+	color := colorAuto
 	columnLabels := []string{}
+	conditionColumns := []string{}
+	groupByKind := false
+	maxNameWidth := uint(0)
 	noHeaders := false
+	reverse := false
+	showAPIVersion := false
+	showConditionAge := false
 	showGroup := false
 	showLabels := false
 	showNamespace := false
+	showNode := false
+	showOwner := false
+	width := uint(0)
 
 	return &HumanPrintFlags{
-		ColumnLabels:  &columnLabels,
-		NoHeaders:     &noHeaders,
-		ShowGroup:     &showGroup,
-		ShowLabels:    &showLabels,
-		ShowNamespace: &showNamespace,
+		AgeFormat:        &ageFormat,
+		ASCII:            &ascii,
+		Collapse:         &collapse,
+		Color:            &color,
+		ColumnLabels:     &columnLabels,
+		ConditionColumns: &conditionColumns,
+		GroupByKind:      &groupByKind,
+		MaxNameWidth:     &maxNameWidth,
+		NoHeaders:        &noHeaders,
+		Reverse:          &reverse,
+		ShowAPIVersion:   &showAPIVersion,
+		ShowConditionAge: &showConditionAge,
+		ShowGroup:        &showGroup,
+		ShowLabels:       &showLabels,
+		ShowNamespace:    &showNamespace,
+		ShowNode:         &showNode,
+		ShowOwner:        &showOwner,
+		Width:            &width,
 	}
 }
@@ -0,0 +1,36 @@
+package printers
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// List of supported structured output formats.
+const (
+	outputFormatJSON  = "json"
+	outputFormatJSONL = "jsonl"
+	outputFormatYAML  = "yaml"
+)
+
+// JSONYamlPrintFlags provides default flags necessary for printing the
+// relationship tree as structured data.
+type JSONYamlPrintFlags struct{}
+
+// AllowedFormats returns the list of structured output formats.
+func (f *JSONYamlPrintFlags) AllowedFormats() []string {
+	return []string{
+		outputFormatJSON,
+		outputFormatJSONL,
+		outputFormatYAML,
+	}
+}
+
+// IsSupportedOutputFormat returns true if provided output format is supported.
+func (f *JSONYamlPrintFlags) IsSupportedOutputFormat(outputFormat string) bool {
+	return sets.NewString(f.AllowedFormats()...).Has(outputFormat)
+}
+
+// NewJSONYamlPrintFlags returns flags associated with structured printing,
+// with default values set.
+func NewJSONYamlPrintFlags() *JSONYamlPrintFlags {
+	return &JSONYamlPrintFlags{}
+}
@@ -0,0 +1,36 @@
+package printers
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// List of supported name output formats.
+const (
+	outputFormatName           = "name"
+	outputFormatNamespacedName = "namespaced-name"
+	outputFormatYAMLList       = "yaml-list"
+)
+
+// NamePrintFlags provides default flags necessary for printing the identifiers
+// of the objects in the relationship tree.
+type NamePrintFlags struct{}
+
+// AllowedFormats returns the list of name output formats.
+func (f *NamePrintFlags) AllowedFormats() []string {
+	return []string{
+		outputFormatName,
+		outputFormatNamespacedName,
+		outputFormatYAMLList,
+	}
+}
+
+// IsSupportedOutputFormat returns true if provided output format is supported.
+func (f *NamePrintFlags) IsSupportedOutputFormat(outputFormat string) bool {
+	return sets.NewString(f.AllowedFormats()...).Has(outputFormat)
+}
+
+// NewNamePrintFlags returns flags associated with name printing, with default
+// values set.
+func NewNamePrintFlags() *NamePrintFlags {
+	return &NamePrintFlags{}
+}
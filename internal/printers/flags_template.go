@@ -0,0 +1,73 @@
+package printers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// List of supported template output formats.
+const (
+	outputFormatGoTemplate     = "go-template"
+	outputFormatGoTemplateFile = "go-template-file"
+)
+
+// TemplatePrintFlags provides default flags necessary for printing the
+// relationship tree with a user-specified Go template.
+type TemplatePrintFlags struct{}
+
+// AllowedFormats returns the list of template output formats.
+func (f *TemplatePrintFlags) AllowedFormats() []string {
+	return []string{
+		outputFormatGoTemplate + "=",
+		outputFormatGoTemplateFile + "=",
+	}
+}
+
+// IsSupportedOutputFormat returns true if provided output format is supported.
+func (f *TemplatePrintFlags) IsSupportedOutputFormat(outputFormat string) bool {
+	format := strings.SplitN(outputFormat, "=", 2)[0]
+	return sets.NewString(outputFormatGoTemplate, outputFormatGoTemplateFile).Has(format)
+}
+
+// ToTemplate parses the Go template from the provided output format.
+func (f *TemplatePrintFlags) ToTemplate(outputFormat string) (*template.Template, error) {
+	parts := strings.SplitN(outputFormat, "=", 2)
+	text := ""
+	if len(parts) == 2 {
+		text = parts[1]
+	}
+
+	switch parts[0] {
+	case outputFormatGoTemplate:
+		if len(text) == 0 {
+			return nil, fmt.Errorf("%s format specified but no template given", outputFormatGoTemplate)
+		}
+	case outputFormatGoTemplateFile:
+		if len(text) == 0 {
+			return nil, fmt.Errorf("%s format specified but no template file given", outputFormatGoTemplateFile)
+		}
+		data, err := os.ReadFile(text)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template %s, %w", text, err)
+		}
+		text = string(data)
+	default:
+		return nil, fmt.Errorf("output format \"%s\" not supported", outputFormat)
+	}
+
+	t, err := template.New("output").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s, %w", text, err)
+	}
+	return t, nil
+}
+
+// NewTemplatePrintFlags returns flags associated with template printing, with
+// default values set.
+func NewTemplatePrintFlags() *TemplatePrintFlags {
+	return &TemplatePrintFlags{}
+}
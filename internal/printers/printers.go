@@ -1,16 +1,21 @@
 package printers
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/printers"
 
 	"github.com/tohjustin/kube-lineage/internal/client"
 	"github.com/tohjustin/kube-lineage/internal/graph"
@@ -26,58 +31,423 @@ func lessGroupKind(lhs, rhs schema.GroupKind) bool {
 	return lhs.String() < rhs.String()
 }
 
+// createSortDepsFn creates a function that takes in either the dependencies or
+// dependents of a node & returns their UIDs sorted based on the provided sort
+// key. Nodes with equal sort keys, or all nodes if no sort key is provided, are
+// sorted based on the underlying object in following order: Namespace, Kind,
+// Group, Name, Version, UID.
+func createSortDepsFn(
+	nodeMap graph.NodeMap,
+	sortBy string,
+	readyStatusFn func(node *graph.Node) (string, string)) func(d map[types.UID]graph.RelationshipSet) []types.UID {
+	reverse := strings.HasPrefix(sortBy, "-")
+	lessFn := getSortByLessFn(strings.TrimPrefix(sortBy, "-"), readyStatusFn)
+	return func(d map[types.UID]graph.RelationshipSet) []types.UID {
+		nodes, ix := make(graph.NodeList, len(d)), 0
+		for uid := range d {
+			nodes[ix] = nodeMap[uid]
+			ix++
+		}
+		sort.Sort(nodes)
+		if lessFn != nil {
+			sort.SliceStable(nodes, func(i, j int) bool {
+				if reverse {
+					return lessFn(nodes[j], nodes[i])
+				}
+				return lessFn(nodes[i], nodes[j])
+			})
+		}
+		sortedUIDs := make([]types.UID, len(d))
+		for ix, node := range nodes {
+			sortedUIDs[ix] = node.UID
+		}
+		return sortedUIDs
+	}
+}
+
+// getSortByLessFn returns a function that reports whether the lhs node should
+// be sorted before the rhs node based on the provided sort key. Returns nil if
+// the sort key is empty or not supported.
+func getSortByLessFn(sortBy string, readyStatusFn func(node *graph.Node) (string, string)) func(lhs, rhs *graph.Node) bool {
+	switch sortBy {
+	case sortByAge:
+		return func(lhs, rhs *graph.Node) bool {
+			return getCreationTimestamp(lhs).Before(getCreationTimestamp(rhs))
+		}
+	case sortByKind:
+		return func(lhs, rhs *graph.Node) bool {
+			if lhs.Kind != rhs.Kind {
+				return lhs.Kind < rhs.Kind
+			}
+			return lhs.Group < rhs.Group
+		}
+	case sortByName:
+		return func(lhs, rhs *graph.Node) bool {
+			return lhs.Name < rhs.Name
+		}
+	case sortByStatus:
+		return func(lhs, rhs *graph.Node) bool {
+			lhsReady, lhsStatus := readyStatusFn(lhs)
+			rhsReady, rhsStatus := readyStatusFn(rhs)
+			return getReadiness(lhsReady, lhsStatus) == readinessNotReady && getReadiness(rhsReady, rhsStatus) != readinessNotReady
+		}
+	default:
+		return nil
+	}
+}
+
+// getCreationTimestamp returns the creation timestamp of the provided node.
+func getCreationTimestamp(node *graph.Node) time.Time {
+	if node.Unstructured == nil {
+		return time.Time{}
+	}
+	return node.GetCreationTimestamp().Time
+}
+
+// readiness represents the readiness state of a Kubernetes object.
+type readiness int
+
+const (
+	readinessUnknown readiness = iota
+	readinessReady
+	readinessNotReady
+)
+
+// getReadiness determines the readiness state from the provided "Ready" cell
+// value, which is either a condition status (eg. "True") or a ready count
+// (eg. "1/3"). Objects that ran to completion (eg. Pods of finished Jobs) &
+// objects that don't exist are considered ready & not ready respectively based
+// on their "Status" cell value.
+func getReadiness(ready, status string) readiness {
+	switch status {
+	case "Completed", "Complete", "Succeeded":
+		return readinessReady
+	case statusNotFound:
+		return readinessNotReady
+	}
+	switch ready {
+	case "True":
+		return readinessReady
+	case "False":
+		return readinessNotReady
+	}
+	var current, desired int
+	if n, err := fmt.Sscanf(ready, "%d/%d", &current, &desired); err == nil && n == 2 {
+		if current < desired {
+			return readinessNotReady
+		}
+		return readinessReady
+	}
+	return readinessUnknown
+}
+
 type Interface interface {
-	Print(w io.Writer, nodeMap graph.NodeMap, rootUID types.UID, maxDepth uint, depsIsDependencies bool) error
+	// Print prints the relationship tree of each of the provided root objects.
+	// Objects shared by multiple trees are only expanded in the first tree
+	// they appear in & are referenced in the others. Objects deeper than the
+	// provided maximum depth aren't printed, see graph.UnlimitedDepth.
+	Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool) error
+}
+
+// getRootNodes returns the nodes of the provided root objects.
+func getRootNodes(nodeMap graph.NodeMap, rootUIDs []types.UID) ([]*graph.Node, error) {
+	roots := make([]*graph.Node, 0, len(rootUIDs))
+	for _, uid := range rootUIDs {
+		root, ok := nodeMap[uid]
+		if !ok {
+			return nil, fmt.Errorf("requested object (uid: %s) not found in list of fetched objects", uid)
+		}
+		roots = append(roots, root)
+	}
+	return roots, nil
 }
 
 type tablePrinter struct {
-	configFlags  *HumanPrintFlags
-	outputFormat string
+	configFlags   *HumanPrintFlags
+	conditionType string
+	outputFormat  string
+	readyStatusFn func(node *graph.Node) (string, string)
+	sortBy        string
 
 	// client for fetching server-printed tables when printing in split output
 	// format
 	client client.Interface
 }
 
-func (p *tablePrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUID types.UID, maxDepth uint, depsIsDependencies bool) error {
-	root, ok := nodeMap[rootUID]
-	if !ok {
-		return fmt.Errorf("requested object (uid: %s) not found in list of fetched objects", rootUID)
+func (p *tablePrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool) error {
+	roots, err := getRootNodes(nodeMap, rootUIDs)
+	if err != nil {
+		return err
 	}
 
 	if p.configFlags.IsSplitOutputFormat(p.outputFormat) {
 		if p.client == nil {
 			return fmt.Errorf("client must be provided to get server-printed tables")
 		}
-		return p.printTablesByGK(w, nodeMap, maxDepth)
+		return p.printTablesByGK(w, nodeMap, roots, maxDepth, depsIsDependencies)
 	}
 
-	return p.printTable(w, nodeMap, root, maxDepth, depsIsDependencies)
+	return p.printTable(w, nodeMap, roots, maxDepth, depsIsDependencies)
 }
 
-func (p *tablePrinter) printTable(w io.Writer, nodeMap graph.NodeMap, root *graph.Node, maxDepth uint, depsIsDependencies bool) error {
+func (p *tablePrinter) printTable(w io.Writer, nodeMap graph.NodeMap, roots []*graph.Node, maxDepth uint, depsIsDependencies bool) error {
 	// Generate Table to print
 	showGroup := false
 	if sg := p.configFlags.ShowGroup; sg != nil {
 		showGroup = *sg
 	}
-	showGroupFn := createShowGroupFn(nodeMap, showGroup, maxDepth)
-	t, err := nodeMapToTable(nodeMap, root, maxDepth, depsIsDependencies, showGroupFn)
-	if err != nil {
-		return err
+	showAPIVersion := false
+	if sv := p.configFlags.ShowAPIVersion; sv != nil {
+		showAPIVersion = *sv
+	}
+	ascii := false
+	if a := p.configFlags.ASCII; a != nil {
+		ascii = *a
+	}
+	groupByKind := false
+	if gk := p.configFlags.GroupByKind; gk != nil {
+		groupByKind = *gk
+	}
+	collapse := false
+	if c := p.configFlags.Collapse; c != nil {
+		collapse = *c
+	}
+	ageFormat := ageFormatHuman
+	if af := p.configFlags.AgeFormat; af != nil {
+		ageFormat = *af
+	}
+	opts := tableOptions{
+		maxDepth:           maxDepth,
+		depsIsDependencies: depsIsDependencies,
+		sortDepsFn:         createSortDepsFn(nodeMap, p.sortBy, p.readyStatusFn),
+		showGroupFn:        createShowGroupFn(nodeMap, showGroup, maxDepth),
+		showAPIVersion:     showAPIVersion,
+		glyphs:             getTreeGlyphs(ascii),
+		groupByKind:        groupByKind,
+		collapse:           collapse,
+		ageFormat:          ageFormat,
+		readyStatusFn:      p.readyStatusFn,
+	}
+	// Tables are generated with the names of objects truncated to the provided
+	// width, since names can only be truncated reliably as they're generated
+	newTableFn := func(maxNameWidth uint) (*metav1.Table, error) {
+		tableOpts := opts
+		tableOpts.maxNameWidth = maxNameWidth
+		var t *metav1.Table
+		var err error
+		if p.configFlags.IsFlatOutputFormat(p.outputFormat) {
+			t, err = nodeMapToFlatTable(nodeMap, roots, tableOpts)
+		} else {
+			t, err = nodeMapToTable(nodeMap, roots, tableOpts)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if so := p.configFlags.ShowOwner; so != nil && *so {
+			addOwnerColumn(t)
+		}
+		if sca := p.configFlags.ShowConditionAge; sca != nil && *sca {
+			if err := addConditionAgeColumn(t, p.conditionType, ageFormat); err != nil {
+				return nil, err
+			}
+		}
+		if sn := p.configFlags.ShowNode; sn != nil && *sn {
+			showNodeColumn(t)
+		}
+		if cc := p.configFlags.ConditionColumns; cc != nil && len(*cc) > 0 {
+			if err := addConditionColumns(t, *cc); err != nil {
+				return nil, err
+			}
+		}
+		if r := p.configFlags.Reverse; r != nil && *r {
+			reverseTableRows(t, getTreeGlyphs(ascii))
+		}
+		return t, nil
 	}
 
 	// Setup Table printer
 	p.configFlags.SetShowNamespace(shouldShowNamespace(nodeMap, maxDepth))
-	tableprinter, err := p.configFlags.ToPrinter(p.outputFormat)
+	newPrinterFn := func() (printers.ResourcePrinter, error) {
+		return p.configFlags.ToPrinter(p.outputFormat)
+	}
+	maxNameWidth := uint(0)
+	if mw := p.configFlags.MaxNameWidth; mw != nil {
+		maxNameWidth = *mw
+	}
+	var buf bytes.Buffer
+	t, err := printTableWithinWidth(&buf, newPrinterFn, newTableFn, p.configFlags.GetWidth(w), maxNameWidth, getTreeGlyphs(ascii).ellipsis)
 	if err != nil {
 		return err
 	}
 
-	return tableprinter.PrintObj(t, w)
+	// Print the chain of controllers of each requested object above the
+	// relationship tree of its dependencies, so that the requested objects
+	// aren't mistaken for the objects at the top of the chain
+	withHeaders := len(t.Rows) > 0 && (p.configFlags.NoHeaders == nil || !*p.configFlags.NoHeaders)
+	if depsIsDependencies && withHeaders && !p.configFlags.IsFlatOutputFormat(p.outputFormat) {
+		for _, root := range roots {
+			if _, err := fmt.Fprintln(w, nodeToBreadcrumb(nodeMap, root, opts.glyphs, opts.showGroupFn, opts.showAPIVersion)); err != nil {
+				return err
+			}
+		}
+	}
+	if !p.configFlags.IsColorEnabled(w) {
+		_, err = buf.WriteTo(w)
+		return err
+	}
+
+	// Colorize the ready state of each object after the table is printed again
+	// with the cells to colorize escaped, so that ANSI escape codes don't affect
+	// the column widths
+	cells := escapeTableReadyCells(t)
+	printer, err := newPrinterFn()
+	if err != nil {
+		return err
+	}
+	buf.Reset()
+	if err := printer.PrintObj(t, &buf); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, colorizeEscapedCells(buf.String(), cells))
+	return err
 }
 
-func (p *tablePrinter) printTablesByGK(w io.Writer, nodeMap graph.NodeMap, maxDepth uint) error {
+// printTableWithinWidth prints the table generated by newTableFn with the names
+// of objects truncated to maxNameWidth if non-zero, & returns the printed table.
+// If any line is wider than the provided width, the cells of the "Status"
+// column (i.e. the reason objects aren't ready) & then the names of objects are
+// truncated further until every line fits, without going below
+// minTruncatedWidth. The table is printed as is if width is 0. A new printer is
+// requested from newPrinterFn for every attempt, since printers only print the
+// headers of the first table they print.
+func printTableWithinWidth(
+	w io.Writer,
+	newPrinterFn func() (printers.ResourcePrinter, error),
+	newTableFn func(maxNameWidth uint) (*metav1.Table, error),
+	width, maxNameWidth uint,
+	ellipsis string) (*metav1.Table, error) {
+	t, err := newTableFn(maxNameWidth)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	printFn := func() (int, error) {
+		buf.Reset()
+		p, err := newPrinterFn()
+		if err != nil {
+			return 0, err
+		}
+		if err := p.PrintObj(t, &buf); err != nil {
+			return 0, err
+		}
+		return maxLineWidth(buf.String()) - int(width), nil
+	}
+
+	excess, err := printFn()
+	if err != nil {
+		return nil, err
+	}
+	if width > 0 {
+		statusIx := columnIndex(t.ColumnDefinitions, "Status")
+		statusWidth := maxColumnWidth(t, statusIx)
+		for excess > 0 && statusWidth > minTruncatedWidth {
+			statusWidth = shrinkWidth(statusWidth, uint(excess))
+			truncateColumn(t, statusIx, statusWidth, ellipsis)
+			if excess, err = printFn(); err != nil {
+				return nil, err
+			}
+		}
+		nameWidth := maxNameLength(t)
+		if maxNameWidth > 0 && maxNameWidth < nameWidth {
+			nameWidth = maxNameWidth
+		}
+		for excess > 0 && nameWidth > minTruncatedWidth {
+			nameWidth = shrinkWidth(nameWidth, uint(excess))
+			if t, err = newTableFn(nameWidth); err != nil {
+				return nil, err
+			}
+			truncateColumn(t, statusIx, statusWidth, ellipsis)
+			if excess, err = printFn(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	_, err = buf.WriteTo(w)
+	return t, err
+}
+
+// printTablesByGK prints the tables of the objects in the relationship tree of
+// each provided root object, one table per GroupKind. Objects shared by
+// multiple trees are only printed with the tree of the first root object they
+// are reachable from.
+func (p *tablePrinter) printTablesByGK(w io.Writer, nodeMap graph.NodeMap, roots []*graph.Node, maxDepth uint, depsIsDependencies bool) error {
+	noHeaders := false
+	if nh := p.configFlags.NoHeaders; nh != nil {
+		noHeaders = *nh
+	}
+	printed := false
+	for _, treeMap := range nodeMapToTreeNodeMaps(nodeMap, roots, maxDepth, depsIsDependencies) {
+		if len(treeMap) == 0 {
+			continue
+		}
+		// Separate the tables of each tree with a blank line unless headers are
+		// omitted, like the tables of each GroupKind
+		if printed && !noHeaders {
+			fmt.Fprintf(w, "\n")
+		}
+		if err := p.printTreeTablesByGK(w, nodeMap, treeMap, maxDepth); err != nil {
+			return err
+		}
+		printed = true
+	}
+	return nil
+}
+
+// nodeMapToTreeNodeMaps splits the objects of the relationship tree of each
+// provided root object up to the provided depth into a submap, in the order of
+// the root objects. Objects shared by multiple trees are only included in the
+// submap of the first root object they are reachable from.
+func nodeMapToTreeNodeMaps(nodeMap graph.NodeMap, roots []*graph.Node, maxDepth uint, depsIsDependencies bool) []graph.NodeMap {
+	uidSet := map[types.UID]struct{}{}
+	result := make([]graph.NodeMap, 0, len(roots))
+	for _, root := range roots {
+		treeMap := graph.NodeMap{}
+		depthMap, uidQueue := map[types.UID]uint{}, []types.UID{}
+		if _, ok := uidSet[root.UID]; !ok {
+			depthMap[root.UID] = 0
+			uidQueue = append(uidQueue, root.UID)
+		}
+		for len(uidQueue) > 0 {
+			uid := uidQueue[0]
+			uidQueue = uidQueue[1:]
+			node, ok := nodeMap[uid]
+			if !ok {
+				continue
+			}
+			uidSet[uid] = struct{}{}
+			treeMap[uid] = node
+			if depthMap[uid] >= maxDepth {
+				continue
+			}
+			for depUID := range node.GetDeps(depsIsDependencies) {
+				if _, ok := uidSet[depUID]; ok {
+					continue
+				}
+				if _, ok := depthMap[depUID]; ok {
+					continue
+				}
+				depthMap[depUID] = depthMap[uid] + 1
+				uidQueue = append(uidQueue, depUID)
+			}
+		}
+		result = append(result, treeMap)
+	}
+	return result
+}
+
+// printTreeTablesByGK prints the tables of the provided objects of a single
+// relationship tree, one table per GroupKind.
+func (p *tablePrinter) printTreeTablesByGK(w io.Writer, nodeMap, treeMap graph.NodeMap, maxDepth uint) error {
 	// Generate Tables to print
 	showGroup, showNamespace := false, false
 	if sg := p.configFlags.ShowGroup; sg != nil {
@@ -86,10 +456,14 @@ func (p *tablePrinter) printTablesByGK(w io.Writer, nodeMap graph.NodeMap, maxDe
 	if sg := p.configFlags.ShowNamespace; sg != nil {
 		showNamespace = *sg
 	}
+	noHeaders := false
+	if nh := p.configFlags.NoHeaders; nh != nil {
+		noHeaders = *nh
+	}
 	showGroupFn := createShowGroupFn(nodeMap, showGroup, maxDepth)
 	showNamespaceFn := createShowNamespaceFn(nodeMap, showNamespace, maxDepth)
 
-	tListByGK, err := p.nodeMapToTableByGK(nodeMap, maxDepth)
+	tListByGK, err := p.nodeMapToTableByGK(treeMap, maxDepth)
 	if err != nil {
 		return err
 	}
@@ -118,7 +492,9 @@ func (p *tablePrinter) printTablesByGK(w io.Writer, nodeMap graph.NodeMap, maxDe
 			if err != nil {
 				return err
 			}
-			if ix != len(gkList)-1 {
+			// Separate tables with a blank line unless headers are omitted, so
+			// that the output remains a contiguous list of rows
+			if !noHeaders && ix != len(gkList)-1 {
 				fmt.Fprintf(w, "\n")
 			}
 		}
@@ -132,7 +508,7 @@ func (p *tablePrinter) nodeMapToTableByGK(nodeMap graph.NodeMap, maxDepth uint)
 	// Filter objects to print based on depth
 	objUIDs := []types.UID{}
 	for uid, node := range nodeMap {
-		if maxDepth == 0 || node.Depth <= maxDepth {
+		if node.Depth <= maxDepth {
 			objUIDs = append(objUIDs, uid)
 		}
 	}
@@ -152,13 +528,16 @@ func (p *tablePrinter) nodeMapToTableByGK(nodeMap graph.NodeMap, maxDepth uint)
 
 	// Fan-out to get server-print tables for all objects
 	eg, ctx := errgroup.WithContext(context.Background())
+	var mu sync.Mutex
 	tableByGKAndNS := map[schema.GroupKind](map[string]*metav1.Table){}
 	for gk, nodesByNS := range nodesByGKAndNS {
 		if len(gk.Kind) == 0 {
 			continue
 		}
 		for ns, nodes := range nodesByNS {
-			if len(nodes) == 0 {
+			// Synthetic objects (e.g. RBAC users & groups) aren't backed by any
+			// API resource, so there aren't any server-printed tables for them
+			if len(nodes) == 0 || len(nodes[0].Resource) == 0 {
 				continue
 			}
 			gk, api, ns, names := gk, client.APIResource(nodes[0].GetAPIResource()), ns, []string{}
@@ -176,6 +555,8 @@ func (p *tablePrinter) nodeMapToTableByGK(nodeMap graph.NodeMap, maxDepth uint)
 				if err != nil || table == nil {
 					return err
 				}
+				mu.Lock()
+				defer mu.Unlock()
 				if _, ok := tableByGKAndNS[gk]; !ok {
 					tableByGKAndNS[gk] = map[string]*metav1.Table{}
 				}
@@ -0,0 +1,98 @@
+package printers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+type csvPrinter struct {
+	ageFormat      string
+	columnLabels   []string
+	noHeaders      bool
+	outputFormat   string
+	readyStatusFn  func(node *graph.Node) (string, string)
+	showAPIVersion bool
+	showGroup      bool
+	sortBy         string
+}
+
+func (p *csvPrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool) error {
+	roots, err := getRootNodes(nodeMap, rootUIDs)
+	if err != nil {
+		return err
+	}
+
+	t, err := nodeMapToFlatTable(nodeMap, roots, tableOptions{
+		maxDepth:           maxDepth,
+		depsIsDependencies: depsIsDependencies,
+		sortDepsFn:         createSortDepsFn(nodeMap, p.sortBy, p.readyStatusFn),
+		showGroupFn:        createShowGroupFn(nodeMap, p.showGroup, maxDepth),
+		showAPIVersion:     p.showAPIVersion,
+		ageFormat:          p.ageFormat,
+		readyStatusFn:      p.readyStatusFn,
+	})
+	if err != nil {
+		return err
+	}
+	addLabelColumns(t, p.columnLabels)
+
+	cw := csv.NewWriter(w)
+	switch p.outputFormat {
+	case outputFormatCSV:
+	case outputFormatTSV:
+		cw.Comma = '\t'
+	default:
+		return fmt.Errorf("output format \"%s\" not supported", p.outputFormat)
+	}
+	for _, record := range tableToRecords(t, !p.noHeaders) {
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// tableToRecords converts the provided table into records of every column of
+// the table preceded by the namespace of the object, optionally including a
+// header record. Placeholder values for missing namespaces & parents are
+// replaced with empty values.
+func tableToRecords(t *metav1.Table, withHeaders bool) [][]string {
+	records := make([][]string, 0, len(t.Rows)+1)
+	if withHeaders {
+		record := []string{"NAMESPACE"}
+		for _, c := range t.ColumnDefinitions {
+			record = append(record, strings.ToUpper(c.Name))
+		}
+		records = append(records, record)
+	}
+	for _, row := range t.Rows {
+		ns := ""
+		if obj, ok := row.Object.Object.(metav1.Object); ok && obj.GetNamespace() != cellNone {
+			ns = obj.GetNamespace()
+		}
+		record := []string{ns}
+		for _, cell := range row.Cells {
+			var v string
+			switch c := cell.(type) {
+			case []string:
+				v = strings.Join(c, ",")
+			default:
+				v = fmt.Sprintf("%v", c)
+			}
+			if v == cellNone {
+				v = ""
+			}
+			record = append(record, v)
+		}
+		records = append(records, record)
+	}
+	return records
+}
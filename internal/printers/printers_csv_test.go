@@ -0,0 +1,83 @@
+package printers
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+func TestCSVPrinter(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	cr := newTestNode("uid-cr", "rbac.authorization.k8s.io", "ClusterRole", "", "view,edit", nil)
+	nodeMap["uid-deploy"].AddDependent(cr.UID, graph.RelationshipOwnerRef)
+	nodeMap[cr.UID] = cr
+	nodeMap["uid-pod"].SetLabels(map[string]string{"app.kubernetes.io/version": "v2", "pod-template-hash": "5cc79d4bf5"})
+
+	readyStatusFn, err := createReadyStatusFn(conditionTypeReady)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		name         string
+		outputFormat string
+		noHeaders    bool
+		columnLabels []string
+		expected     string
+	}{
+		{
+			name:         "csv",
+			outputFormat: outputFormatCSV,
+			expected: `NAMESPACE,NAME,READY,STATUS,AGE,DEPTH,PARENT,RESTARTS,NODE,IP,RELATIONSHIPS
+default,Deployment/web,0/0,,<unknown>,0,,-,,,
+,"ClusterRole/view,edit",-,,<unknown>,1,Deployment/web,-,,,OwnerReference
+default,ReplicaSet/web-5cc79d4bf5,0/0,,<unknown>,1,Deployment/web,-,,,ControllerReference
+default,Pod/web-5cc79d4bf5-xgvkc,0/1,,<unknown>,2,ReplicaSet/web-5cc79d4bf5,0,,,ControllerReference
+`,
+		},
+		{
+			name:         "csv with label columns",
+			outputFormat: outputFormatCSV,
+			columnLabels: []string{"app.kubernetes.io/version", "pod-template-hash"},
+			expected: `NAMESPACE,NAME,READY,STATUS,AGE,DEPTH,PARENT,RESTARTS,NODE,IP,RELATIONSHIPS,VERSION,POD-TEMPLATE-HASH
+default,Deployment/web,0/0,,<unknown>,0,,-,,,,,
+,"ClusterRole/view,edit",-,,<unknown>,1,Deployment/web,-,,,OwnerReference,,
+default,ReplicaSet/web-5cc79d4bf5,0/0,,<unknown>,1,Deployment/web,-,,,ControllerReference,,
+default,Pod/web-5cc79d4bf5-xgvkc,0/1,,<unknown>,2,ReplicaSet/web-5cc79d4bf5,0,,,ControllerReference,v2,5cc79d4bf5
+`,
+		},
+		{
+			name:         "tsv without headers",
+			outputFormat: outputFormatTSV,
+			noHeaders:    true,
+			expected: "default\tDeployment/web\t0/0\t\t<unknown>\t0\t\t-\t\t\t\n" +
+				"\tClusterRole/view,edit\t-\t\t<unknown>\t1\tDeployment/web\t-\t\t\tOwnerReference\n" +
+				"default\tReplicaSet/web-5cc79d4bf5\t0/0\t\t<unknown>\t1\tDeployment/web\t-\t\t\tControllerReference\n" +
+				"default\tPod/web-5cc79d4bf5-xgvkc\t0/1\t\t<unknown>\t2\tReplicaSet/web-5cc79d4bf5\t0\t\t\tControllerReference\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := &csvPrinter{
+				columnLabels:  tt.columnLabels,
+				noHeaders:     tt.noHeaders,
+				outputFormat:  tt.outputFormat,
+				readyStatusFn: readyStatusFn,
+			}
+			var buf bytes.Buffer
+			if err := p.Print(&buf, nodeMap, []types.UID{"uid-deploy"}, graph.UnlimitedDepth, false); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := buf.String(); got != tt.expected {
+				t.Fatalf("expected \"%s\" got \"%s\"", tt.expected, got)
+			}
+		})
+	}
+}
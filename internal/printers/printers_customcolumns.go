@@ -0,0 +1,119 @@
+package printers
+
+import (
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/kubectl/pkg/cmd/get"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+// customColumn represents a user-specified column.
+type customColumn struct {
+	Header   string
+	JSONPath *jsonpath.JSONPath
+}
+
+type customColumnsPrinter struct {
+	ageFormat      string
+	ascii          bool
+	columns        []customColumn
+	noHeaders      bool
+	readyStatusFn  func(node *graph.Node) (string, string)
+	showAPIVersion bool
+	showGroup      bool
+	sortBy         string
+}
+
+// newCustomColumnsPrinter returns a printer that prints the relationship tree
+// with the provided columns in place of the default columns.
+func newCustomColumnsPrinter(
+	columns []get.Column,
+	ascii, noHeaders, showAPIVersion, showGroup bool,
+	ageFormat, sortBy string,
+	readyStatusFn func(node *graph.Node) (string, string)) (*customColumnsPrinter, error) {
+	p := customColumnsPrinter{
+		ageFormat:      ageFormat,
+		ascii:          ascii,
+		columns:        make([]customColumn, len(columns)),
+		noHeaders:      noHeaders,
+		readyStatusFn:  readyStatusFn,
+		showAPIVersion: showAPIVersion,
+		showGroup:      showGroup,
+		sortBy:         sortBy,
+	}
+	for ix, c := range columns {
+		jp := jsonpath.New(c.Header).AllowMissingKeys(true)
+		if err := jp.Parse(c.FieldSpec); err != nil {
+			return nil, err
+		}
+		p.columns[ix] = customColumn{Header: c.Header, JSONPath: jp}
+	}
+	return &p, nil
+}
+
+func (p *customColumnsPrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool) error {
+	roots, err := getRootNodes(nodeMap, rootUIDs)
+	if err != nil {
+		return err
+	}
+
+	t, err := nodeMapToTable(nodeMap, roots, tableOptions{
+		maxDepth:           maxDepth,
+		depsIsDependencies: depsIsDependencies,
+		sortDepsFn:         createSortDepsFn(nodeMap, p.sortBy, p.readyStatusFn),
+		showGroupFn:        createShowGroupFn(nodeMap, p.showGroup, maxDepth),
+		showAPIVersion:     p.showAPIVersion,
+		glyphs:             getTreeGlyphs(p.ascii),
+		ageFormat:          p.ageFormat,
+		readyStatusFn:      p.readyStatusFn,
+	})
+	if err != nil {
+		return err
+	}
+	t, err = tableToCustomColumnsTable(t, p.columns)
+	if err != nil {
+		return err
+	}
+
+	tableprinter := printers.NewTablePrinter(printers.PrintOptions{NoHeaders: p.noHeaders})
+	return tableprinter.PrintObj(t, w)
+}
+
+// tableToCustomColumnsTable converts the provided table into a table that only
+// includes the "Name" column, which holds the relationship tree, followed by
+// the provided columns.
+func tableToCustomColumnsTable(t *metav1.Table, columns []customColumn) (*metav1.Table, error) {
+	columnDefinitions := []metav1.TableColumnDefinition{objectColumnDefinitions[0]}
+	for _, c := range columns {
+		columnDefinitions = append(columnDefinitions, metav1.TableColumnDefinition{Name: c.Header, Type: "string"})
+	}
+
+	rows := make([]metav1.TableRow, len(t.Rows))
+	for ix, row := range t.Rows {
+		cells := []interface{}{row.Cells[0]}
+		u, ok := row.Object.Object.(*unstructuredv1.Unstructured)
+		for _, c := range columns {
+			if !ok {
+				cells = append(cells, "")
+				continue
+			}
+			value, err := getNestedString(u.UnstructuredContent(), c.JSONPath)
+			if err != nil {
+				return nil, err
+			}
+			if len(value) == 0 {
+				value = cellNone
+			}
+			cells = append(cells, value)
+		}
+		rows[ix] = metav1.TableRow{Cells: cells, Object: row.Object}
+	}
+
+	return &metav1.Table{ColumnDefinitions: columnDefinitions, Rows: rows}, nil
+}
@@ -0,0 +1,43 @@
+package printers
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+func TestCustomColumnsPrinter(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	nodeMap["uid-pod"].SetLabels(map[string]string{"app": "web"})
+	columns, err := NewCustomColumnsPrintFlags().ToColumns("custom-columns=UID:.metadata.uid,APP:.metadata.labels.app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	readyStatusFn, err := createReadyStatusFn(conditionTypeReady)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p, err := newCustomColumnsPrinter(columns, false, false, false, false, ageFormatHuman, "", readyStatusFn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := p.Print(&buf, nodeMap, []types.UID{"uid-deploy"}, graph.UnlimitedDepth, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Missing fields are printed as "<none>"
+	expected := `NAME                               UID          APP
+▶ Deployment/web                   uid-deploy   <none>
+└── ReplicaSet/web-5cc79d4bf5      uid-rs       <none>
+    └── Pod/web-5cc79d4bf5-xgvkc   uid-pod      web
+`
+	if output := buf.String(); output != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, output)
+	}
+}
@@ -0,0 +1,268 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+type graphPrinter struct {
+	outputFormat   string
+	readyStatusFn  func(node *graph.Node) (string, string)
+	showAPIVersion bool
+	showGroup      bool
+	sortBy         string
+}
+
+func (p *graphPrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool) error {
+	roots, err := getRootNodes(nodeMap, rootUIDs)
+	if err != nil {
+		return err
+	}
+
+	showGroupFn := createShowGroupFn(nodeMap, p.showGroup, maxDepth)
+	sortDepsFn := createSortDepsFn(nodeMap, p.sortBy, p.readyStatusFn)
+	switch p.outputFormat {
+	case outputFormatDOT:
+		return writeDOT(w, nodeMap, roots, maxDepth, depsIsDependencies, sortDepsFn, showGroupFn, p.showAPIVersion, p.readyStatusFn)
+	case outputFormatMermaid:
+		return writeMermaid(w, nodeMap, roots, maxDepth, depsIsDependencies, sortDepsFn, showGroupFn, p.showAPIVersion)
+	default:
+		return fmt.Errorf("output format \"%s\" not supported", p.outputFormat)
+	}
+}
+
+// graphEdge represents a directed edge between two nodes in a graph, along
+// with the relationships between them.
+type graphEdge struct {
+	From          types.UID
+	To            types.UID
+	Relationships graph.RelationshipSet
+	// Cycle is true if the edge leads back to an ancestor of the object.
+	Cycle bool
+}
+
+// nodeMapToGraph returns the list of nodes & edges reachable from the provided
+// root nodes. Both lists are ordered by their first appearance in a depth-first
+// traversal of the relationship trees.
+func nodeMapToGraph(
+	nodeMap graph.NodeMap,
+	roots []*graph.Node,
+	maxDepth uint,
+	depsIsDependencies bool,
+	sortDepsFn func(d map[types.UID]graph.RelationshipSet) []types.UID) ([]*graph.Node, []graphEdge, error) {
+	var nodes []*graph.Node
+	var edges []graphEdge
+	uidSet := map[types.UID]struct{}{}
+	pathSet := map[types.UID]struct{}{}
+
+	var walkFn func(node *graph.Node, depth uint) error
+	walkFn = func(node *graph.Node, depth uint) error {
+		// Guard against possible cycles
+		if _, ok := uidSet[node.UID]; ok {
+			return nil
+		}
+		uidSet[node.UID] = struct{}{}
+		pathSet[node.UID] = struct{}{}
+		defer delete(pathSet, node.UID)
+		nodes = append(nodes, node)
+		if depth >= maxDepth {
+			return nil
+		}
+
+		deps := node.GetDeps(depsIsDependencies)
+		for _, childUID := range sortDepsFn(deps) {
+			child, ok := nodeMap[childUID]
+			if !ok {
+				return fmt.Errorf("dependent object (uid: %s) not found in list of fetched objects", childUID)
+			}
+			_, cycle := pathSet[childUID]
+			edges = append(edges, graphEdge{From: node.UID, To: childUID, Relationships: deps[childUID], Cycle: cycle})
+			if err := walkFn(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, root := range roots {
+		if err := walkFn(root, 0); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return nodes, edges, nil
+}
+
+// nodeToGraphLabel returns the label of the provided node in a graph.
+func nodeToGraphLabel(node *graph.Node, showGroupFn func(kind string) bool, showAPIVersion bool) string {
+	label := nodeToTableName(node, showGroupFn, showAPIVersion)
+	if len(node.Cluster) > 0 {
+		label = fmt.Sprintf("%s [cluster: %s]", label, node.Cluster)
+	}
+	return label
+}
+
+// isNonControllerOwnerEdge returns true if the provided relationships of an
+// edge include an owner reference that isn't the controller of the object
+// (e.g. the additional owners of an object with multiple owners).
+func isNonControllerOwnerEdge(rset graph.RelationshipSet) bool {
+	_, isOwner := rset[graph.RelationshipOwnerRef]
+	_, isController := rset[graph.RelationshipControllerRef]
+	return isOwner && !isController
+}
+
+// escapeDOTString escapes the provided string so that it can be used as a
+// quoted string in the DOT language.
+func escapeDOTString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// writeDOT writes the provided nodes & either their dependencies or dependents
+// as a directed graph in the Graphviz DOT language.
+func writeDOT(
+	w io.Writer,
+	nodeMap graph.NodeMap,
+	roots []*graph.Node,
+	maxDepth uint,
+	depsIsDependencies bool,
+	sortDepsFn func(d map[types.UID]graph.RelationshipSet) []types.UID,
+	showGroupFn func(kind string) bool,
+	showAPIVersion bool,
+	readyStatusFn func(node *graph.Node) (string, string)) error {
+	nodes, edges, err := nodeMapToGraph(nodeMap, roots, maxDepth, depsIsDependencies, sortDepsFn)
+	if err != nil {
+		return err
+	}
+	rootUIDSet := map[types.UID]struct{}{}
+	for _, root := range roots {
+		rootUIDSet[root.UID] = struct{}{}
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph {\n")
+	b.WriteString("    node [shape=\"box\"];\n")
+	for _, node := range nodes {
+		var styles []string
+		attrs := []string{fmt.Sprintf("label=\"%s\"", escapeDOTString(nodeToGraphLabel(node, showGroupFn, showAPIVersion)))}
+		if _, ok := rootUIDSet[node.UID]; ok {
+			styles = append(styles, "bold")
+		}
+		if ready, status := readyStatusFn(node); getReadiness(ready, status) == readinessNotReady {
+			styles = append(styles, "filled")
+			attrs = append(attrs, "fillcolor=\"red\"")
+		}
+		if node.PassThrough {
+			styles = append(styles, "dashed")
+		}
+		if node.NotFound {
+			styles = append(styles, "dotted")
+		}
+		if len(styles) > 0 {
+			attrs = append(attrs, fmt.Sprintf("style=\"%s\"", strings.Join(styles, ",")))
+		}
+		fmt.Fprintf(&b, "    \"%s\" [%s];\n", escapeDOTString(string(node.UID)), strings.Join(attrs, ", "))
+	}
+	for _, e := range edges {
+		var attrs []string
+		label := strings.Join(e.Relationships.List(), ",")
+		if e.Cycle {
+			label = strings.TrimSpace(label + " " + cellCycle)
+		}
+		if len(label) > 0 {
+			attrs = append(attrs, fmt.Sprintf("label=\"%s\"", escapeDOTString(label)))
+		}
+		if isNonControllerOwnerEdge(e.Relationships) {
+			attrs = append(attrs, "style=\"dashed\"")
+		}
+		var attrList string
+		if len(attrs) > 0 {
+			attrList = fmt.Sprintf(" [%s]", strings.Join(attrs, ", "))
+		}
+		fmt.Fprintf(&b, "    \"%s\" -> \"%s\"%s;\n", escapeDOTString(string(e.From)), escapeDOTString(string(e.To)), attrList)
+	}
+	b.WriteString("}\n")
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidNodeID returns an identifier derived from the provided UID that is
+// valid in a Mermaid diagram.
+func mermaidNodeID(uid types.UID) string {
+	var b strings.Builder
+	b.WriteString("uid_")
+	for _, r := range string(uid) {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// escapeMermaidString escapes the provided string so that it can be used as a
+// quoted node label in a Mermaid diagram.
+func escapeMermaidString(s string) string {
+	return strings.NewReplacer(
+		"#", "#35;",
+		`"`, "#quot;",
+		"<", "#lt;",
+		">", "#gt;",
+		"\n", " ",
+	).Replace(s)
+}
+
+// writeMermaid writes the provided nodes & either their dependencies or
+// dependents as a top-down Mermaid flowchart.
+func writeMermaid(
+	w io.Writer,
+	nodeMap graph.NodeMap,
+	roots []*graph.Node,
+	maxDepth uint,
+	depsIsDependencies bool,
+	sortDepsFn func(d map[types.UID]graph.RelationshipSet) []types.UID,
+	showGroupFn func(kind string) bool,
+	showAPIVersion bool) error {
+	nodes, edges, err := nodeMapToGraph(nodeMap, roots, maxDepth, depsIsDependencies, sortDepsFn)
+	if err != nil {
+		return err
+	}
+	rootUIDSet := map[types.UID]struct{}{}
+	for _, root := range roots {
+		rootUIDSet[root.UID] = struct{}{}
+	}
+
+	var b strings.Builder
+	b.WriteString("graph TD\n")
+	for _, node := range nodes {
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", mermaidNodeID(node.UID), escapeMermaidString(nodeToGraphLabel(node, showGroupFn, showAPIVersion)))
+		var styles []string
+		if _, ok := rootUIDSet[node.UID]; ok {
+			styles = append(styles, "stroke-width:3px")
+		}
+		if node.NotFound {
+			styles = append(styles, "stroke-dasharray: 2 2")
+		}
+		if len(styles) > 0 {
+			fmt.Fprintf(&b, "    style %s %s\n", mermaidNodeID(node.UID), strings.Join(styles, ","))
+		}
+	}
+	for _, e := range edges {
+		arrow := "-->"
+		if isNonControllerOwnerEdge(e.Relationships) {
+			arrow = "-.->"
+		}
+		if e.Cycle {
+			arrow += fmt.Sprintf("|\"%s\"|", cellCycle)
+		}
+		fmt.Fprintf(&b, "    %s %s %s\n", mermaidNodeID(e.From), arrow, mermaidNodeID(e.To))
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
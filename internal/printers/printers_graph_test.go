@@ -0,0 +1,181 @@
+package printers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+func TestWriteDOT(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	showGroupFn := createShowGroupFn(nodeMap, false, 0)
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	sortDepsFn := createSortDepsFn(nodeMap, "", readyStatusFn)
+	var buf bytes.Buffer
+	if err := writeDOT(&buf, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, graph.UnlimitedDepth, false, sortDepsFn, showGroupFn, false, readyStatusFn); err != nil {
+		t.Fatalf("failed to write DOT: %v", err)
+	}
+
+	expected := `digraph {
+    node [shape="box"];
+    "uid-deploy" [label="Deployment/web", style="bold"];
+    "uid-rs" [label="ReplicaSet/web-5cc79d4bf5"];
+    "uid-pod" [label="Pod/web-5cc79d4bf5-xgvkc", fillcolor="red", style="filled"];
+    "uid-deploy" -> "uid-rs" [label="ControllerReference"];
+    "uid-rs" -> "uid-pod" [label="ControllerReference"];
+}
+`
+	if output := buf.String(); output != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, output)
+	}
+}
+
+func TestWriteDOTOwnerReferences(t *testing.T) {
+	t.Parallel()
+
+	deploy := newTestNode("uid-deploy", "apps", "Deployment", "default", "web", nil)
+	rs := newTestNode("uid-rs", "apps", "ReplicaSet", "default", "web-5cc79d4bf5", nil)
+	cm := newTestNode("uid-cm", "", "ConfigMap", "default", "web-config", nil)
+	for _, r := range []graph.Relationship{graph.RelationshipControllerRef, graph.RelationshipOwnerRef} {
+		deploy.AddDependent(rs.UID, r)
+		rs.AddDependency(deploy.UID, r)
+	}
+	deploy.AddDependent(cm.UID, graph.RelationshipOwnerRef)
+	cm.AddDependency(deploy.UID, graph.RelationshipOwnerRef)
+	nodeMap := graph.NodeMap{deploy.UID: deploy, rs.UID: rs, cm.UID: cm}
+	showGroupFn := createShowGroupFn(nodeMap, false, 0)
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	sortDepsFn := createSortDepsFn(nodeMap, "", readyStatusFn)
+
+	var buf bytes.Buffer
+	if err := writeDOT(&buf, nodeMap, []*graph.Node{deploy}, graph.UnlimitedDepth, false, sortDepsFn, showGroupFn, false, readyStatusFn); err != nil {
+		t.Fatalf("failed to write DOT: %v", err)
+	}
+	expected := `digraph {
+    node [shape="box"];
+    "uid-deploy" [label="Deployment/web", style="bold"];
+    "uid-cm" [label="ConfigMap/web-config"];
+    "uid-rs" [label="ReplicaSet/web-5cc79d4bf5"];
+    "uid-deploy" -> "uid-cm" [label="OwnerReference", style="dashed"];
+    "uid-deploy" -> "uid-rs" [label="ControllerReference,OwnerReference"];
+}
+`
+	if output := buf.String(); output != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, output)
+	}
+
+	buf.Reset()
+	if err := writeMermaid(&buf, nodeMap, []*graph.Node{deploy}, graph.UnlimitedDepth, false, sortDepsFn, showGroupFn, false); err != nil {
+		t.Fatalf("failed to write Mermaid: %v", err)
+	}
+	expected = `graph TD
+    uid_uid_deploy["Deployment/web"]
+    style uid_uid_deploy stroke-width:3px
+    uid_uid_cm["ConfigMap/web-config"]
+    uid_uid_rs["ReplicaSet/web-5cc79d4bf5"]
+    uid_uid_deploy -.-> uid_uid_cm
+    uid_uid_deploy --> uid_uid_rs
+`
+	if output := buf.String(); output != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, output)
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	nodeMap["uid-deploy"].Name = "web\"<#>"
+	showGroupFn := createShowGroupFn(nodeMap, false, 0)
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	sortDepsFn := createSortDepsFn(nodeMap, "", readyStatusFn)
+	var buf bytes.Buffer
+	if err := writeMermaid(&buf, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, graph.UnlimitedDepth, false, sortDepsFn, showGroupFn, false); err != nil {
+		t.Fatalf("failed to write Mermaid: %v", err)
+	}
+
+	expected := `graph TD
+    uid_uid_deploy["Deployment/web#quot;#lt;#35;#gt;"]
+    style uid_uid_deploy stroke-width:3px
+    uid_uid_rs["ReplicaSet/web-5cc79d4bf5"]
+    uid_uid_pod["Pod/web-5cc79d4bf5-xgvkc"]
+    uid_uid_deploy --> uid_uid_rs
+    uid_uid_rs --> uid_uid_pod
+`
+	if output := buf.String(); output != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, output)
+	}
+}
+
+func TestNodeToGraphLabel(t *testing.T) {
+	t.Parallel()
+
+	node := newTestNode("uid-deploy", "apps", "Deployment", "default", "web", nil)
+	clusterNode := newTestNode("uid-node", "", "Node", "", "worker-1", nil)
+	clusterNode.Cluster = "workload"
+	tests := []struct {
+		node           *graph.Node
+		showGroup      bool
+		showAPIVersion bool
+		expected       string
+	}{
+		{node, false, false, "Deployment/web"},
+		{node, true, false, "Deployment.apps/web"},
+		{node, false, true, "apps/v1/Deployment/web"},
+		{node, true, true, "apps/v1/Deployment/web"},
+		{clusterNode, false, true, "v1/Node/worker-1 [cluster: workload]"},
+	}
+	for _, tt := range tests {
+		showGroupFn := func(string) bool { return tt.showGroup }
+		if output := nodeToGraphLabel(tt.node, showGroupFn, tt.showAPIVersion); output != tt.expected {
+			t.Fatalf("expected \"%s\" got \"%s\"", tt.expected, output)
+		}
+	}
+}
+
+func TestWriteGraphCycle(t *testing.T) {
+	t.Parallel()
+
+	a := newTestNode("uid-a", "example.com", "Foo", "default", "a", nil)
+	b := newTestNode("uid-b", "example.com", "Bar", "default", "b", nil)
+	a.AddDependent(b.UID, graph.RelationshipControllerRef)
+	b.AddDependent(a.UID, graph.RelationshipControllerRef)
+	nodeMap := graph.NodeMap{a.UID: a, b.UID: b}
+	showGroupFn := createShowGroupFn(nodeMap, false, 0)
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	sortDepsFn := createSortDepsFn(nodeMap, "", readyStatusFn)
+
+	var buf bytes.Buffer
+	if err := writeDOT(&buf, nodeMap, []*graph.Node{a}, graph.UnlimitedDepth, false, sortDepsFn, showGroupFn, false, readyStatusFn); err != nil {
+		t.Fatalf("failed to write DOT: %v", err)
+	}
+	expected := `digraph {
+    node [shape="box"];
+    "uid-a" [label="Foo/a", style="bold"];
+    "uid-b" [label="Bar/b"];
+    "uid-a" -> "uid-b" [label="ControllerReference"];
+    "uid-b" -> "uid-a" [label="ControllerReference (cycle)"];
+}
+`
+	if output := buf.String(); output != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, output)
+	}
+
+	buf.Reset()
+	if err := writeMermaid(&buf, nodeMap, []*graph.Node{a}, graph.UnlimitedDepth, false, sortDepsFn, showGroupFn, false); err != nil {
+		t.Fatalf("failed to write Mermaid: %v", err)
+	}
+	expected = `graph TD
+    uid_uid_a["Foo/a"]
+    style uid_uid_a stroke-width:3px
+    uid_uid_b["Bar/b"]
+    uid_uid_a --> uid_uid_b
+    uid_uid_b -->|"(cycle)"| uid_uid_a
+`
+	if output := buf.String(); output != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, output)
+	}
+}
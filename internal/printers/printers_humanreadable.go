@@ -2,11 +2,13 @@ package printers
 
 import (
 	"fmt"
-	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	eventsv1 "k8s.io/api/events/v1"
 	policyv1 "k8s.io/api/policy/v1"
@@ -23,9 +25,71 @@ import (
 	"github.com/tohjustin/kube-lineage/internal/graph"
 )
 
+// ANSI escape codes used for colorizing table cells.
 const (
+	colorGreen  = "\x1b[32m"
+	colorRed    = "\x1b[31m"
+	colorReset  = "\x1b[0m"
+	colorYellow = "\x1b[33m"
+)
+
+// tabwriterEscape is the character escaping text segments in the tabwriter of
+// table printers, which excludes it from the column widths & passes it through
+// to the output.
+const tabwriterEscape = "\xff"
+
+// conditionTypeReady is the default status condition type used to determine
+// the ready & status value of objects.
+const conditionTypeReady = "Ready"
+
+// treeGlyphs is the set of characters used to draw the relationship tree.
+type treeGlyphs struct {
+	branch     string
+	lastBranch string
+	vertical   string
+	indent     string
+	ellipsis   string
+	// reversedLastBranch replaces lastBranch when the relationship tree is
+	// printed bottom-up, since the last dependent is then listed first
+	reversedLastBranch string
+	// root marks the requested objects at the root of the relationship tree
+	root string
+	// separator separates the objects of a breadcrumb
+	separator string
+}
+
+var (
+	unicodeTreeGlyphs = treeGlyphs{branch: "├── ", lastBranch: "└── ", vertical: "│   ", indent: "    ", ellipsis: "…", reversedLastBranch: "┌── ", root: "▶ ", separator: " › "}
+	asciiTreeGlyphs   = treeGlyphs{branch: "|-- ", lastBranch: "`-- ", vertical: "|   ", indent: "    ", ellipsis: "...", reversedLastBranch: ",-- ", root: "> ", separator: " -> "}
+)
+
+// getTreeGlyphs returns the set of characters used to draw the relationship
+// tree, using only ASCII characters if ascii is true.
+func getTreeGlyphs(ascii bool) treeGlyphs {
+	if ascii {
+		return asciiTreeGlyphs
+	}
+	return unicodeTreeGlyphs
+}
+
+// statusNotFound is the status of objects that are referenced by other objects
+// but don't exist in the cluster.
+const statusNotFound = "NotFound"
+
+// minTruncatedWidth is the minimum width cells are truncated to when fitting
+// tables within the width of the terminal.
+const minTruncatedWidth = 8
+
+const (
+	cellNone          = "<none>"
 	cellUnknown       = "<unknown>"
 	cellNotApplicable = "-"
+	cellSeeAbove      = "(see above)"
+	cellSeeBelow      = "(see below)"
+	cellCycle         = "(cycle)"
+	// cellNonControllerOwner marks objects whose relationship with their parent
+	// is an owner reference that doesn't mark the parent as their controller
+	cellNonControllerOwner = "*"
 )
 
 var (
@@ -35,14 +99,31 @@ var (
 		{Name: "Ready", Type: "string", Description: "The readiness state of this object."},
 		{Name: "Status", Type: "string", Description: "The status of this object."},
 		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+		{Name: "Restarts", Type: "string", Description: "The number of times the containers in this Pod have been restarted.", Priority: -1},
+		{Name: "Node", Type: "string", Description: corev1.PodSpec{}.SwaggerDoc()["nodeName"], Priority: -1},
+		{Name: "IP", Type: "string", Description: corev1.PodStatus{}.SwaggerDoc()["podIP"], Priority: -1},
 		{Name: "Relationships", Type: "array", Description: "The relationships this object has with its parent.", Priority: -1},
 	}
-	// objectReadyReasonJSONPath is the JSON path to get a Kubernetes object's
-	// "Ready" condition reason.
-	objectReadyReasonJSONPath = newJSONPath("reason", "{.status.conditions[?(@.type==\"Ready\")].reason}")
-	// objectReadyStatusJSONPath is the JSON path to get a Kubernetes object's
-	// "Ready" condition status.
-	objectReadyStatusJSONPath = newJSONPath("status", "{.status.conditions[?(@.type==\"Ready\")].status}")
+	// flatColumnDefinitions holds the table column definitions for the depth &
+	// parent of Kubernetes objects in the relationship tree.
+	flatColumnDefinitions = []metav1.TableColumnDefinition{
+		{Name: "Depth", Type: "integer", Description: "The depth of this object in the relationship tree."},
+		{Name: "Parent", Type: "string", Description: "The parent of this object in the relationship tree."},
+	}
+	// ownerColumnDefinition holds the table column definition for the
+	// controller of Kubernetes objects.
+	ownerColumnDefinition = metav1.TableColumnDefinition{Name: "Owner", Type: "string", Description: "The controller of this object, as declared in its owner references."}
+	// conditionAgeColumnDefinition holds the table column definition for the
+	// time since the last transition of the status condition of Kubernetes
+	// objects.
+	conditionAgeColumnDefinition = metav1.TableColumnDefinition{Name: "Condition Age", Type: "string", Description: "The time since the status condition of this object last transitioned."}
+	// objectPhaseJSONPath is the JSON path to get a Kubernetes object's phase.
+	objectPhaseJSONPath = newJSONPath("phase", "{.status.phase}")
+	// podNodeNameJSONPath is the JSON path to get the name of the node a Pod
+	// is scheduled on.
+	podNodeNameJSONPath = newJSONPath("nodeName", "{.spec.nodeName}")
+	// podIPJSONPath is the JSON path to get a Pod's IP address.
+	podIPJSONPath = newJSONPath("podIP", "{.status.podIP}")
 )
 
 // createShowGroupFn creates a function that takes in a resource's kind &
@@ -58,7 +139,7 @@ func createShowGroupFn(nodeMap graph.NodeMap, showGroup bool, maxDepth uint) fun
 	// Track every object kind in the node map & the groups that they belong to.
 	kindToGroupSetMap := map[string](map[string]struct{}){}
 	for _, node := range nodeMap {
-		if maxDepth != 0 && node.Depth > maxDepth {
+		if node.Depth > maxDepth {
 			continue
 		}
 		if _, ok := kindToGroupSetMap[node.Kind]; !ok {
@@ -88,7 +169,7 @@ func createShowNamespaceFn(nodeMap graph.NodeMap, showNamespace bool, maxDepth u
 
 	clusterScopeGKSet := map[schema.GroupKind]struct{}{}
 	for _, node := range nodeMap {
-		if maxDepth != 0 && node.Depth > maxDepth {
+		if node.Depth > maxDepth {
 			continue
 		}
 		gk := node.GroupVersionKind().GroupKind()
@@ -107,7 +188,7 @@ func createShowNamespaceFn(nodeMap graph.NodeMap, showNamespace bool, maxDepth u
 func shouldShowNamespace(nodeMap graph.NodeMap, maxDepth uint) bool {
 	nsSet := map[string]struct{}{}
 	for _, node := range nodeMap {
-		if maxDepth != 0 && node.Depth > maxDepth {
+		if node.Depth > maxDepth {
 			continue
 		}
 		ns := node.Namespace
@@ -146,17 +227,68 @@ func getNestedString(data map[string]interface{}, jp *jsonpath.JSONPath) (string
 	return str, nil
 }
 
-// getObjectReadyStatus returns the ready & status value of a Kubernetes object.
-func getObjectReadyStatus(u *unstructuredv1.Unstructured) (string, string, error) {
+// newConditionJSONPaths returns the JSON paths to get the status & reason of
+// a Kubernetes object's condition with the provided type.
+func newConditionJSONPaths(conditionType string) (*jsonpath.JSONPath, *jsonpath.JSONPath, error) {
+	if len(conditionType) == 0 || strings.ContainsAny(conditionType, "\"\\") {
+		return nil, nil, fmt.Errorf("invalid condition type \"%s\"", conditionType)
+	}
+	statusJP := jsonpath.New("status").AllowMissingKeys(true)
+	if err := statusJP.Parse(fmt.Sprintf("{.status.conditions[?(@.type==\"%s\")].status}", conditionType)); err != nil {
+		return nil, nil, err
+	}
+	reasonJP := jsonpath.New("reason").AllowMissingKeys(true)
+	if err := reasonJP.Parse(fmt.Sprintf("{.status.conditions[?(@.type==\"%s\")].reason}", conditionType)); err != nil {
+		return nil, nil, err
+	}
+	return statusJP, reasonJP, nil
+}
+
+// newConditionLastTransitionTimeJSONPath returns the JSON path to get the last
+// transition time of a Kubernetes object's condition with the provided type.
+func newConditionLastTransitionTimeJSONPath(conditionType string) (*jsonpath.JSONPath, error) {
+	if len(conditionType) == 0 || strings.ContainsAny(conditionType, "\"\\") {
+		return nil, fmt.Errorf("invalid condition type \"%s\"", conditionType)
+	}
+	lastTransitionTimeJP := jsonpath.New("lastTransitionTime").AllowMissingKeys(true)
+	if err := lastTransitionTimeJP.Parse(fmt.Sprintf("{.status.conditions[?(@.type==\"%s\")].lastTransitionTime}", conditionType)); err != nil {
+		return nil, err
+	}
+	return lastTransitionTimeJP, nil
+}
+
+// createReadyStatusFn creates a function that takes in a node & returns its
+// ready & status value. Objects without a kind-specific rule are evaluated
+// against the status condition with the provided type.
+func createReadyStatusFn(conditionType string) (func(node *graph.Node) (string, string), error) {
+	statusJP, reasonJP, err := newConditionJSONPaths(conditionType)
+	if err != nil {
+		return nil, err
+	}
+	return func(node *graph.Node) (string, string) {
+		return getNodeReadyStatus(node, statusJP, reasonJP)
+	}, nil
+}
+
+// getObjectReadyStatus returns the ready & status value of a Kubernetes object
+// based on the condition status & reason at the provided JSON paths. Objects
+// without such condition fall back to using their phase as their status.
+func getObjectReadyStatus(u *unstructuredv1.Unstructured, statusJP, reasonJP *jsonpath.JSONPath) (string, string, error) {
 	data := u.UnstructuredContent()
-	ready, err := getNestedString(data, objectReadyStatusJSONPath)
+	ready, err := getNestedString(data, statusJP)
 	if err != nil {
 		return "", "", err
 	}
-	status, err := getNestedString(data, objectReadyReasonJSONPath)
+	status, err := getNestedString(data, reasonJP)
 	if err != nil {
 		return ready, "", err
 	}
+	if len(ready) == 0 && len(status) == 0 {
+		status, err = getNestedString(data, objectPhaseJSONPath)
+		if err != nil {
+			return "", "", err
+		}
+	}
 
 	return ready, status, nil
 }
@@ -203,7 +335,6 @@ func getDaemonSetReadyStatus(u *unstructuredv1.Unstructured) (string, string, er
 // getDeploymentReadyStatus returns the ready & status value of a Deployment
 // which is based off the table cell values computed by printDeployment from
 // https://github.com/kubernetes/kubernetes/blob/v1.22.1/pkg/printers/internalversion/printers.go.
-//nolint:unparam
 func getDeploymentReadyStatus(u *unstructuredv1.Unstructured) (string, string, error) {
 	var deploy appsv1.Deployment
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &deploy)
@@ -213,8 +344,21 @@ func getDeploymentReadyStatus(u *unstructuredv1.Unstructured) (string, string, e
 	desiredReplicas := deploy.Status.Replicas
 	readyReplicas := deploy.Status.ReadyReplicas
 	ready := fmt.Sprintf("%d/%d", readyReplicas, desiredReplicas)
+	// Rollouts that stopped progressing take precedence over Deployments that
+	// are unavailable since they're unlikely to become available on their own
+	var status string
+	for _, c := range deploy.Status.Conditions {
+		switch {
+		case c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse:
+			status = c.Reason
+		case c.Type == appsv1.DeploymentAvailable && c.Status == corev1.ConditionFalse && len(status) == 0:
+			status = c.Reason
+		case c.Type == appsv1.DeploymentReplicaFailure && c.Status == corev1.ConditionTrue && len(status) == 0:
+			status = c.Reason
+		}
+	}
 
-	return ready, "", nil
+	return ready, status, nil
 }
 
 // getEventCoreReadyStatus returns the ready & status value of a Event.
@@ -253,6 +397,93 @@ func getEventReadyStatus(u *unstructuredv1.Unstructured) (string, string, error)
 	return "", status, nil
 }
 
+// getJobReadyStatus returns the ready & status value of a Job. The ready value
+// is based off the "Completions" table cell value computed by printJob from
+// https://github.com/kubernetes/kubernetes/blob/v1.22.1/pkg/printers/internalversion/printers.go.
+func getJobReadyStatus(u *unstructuredv1.Unstructured) (string, string, error) {
+	var job batchv1.Job
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &job)
+	if err != nil {
+		return "", "", err
+	}
+	var ready, status string
+	switch {
+	case job.Spec.Completions != nil:
+		ready = fmt.Sprintf("%d/%d", job.Status.Succeeded, *job.Spec.Completions)
+	case job.Spec.Parallelism != nil && *job.Spec.Parallelism > 1:
+		ready = fmt.Sprintf("%d/1 of %d", job.Status.Succeeded, *job.Spec.Parallelism)
+	default:
+		ready = fmt.Sprintf("%d/1", job.Status.Succeeded)
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			status = string(batchv1.JobComplete)
+		case batchv1.JobFailed:
+			status = string(batchv1.JobFailed)
+			if len(c.Reason) > 0 {
+				status = fmt.Sprintf("%s: %s", batchv1.JobFailed, c.Reason)
+			}
+		case batchv1.JobSuspended:
+			status = string(batchv1.JobSuspended)
+		}
+	}
+	if len(status) == 0 && job.Status.Active > 0 {
+		status = "Running"
+	}
+
+	return ready, status, nil
+}
+
+// getClusterNodeReadyStatus returns the ready & status value of a Node, which
+// is based off its "Ready" condition & whether it's cordoned similar to the
+// "Status" table cell value computed by printNode from
+// https://github.com/kubernetes/kubernetes/blob/v1.22.1/pkg/printers/internalversion/printers.go.
+func getClusterNodeReadyStatus(u *unstructuredv1.Unstructured) (string, string, error) {
+	var node corev1.Node
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &node)
+	if err != nil {
+		return "", "", err
+	}
+	var ready string
+	var statuses []string
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			ready = string(c.Status)
+			if len(c.Reason) > 0 {
+				statuses = append(statuses, c.Reason)
+			}
+		}
+	}
+	if node.Spec.Unschedulable {
+		statuses = append(statuses, "SchedulingDisabled")
+	}
+
+	return ready, strings.Join(statuses, ","), nil
+}
+
+// getPersistentVolumeClaimReadyStatus returns the ready & status value of a
+// PersistentVolumeClaim, which is ready once it's bound to a volume.
+func getPersistentVolumeClaimReadyStatus(u *unstructuredv1.Unstructured) (string, string, error) {
+	var pvc corev1.PersistentVolumeClaim
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &pvc)
+	if err != nil {
+		return "", "", err
+	}
+	var ready string
+	switch pvc.Status.Phase {
+	case corev1.ClaimBound:
+		ready = "True"
+	case corev1.ClaimPending, corev1.ClaimLost:
+		ready = "False"
+	}
+
+	return ready, string(pvc.Status.Phase), nil
+}
+
 // getPodReadyStatus returns the ready & status value of a Pod which is based
 // off the table cell values computed by printPod from
 // https://github.com/kubernetes/kubernetes/blob/v1.22.1/pkg/printers/internalversion/printers.go.
@@ -334,8 +565,56 @@ func getPodReadyStatus(u *unstructuredv1.Unstructured) (string, string, error) {
 	return ready, reason, nil
 }
 
+// getPodRestarts returns the restart count of a Pod which is based off the
+// table cell value computed by printPod from
+// https://github.com/kubernetes/kubernetes/blob/v1.22.1/pkg/printers/internalversion/printers.go.
+func getPodRestarts(u *unstructuredv1.Unstructured) (string, error) {
+	var pod corev1.Pod
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &pod)
+	if err != nil {
+		return "", err
+	}
+	restarts := 0
+	lastRestartDate := metav1.NewTime(time.Time{})
+	initializing := false
+	for i := range pod.Status.InitContainerStatuses {
+		container := pod.Status.InitContainerStatuses[i]
+		restarts += int(container.RestartCount)
+		if container.LastTerminationState.Terminated != nil {
+			terminatedDate := container.LastTerminationState.Terminated.FinishedAt
+			if lastRestartDate.Before(&terminatedDate) {
+				lastRestartDate = terminatedDate
+			}
+		}
+		if container.State.Terminated != nil && container.State.Terminated.ExitCode == 0 {
+			continue
+		}
+		initializing = true
+		break
+	}
+	if !initializing {
+		restarts = 0
+		for i := range pod.Status.ContainerStatuses {
+			container := pod.Status.ContainerStatuses[i]
+			restarts += int(container.RestartCount)
+			if container.LastTerminationState.Terminated != nil {
+				terminatedDate := container.LastTerminationState.Terminated.FinishedAt
+				if lastRestartDate.Before(&terminatedDate) {
+					lastRestartDate = terminatedDate
+				}
+			}
+		}
+	}
+	if restarts != 0 && !lastRestartDate.IsZero() {
+		return fmt.Sprintf("%d (%s ago)", restarts, translateTimestampSince(lastRestartDate, ageFormatHuman)), nil
+	}
+
+	return fmt.Sprintf("%d", restarts), nil
+}
+
 // getPodDisruptionBudgetReadyStatus returns the ready & status value of a
-// PodDisruptionBudget.
+// PodDisruptionBudget. The ready value is the number of healthy pods covered by
+// the PodDisruptionBudget out of the desired number of healthy pods.
 //nolint:unparam
 func getPodDisruptionBudgetReadyStatus(u *unstructuredv1.Unstructured) (string, string, error) {
 	var pdb policyv1.PodDisruptionBudget
@@ -343,6 +622,7 @@ func getPodDisruptionBudgetReadyStatus(u *unstructuredv1.Unstructured) (string,
 	if err != nil {
 		return "", "", err
 	}
+	ready := fmt.Sprintf("%d/%d", pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy)
 	var status string
 	for _, condition := range pdb.Status.Conditions {
 		if condition.ObservedGeneration == pdb.Generation {
@@ -352,7 +632,7 @@ func getPodDisruptionBudgetReadyStatus(u *unstructuredv1.Unstructured) (string,
 		}
 	}
 
-	return "", status, nil
+	return ready, status, nil
 }
 
 // getReplicaSetReadyStatus returns the ready & status value of a ReplicaSet
@@ -435,23 +715,19 @@ func getVolumeAttachmentReadyStatus(u *unstructuredv1.Unstructured) (string, str
 	return ready, status, nil
 }
 
-// nodeToTableRow converts the provided node into a table row.
-//nolint:funlen,gocognit,goconst
-func nodeToTableRow(node *graph.Node, rset graph.RelationshipSet, namePrefix string, showGroupFn func(kind string) bool) metav1.TableRow {
-	var name, ready, status, age string
-	var relationships interface{}
-
-	switch {
-	case len(node.Kind) == 0:
-		name = node.Name
-	case len(node.Group) > 0 && showGroupFn(node.Kind):
-		name = fmt.Sprintf("%s%s.%s/%s", namePrefix, node.Kind, node.Group, node.Name)
-	default:
-		name = fmt.Sprintf("%s%s/%s", namePrefix, node.Kind, node.Name)
-	}
+// getNodeReadyStatus returns the ready & status value of the provided node.
+//nolint:gocyclo
+func getNodeReadyStatus(node *graph.Node, statusJP, reasonJP *jsonpath.JSONPath) (string, string) {
+	var ready, status string
 	switch {
+	case node.NotFound:
+		status = statusNotFound
 	case node.Group == corev1.GroupName && node.Kind == "Event":
 		ready, status, _ = getEventCoreReadyStatus(node.Unstructured)
+	case node.Group == corev1.GroupName && node.Kind == "Node":
+		ready, status, _ = getClusterNodeReadyStatus(node.Unstructured)
+	case node.Group == corev1.GroupName && node.Kind == "PersistentVolumeClaim":
+		ready, status, _ = getPersistentVolumeClaimReadyStatus(node.Unstructured)
 	case node.Group == corev1.GroupName && node.Kind == "Pod":
 		ready, status, _ = getPodReadyStatus(node.Unstructured)
 	case node.Group == corev1.GroupName && node.Kind == "ReplicationController":
@@ -464,6 +740,8 @@ func nodeToTableRow(node *graph.Node, rset graph.RelationshipSet, namePrefix str
 		ready, status, _ = getReplicaSetReadyStatus(node.Unstructured)
 	case node.Group == appsv1.GroupName && node.Kind == "StatefulSet":
 		ready, status, _ = getStatefulSetReadyStatus(node.Unstructured)
+	case node.Group == batchv1.GroupName && node.Kind == "Job":
+		ready, status, _ = getJobReadyStatus(node.Unstructured)
 	case node.Group == policyv1.GroupName && node.Kind == "PodDisruptionBudget":
 		ready, status, _ = getPodDisruptionBudgetReadyStatus(node.Unstructured)
 	case node.Group == apiregistrationv1.GroupName && node.Kind == "APIService":
@@ -473,64 +751,566 @@ func nodeToTableRow(node *graph.Node, rset graph.RelationshipSet, namePrefix str
 	case node.Group == storagev1.GroupName && node.Kind == "VolumeAttachment":
 		ready, status, _ = getVolumeAttachmentReadyStatus(node.Unstructured)
 	case node.Unstructured != nil:
-		ready, status, _ = getObjectReadyStatus(node.Unstructured)
+		ready, status, _ = getObjectReadyStatus(node.Unstructured, statusJP, reasonJP)
 	}
+
+	return ready, status
+}
+
+// getNodeRestarts returns the restart count of the provided node if it is a
+// Pod.
+func getNodeRestarts(node *graph.Node) string {
+	if node.Unstructured == nil || node.Group != corev1.GroupName || node.Kind != "Pod" {
+		return cellNotApplicable
+	}
+	restarts, err := getPodRestarts(node.Unstructured)
+	if err != nil {
+		return cellUnknown
+	}
+	return restarts
+}
+
+// getPodNodeIP returns the node name & IP address of the provided node if
+// it is a Pod.
+func getPodNodeIP(node *graph.Node) (string, string) {
+	if node.Unstructured == nil || node.Group != corev1.GroupName || node.Kind != "Pod" {
+		return cellNone, cellNone
+	}
+	data := node.UnstructuredContent()
+	nodeName, _ := getNestedString(data, podNodeNameJSONPath)
+	if len(nodeName) == 0 {
+		nodeName = cellNone
+	}
+	podIP, _ := getNestedString(data, podIPJSONPath)
+	if len(podIP) == 0 {
+		podIP = cellNone
+	}
+	return nodeName, podIP
+}
+
+// getDaemonSetMissingPods returns the number of nodes that should run the Pod
+// of the provided node if it is a DaemonSet, but aren't running it.
+func getDaemonSetMissingPods(node *graph.Node) int64 {
+	if node.Unstructured == nil || node.Group != appsv1.GroupName || node.Kind != "DaemonSet" {
+		return 0
+	}
+	desired, _, _ := unstructuredv1.NestedInt64(node.UnstructuredContent(), "status", "desiredNumberScheduled")
+	current, _, _ := unstructuredv1.NestedInt64(node.UnstructuredContent(), "status", "currentNumberScheduled")
+	if current >= desired {
+		return 0
+	}
+	return desired - current
+}
+
+// nodeToTableName returns the name of the provided node in a table.
+func nodeToTableName(node *graph.Node, showGroupFn func(kind string) bool, showAPIVersion bool) string {
+	switch {
+	case len(node.Kind) == 0:
+		return node.Name
+	case showAPIVersion:
+		gv := schema.GroupVersion{Group: node.Group, Version: node.Version}
+		return fmt.Sprintf("%s/%s/%s", gv.String(), node.Kind, node.Name)
+	case len(node.Group) > 0 && showGroupFn(node.Kind):
+		return fmt.Sprintf("%s.%s/%s", node.Kind, node.Group, node.Name)
+	default:
+		return fmt.Sprintf("%s/%s", node.Kind, node.Name)
+	}
+}
+
+// nodeToTableRow converts the provided node into a table row.
+//nolint:funlen,gocognit,goconst
+func nodeToTableRow(node *graph.Node, rset graph.RelationshipSet, namePrefix string, opts tableOptions) metav1.TableRow {
+	var age string
+	var relationships interface{}
+
+	nameNode := node
+	if opts.maxNameWidth > 0 {
+		truncated := *node
+		truncated.Name = truncateName(node.Name, opts.maxNameWidth, opts.glyphs.ellipsis)
+		nameNode = &truncated
+	}
+	name := nodeToTableName(nameNode, opts.showGroupFn, opts.showAPIVersion)
+	// Objects kept only to connect objects matching the filters are enclosed
+	// in parentheses
+	if node.PassThrough {
+		name = fmt.Sprintf("(%s)", name)
+	}
+	// Objects in other clusters (eg. the Nodes of Cluster API workload
+	// clusters) are tagged with their cluster
+	if len(node.Cluster) > 0 {
+		name = fmt.Sprintf("%s [cluster: %s]", name, node.Cluster)
+	}
+	if len(node.Kind) > 0 {
+		name = namePrefix + name
+	}
+	ready, status := opts.readyStatusFn(node)
 	if len(ready) == 0 {
 		ready = cellNotApplicable
 	}
 	if node.Unstructured != nil {
-		age = translateTimestampSince(node.GetCreationTimestamp())
+		age = translateTimestampSince(node.GetCreationTimestamp(), opts.ageFormat)
+	}
+	restarts := getNodeRestarts(node)
+	nodeName, podIP := getPodNodeIP(node)
+	// DaemonSets are expected to run a Pod on each of their nodes, highlight
+	// the number of nodes missing their Pod instead
+	if missing := getDaemonSetMissingPods(node); missing > 0 {
+		nodeName = fmt.Sprintf("(%d missing)", missing)
 	}
 	relationships = []string{}
 	if rset != nil {
 		relationships = rset.List()
 	}
 
+	// Show cluster-scoped objects as having no namespace when the table
+	// includes a namespace column
+	obj := node.DeepCopy()
+	if len(obj.GetNamespace()) == 0 {
+		obj.SetNamespace(cellNone)
+	}
+
 	return metav1.TableRow{
-		Object: runtime.RawExtension{Object: node.DeepCopyObject()},
+		Object: runtime.RawExtension{Object: obj},
 		Cells: []interface{}{
 			name,
 			ready,
 			status,
 			age,
+			restarts,
+			nodeName,
+			podIP,
 			relationships,
 		},
 	}
 }
 
-// nodeMapToTable converts the provided node & either its dependencies or
-// dependents into table rows.
-func nodeMapToTable(
-	nodeMap graph.NodeMap,
-	root *graph.Node,
-	maxDepth uint,
-	depsIsDependencies bool,
-	showGroupFn func(kind string) bool) (*metav1.Table, error) {
-	// Sorts the list of UIDs based on the underlying object in following order:
-	// Namespace, Kind, Group, Name
-	sortDepsFn := func(d map[types.UID]graph.RelationshipSet) []types.UID {
-		nodes, ix := make(graph.NodeList, len(d)), 0
-		for uid := range d {
-			nodes[ix] = nodeMap[uid]
-			ix++
+// truncatedDepsToTableRow returns a table row indicating the number of
+// dependencies or dependents omitted from the table due to the maximum depth.
+func truncatedDepsToTableRow(count int, namePrefix string) metav1.TableRow {
+	return nameOnlyTableRow(fmt.Sprintf("%s... (%d more)", namePrefix, count))
+}
+
+// hiddenCompletedPodsToTableRow returns a table row indicating the number of
+// completed Pods hidden from the dependencies or dependents of an object.
+func hiddenCompletedPodsToTableRow(count int, namePrefix string) metav1.TableRow {
+	return nameOnlyTableRow(fmt.Sprintf("%s(+%d completed hidden)", namePrefix, count))
+}
+
+// addOwnerColumn adds a column after the "Age" column of the provided table, or
+// at the end if there isn't any, holding the controller of each object, as
+// declared in its owner references. Rows that aren't backed by any object are
+// left empty.
+func addOwnerColumn(t *metav1.Table) {
+	ix := columnIndex(t.ColumnDefinitions, "Age") + 1
+	if ix == 0 {
+		ix = len(t.ColumnDefinitions)
+	}
+	t.ColumnDefinitions = append(t.ColumnDefinitions[:ix:ix], append([]metav1.TableColumnDefinition{ownerColumnDefinition}, t.ColumnDefinitions[ix:]...)...)
+	for i, row := range t.Rows {
+		owner := ""
+		if obj, ok := row.Object.Object.(metav1.Object); ok {
+			owner = cellNone
+			if ref := metav1.GetControllerOfNoCopy(obj); ref != nil {
+				owner = fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+			}
+		}
+		cells := append([]interface{}{}, row.Cells[:ix]...)
+		cells = append(cells, owner)
+		t.Rows[i].Cells = append(cells, row.Cells[ix:]...)
+	}
+}
+
+// addLabelColumns adds a column at the end of the provided table for each of
+// the provided label keys, holding the value of the label of each object. Like
+// kubectl, columns are named after the last segment of their label key.
+func addLabelColumns(t *metav1.Table, labelKeys []string) {
+	for _, key := range labelKeys {
+		parts := strings.Split(key, "/")
+		t.ColumnDefinitions = append(t.ColumnDefinitions, metav1.TableColumnDefinition{
+			Name:        parts[len(parts)-1],
+			Type:        "string",
+			Description: fmt.Sprintf("The value of the %s label of this object.", key),
+		})
+	}
+	for i, row := range t.Rows {
+		cells := append([]interface{}{}, row.Cells...)
+		obj, ok := row.Object.Object.(metav1.Object)
+		for _, key := range labelKeys {
+			value := ""
+			if ok {
+				value = obj.GetLabels()[key]
+			}
+			cells = append(cells, value)
 		}
-		sort.Sort(nodes)
-		sortedUIDs := make([]types.UID, len(d))
-		for ix, node := range nodes {
-			sortedUIDs[ix] = node.UID
+		t.Rows[i].Cells = cells
+	}
+}
+
+// addConditionAgeColumn adds a column after the "Age" column of the provided
+// table, or at the end if there isn't any, holding the time since the status
+// condition with the provided type of each object last transitioned, in the
+// provided age format. Rows that aren't backed by any object are left empty.
+func addConditionAgeColumn(t *metav1.Table, conditionType, ageFormat string) error {
+	lastTransitionTimeJP, err := newConditionLastTransitionTimeJSONPath(conditionType)
+	if err != nil {
+		return err
+	}
+	ix := columnIndex(t.ColumnDefinitions, "Age") + 1
+	if ix == 0 {
+		ix = len(t.ColumnDefinitions)
+	}
+	t.ColumnDefinitions = append(t.ColumnDefinitions[:ix:ix], append([]metav1.TableColumnDefinition{conditionAgeColumnDefinition}, t.ColumnDefinitions[ix:]...)...)
+	for i, row := range t.Rows {
+		age := ""
+		if obj, ok := row.Object.Object.(*unstructuredv1.Unstructured); ok {
+			age = cellNone
+			if s, _ := getNestedString(obj.UnstructuredContent(), lastTransitionTimeJP); len(s) > 0 {
+				age = cellUnknown
+				if ts, err := time.Parse(time.RFC3339, s); err == nil {
+					age = translateTimestampSince(metav1.NewTime(ts), ageFormat)
+				}
+			}
 		}
-		return sortedUIDs
+		cells := append([]interface{}{}, row.Cells[:ix]...)
+		cells = append(cells, age)
+		t.Rows[i].Cells = append(cells, row.Cells[ix:]...)
 	}
+	return nil
+}
 
+// addConditionColumns adds a column after the "Status" column of the provided
+// table, or at the end if there isn't any, for each of the provided status
+// condition types, holding the status of the condition of each object. Rows
+// that aren't backed by any object are left empty.
+func addConditionColumns(t *metav1.Table, conditionTypes []string) error {
+	ix := columnIndex(t.ColumnDefinitions, "Status") + 1
+	if ix == 0 {
+		ix = len(t.ColumnDefinitions)
+	}
+	columnDefinitions := make([]metav1.TableColumnDefinition, 0, len(conditionTypes))
+	statusJPs := make([]*jsonpath.JSONPath, 0, len(conditionTypes))
+	for _, ct := range conditionTypes {
+		statusJP, _, err := newConditionJSONPaths(ct)
+		if err != nil {
+			return err
+		}
+		columnDefinitions = append(columnDefinitions, metav1.TableColumnDefinition{
+			Name:        ct,
+			Type:        "string",
+			Description: fmt.Sprintf("The status of the %s condition of this object.", ct),
+		})
+		statusJPs = append(statusJPs, statusJP)
+	}
+	t.ColumnDefinitions = append(t.ColumnDefinitions[:ix:ix], append(columnDefinitions, t.ColumnDefinitions[ix:]...)...)
+	for i, row := range t.Rows {
+		cells := append([]interface{}{}, row.Cells[:ix]...)
+		obj, ok := row.Object.Object.(*unstructuredv1.Unstructured)
+		for _, statusJP := range statusJPs {
+			status := ""
+			if ok {
+				status, _ = getNestedString(obj.UnstructuredContent(), statusJP)
+				if len(status) == 0 {
+					status = cellNone
+				}
+			}
+			cells = append(cells, status)
+		}
+		t.Rows[i].Cells = append(cells, row.Cells[ix:]...)
+	}
+	return nil
+}
+
+// columnIndex returns the index of the column with the provided name in the
+// provided column definitions, or -1 if there isn't any.
+func columnIndex(columnDefinitions []metav1.TableColumnDefinition, name string) int {
+	for ix, c := range columnDefinitions {
+		if c.Name == name {
+			return ix
+		}
+	}
+	return -1
+}
+
+// showNodeColumn shows the "Node" column of the provided table in the default
+// output format, which is otherwise only shown in the wide output format.
+func showNodeColumn(t *metav1.Table) {
+	columnDefinitions := append([]metav1.TableColumnDefinition{}, t.ColumnDefinitions...)
+	for i := range columnDefinitions {
+		if columnDefinitions[i].Name == "Node" {
+			columnDefinitions[i].Priority = 0
+		}
+	}
+	t.ColumnDefinitions = columnDefinitions
+}
+
+// reverseTableRows reverses the order of the rows of the provided table so that
+// the relationship tree reads bottom-up, with the root objects listed last.
+// The tree prefix of each row is redrawn so that its branches still connect
+// to the object listed below them.
+func reverseTableRows(t *metav1.Table, glyphs treeGlyphs) {
+	rows := make([]metav1.TableRow, 0, len(t.Rows))
+	for i := len(t.Rows) - 1; i >= 0; i-- {
+		row := t.Rows[i]
+		cells := append([]interface{}{}, row.Cells...)
+		if name, ok := cells[0].(string); ok {
+			cells[0] = reverseTreePrefix(name, glyphs)
+		}
+		row.Cells = cells
+		rows = append(rows, row)
+	}
+	t.Rows = rows
+}
+
+// reverseTreePrefix redraws the tree prefix of the provided name cell for a
+// bottom-up relationship tree. Objects that are expanded elsewhere in the tree
+// are now listed below their expanded occurrence.
+func reverseTreePrefix(name string, glyphs treeGlyphs) string {
+	var prefix strings.Builder
+	rest := name
+	for {
+		switch {
+		case strings.HasPrefix(rest, glyphs.vertical):
+			prefix.WriteString(glyphs.vertical)
+			rest = rest[len(glyphs.vertical):]
+			continue
+		case strings.HasPrefix(rest, glyphs.indent):
+			prefix.WriteString(glyphs.indent)
+			rest = rest[len(glyphs.indent):]
+			continue
+		case strings.HasPrefix(rest, glyphs.branch):
+			prefix.WriteString(glyphs.branch)
+			rest = rest[len(glyphs.branch):]
+		case strings.HasPrefix(rest, glyphs.lastBranch):
+			prefix.WriteString(glyphs.reversedLastBranch)
+			rest = rest[len(glyphs.lastBranch):]
+		}
+		break
+	}
+	if strings.HasSuffix(rest, " "+cellSeeAbove) {
+		rest = strings.TrimSuffix(rest, cellSeeAbove) + cellSeeBelow
+	}
+	return prefix.String() + rest
+}
+
+// truncateName truncates the provided name to the provided width, replacing
+// the end of the name with the provided ellipsis.
+func truncateName(name string, maxWidth uint, ellipsis string) string {
+	runes := []rune(name)
+	if uint(len(runes)) <= maxWidth {
+		return name
+	}
+	// Names are cut without any ellipsis if the width can't fit one
+	keep := int(maxWidth) - len([]rune(ellipsis))
+	if keep < 1 {
+		return string(runes[:maxWidth])
+	}
+	return string(runes[:keep]) + ellipsis
+}
+
+// maxLineWidth returns the width of the widest line of the provided output.
+func maxLineWidth(output string) int {
+	result := 0
+	for _, line := range strings.Split(output, "\n") {
+		if n := utf8.RuneCountInString(line); n > result {
+			result = n
+		}
+	}
+	return result
+}
+
+// maxColumnWidth returns the width of the widest string cell in the provided
+// column of the table, or 0 if the column doesn't exist.
+func maxColumnWidth(t *metav1.Table, ix int) uint {
+	var result uint
+	if ix < 0 {
+		return result
+	}
+	for _, row := range t.Rows {
+		if cell, ok := row.Cells[ix].(string); ok {
+			if n := uint(utf8.RuneCountInString(cell)); n > result {
+				result = n
+			}
+		}
+	}
+	return result
+}
+
+// maxNameLength returns the length of the longest name of the objects in the
+// provided table.
+func maxNameLength(t *metav1.Table) uint {
+	var result uint
+	for _, row := range t.Rows {
+		if obj, ok := row.Object.Object.(*unstructuredv1.Unstructured); ok {
+			if n := uint(utf8.RuneCountInString(obj.GetName())); n > result {
+				result = n
+			}
+		}
+	}
+	return result
+}
+
+// truncateColumn truncates the string cells in the provided column of the table
+// to the provided width, replacing the end of each cell with the provided
+// ellipsis.
+func truncateColumn(t *metav1.Table, ix int, maxWidth uint, ellipsis string) {
+	if ix < 0 {
+		return
+	}
+	for i, row := range t.Rows {
+		if cell, ok := row.Cells[ix].(string); ok {
+			t.Rows[i].Cells[ix] = truncateName(cell, maxWidth, ellipsis)
+		}
+	}
+}
+
+// shrinkWidth returns the provided width reduced by the provided amount,
+// without going below minTruncatedWidth.
+func shrinkWidth(width, by uint) uint {
+	if width < minTruncatedWidth+by {
+		return minTruncatedWidth
+	}
+	return width - by
+}
+
+// kindGroup holds the UIDs of the dependencies or dependents of an object that
+// are of the same GroupKind.
+type kindGroup struct {
+	gk   schema.GroupKind
+	uids []types.UID
+}
+
+// groupDepsByKind buckets the provided UIDs by the GroupKind of their objects,
+// keeping the order of the UIDs within each group. Groups are ordered by the
+// first UID of each group.
+func groupDepsByKind(nodeMap graph.NodeMap, uids []types.UID) []kindGroup {
+	var groups []kindGroup
+	groupIxs := map[schema.GroupKind]int{}
+	for _, uid := range uids {
+		var gk schema.GroupKind
+		if node, ok := nodeMap[uid]; ok {
+			gk = schema.GroupKind{Group: node.Group, Kind: node.Kind}
+		}
+		ix, ok := groupIxs[gk]
+		if !ok {
+			ix = len(groups)
+			groupIxs[gk] = ix
+			groups = append(groups, kindGroup{gk: gk})
+		}
+		groups[ix].uids = append(groups[ix].uids, uid)
+	}
+	return groups
+}
+
+// kindGroupToTableRow returns a table row heading the provided group of
+// dependencies or dependents, along with the number of objects in the group.
+func kindGroupToTableRow(group kindGroup, namePrefix string, showGroupFn func(kind string) bool) metav1.TableRow {
+	kind := group.gk.Kind
+	if len(group.gk.Group) > 0 && showGroupFn(kind) {
+		kind = fmt.Sprintf("%s.%s", group.gk.Kind, group.gk.Group)
+	}
+	return nameOnlyTableRow(fmt.Sprintf("%s[%s] (%d)", namePrefix, kind, len(group.uids)))
+}
+
+// nameOnlyTableRow returns a table row that isn't backed by any object, with
+// only its name cell set.
+func nameOnlyTableRow(name string) metav1.TableRow {
+	return metav1.TableRow{
+		Cells: []interface{}{
+			name,
+			"",
+			"",
+			"",
+			"",
+			"",
+			"",
+			[]string{},
+		},
+	}
+}
+
+// nodeToBreadcrumb returns the chain of controllers of the provided node, as
+// declared by their controller references, from the top-most controller down
+// to the node itself, which is marked as the root of the relationship tree.
+// Only controllers included in the provided nodes are part of the chain.
+func nodeToBreadcrumb(nodeMap graph.NodeMap, node *graph.Node, glyphs treeGlyphs, showGroupFn func(kind string) bool, showAPIVersion bool) string {
+	names := []string{glyphs.root + nodeToTableName(node, showGroupFn, showAPIVersion)}
+	visited := map[types.UID]struct{}{node.UID: {}}
+	for {
+		var controller *graph.Node
+		for uid, rset := range node.Dependencies {
+			if _, ok := rset[graph.RelationshipControllerRef]; !ok {
+				continue
+			}
+			if n, ok := nodeMap[uid]; ok {
+				controller = n
+				break
+			}
+		}
+		if controller == nil {
+			break
+		}
+		// Guard against possible cycles
+		if _, ok := visited[controller.UID]; ok {
+			break
+		}
+		visited[controller.UID] = struct{}{}
+		names = append([]string{nodeToTableName(controller, showGroupFn, showAPIVersion)}, names...)
+		node = controller
+	}
+	return strings.Join(names, glyphs.separator)
+}
+
+// tableOptions configures how relationship trees are converted into table
+// rows.
+type tableOptions struct {
+	maxDepth           uint
+	depsIsDependencies bool
+	sortDepsFn         func(d map[types.UID]graph.RelationshipSet) []types.UID
+	showGroupFn        func(kind string) bool
+	showAPIVersion     bool
+	// maxNameWidth truncates the names of objects to the provided width with
+	// the ellipsis of glyphs if non-zero
+	maxNameWidth uint
+	// glyphs (except for the ellipsis), groupByKind & collapse are ignored by
+	// nodeMapToFlatTable
+	glyphs        treeGlyphs
+	groupByKind   bool
+	collapse      bool
+	ageFormat     string
+	readyStatusFn func(node *graph.Node) (string, string)
+}
+
+// nodeMapToTable converts the provided nodes & either their dependencies or
+// dependents into table rows.
+func nodeMapToTable(nodeMap graph.NodeMap, roots []*graph.Node, opts tableOptions) (*metav1.Table, error) {
 	var rows []metav1.TableRow
-	row := nodeToTableRow(root, nil, "", showGroupFn)
 	uidSet := map[types.UID]struct{}{}
-	depRows, err := nodeDepsToTableRows(nodeMap, uidSet, root, "", 1, maxDepth, depsIsDependencies, sortDepsFn, showGroupFn)
-	if err != nil {
-		return nil, err
+	pathSet := map[types.UID]struct{}{}
+	for _, root := range roots {
+		// Mark the requested objects, including those that aren't backed by
+		// any API resource (e.g. Helm releases)
+		row := nodeToTableRow(root, nil, "", opts)
+		if name, ok := row.Cells[0].(string); ok {
+			row.Cells[0] = opts.glyphs.root + name
+		}
+		rows = append(rows, row)
+		// Indicate that the root object's dependencies or dependents were
+		// truncated
+		if opts.maxDepth == 0 {
+			if _, ok := uidSet[root.UID]; !ok {
+				if n := len(root.GetDeps(opts.depsIsDependencies)); n > 0 {
+					rows = append(rows, truncatedDepsToTableRow(n, opts.glyphs.lastBranch))
+				}
+			}
+			uidSet[root.UID] = struct{}{}
+			continue
+		}
+		depRows, err := nodeDepsToTableRows(nodeMap, uidSet, pathSet, root, "", 1, opts)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, depRows...)
 	}
-	rows = append(rows, row)
-	rows = append(rows, depRows...)
 	table := metav1.Table{
 		ColumnDefinitions: objectColumnDefinitions,
 		Rows:              rows,
@@ -539,18 +1319,75 @@ func nodeMapToTable(
 	return &table, nil
 }
 
+// nodeMapToFlatTable converts the provided nodes & either their dependencies or
+// dependents into table rows without any tree prefixes, listing the depth &
+// parent of each object in the relationship tree in columns after the "Age"
+// column instead. Objects are listed once, in the same order as in the
+// relationship tree.
+func nodeMapToFlatTable(nodeMap graph.NodeMap, roots []*graph.Node, opts tableOptions) (*metav1.Table, error) {
+	ix := columnIndex(objectColumnDefinitions, "Age") + 1
+	columnDefinitions := append([]metav1.TableColumnDefinition{}, objectColumnDefinitions[:ix]...)
+	columnDefinitions = append(columnDefinitions, flatColumnDefinitions...)
+	columnDefinitions = append(columnDefinitions, objectColumnDefinitions[ix:]...)
+
+	var rows []metav1.TableRow
+	uidSet := map[types.UID]struct{}{}
+	var walkFn func(node, parent *graph.Node, rset graph.RelationshipSet, depth uint) error
+	walkFn = func(node, parent *graph.Node, rset graph.RelationshipSet, depth uint) error {
+		// Guard against possible cycles
+		if _, ok := uidSet[node.UID]; ok {
+			return nil
+		}
+		uidSet[node.UID] = struct{}{}
+
+		parentName := cellNone
+		if parent != nil {
+			parentName = nodeToTableName(parent, opts.showGroupFn, opts.showAPIVersion)
+		}
+		row := nodeToTableRow(node, rset, "", opts)
+		cells := append([]interface{}{}, row.Cells[:ix]...)
+		cells = append(cells, int64(depth), parentName)
+		row.Cells = append(cells, row.Cells[ix:]...)
+		rows = append(rows, row)
+		if depth >= opts.maxDepth {
+			return nil
+		}
+
+		deps := node.GetDeps(opts.depsIsDependencies)
+		for _, childUID := range opts.sortDepsFn(deps) {
+			child, ok := nodeMap[childUID]
+			if !ok {
+				return fmt.Errorf("dependent object (uid: %s) not found in list of fetched objects", childUID)
+			}
+			if err := walkFn(child, node, deps[childUID], depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, root := range roots {
+		if err := walkFn(root, nil, nil, 0); err != nil {
+			return nil, err
+		}
+	}
+	table := metav1.Table{
+		ColumnDefinitions: columnDefinitions,
+		Rows:              rows,
+	}
+
+	return &table, nil
+}
+
 // nodeDepsToTableRows converts either the dependencies or dependents of the
 // provided node into table rows.
 func nodeDepsToTableRows(
 	nodeMap graph.NodeMap,
 	uidSet map[types.UID]struct{},
+	pathSet map[types.UID]struct{},
 	node *graph.Node,
 	prefix string,
 	depth uint,
-	maxDepth uint,
-	depsIsDependencies bool,
-	sortDepsFn func(d map[types.UID]graph.RelationshipSet) []types.UID,
-	showGroupFn func(kind string) bool) ([]metav1.TableRow, error) {
+	opts tableOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(nodeMap))
 
 	// Guard against possible cycles
@@ -558,46 +1395,292 @@ func nodeDepsToTableRows(
 		return rows, nil
 	}
 	uidSet[node.UID] = struct{}{}
+	pathSet[node.UID] = struct{}{}
+	defer delete(pathSet, node.UID)
 
-	deps := node.GetDeps(depsIsDependencies)
-	depUIDs := sortDepsFn(deps)
-	lastIx := len(depUIDs) - 1
-	for ix, childUID := range depUIDs {
-		var childPrefix, depPrefix string
-		if ix != lastIx {
-			childPrefix, depPrefix = prefix+"├── ", prefix+"│   "
-		} else {
-			childPrefix, depPrefix = prefix+"└── ", prefix+"    "
-		}
-
-		child, ok := nodeMap[childUID]
-		if !ok {
-			return nil, fmt.Errorf("dependent object (uid: %s) not found in list of fetched objects", childUID)
+	deps := node.GetDeps(opts.depsIsDependencies)
+	depUIDs := opts.sortDepsFn(deps)
+	groups := []kindGroup{{uids: depUIDs}}
+	if opts.groupByKind {
+		groups = groupDepsByKind(nodeMap, depUIDs)
+	}
+	// The row indicating hidden completed Pods is placed after every dependency
+	// or dependent
+	lastGroupIx := len(groups) - 1
+	if node.HiddenCompletedPods > 0 {
+		lastGroupIx = len(groups)
+	}
+	for groupIx, group := range groups {
+		// Pods sharing the same ready & status value are summarized in the row
+		// of the first of them, while the header of the group still counts
+		// every object of its kind
+		uids := group.uids
+		var collapsed map[types.UID][]types.UID
+		if opts.collapse {
+			uids, collapsed = collapsePods(nodeMap, uidSet, uids, opts.depsIsDependencies, opts.readyStatusFn)
 		}
-		rset, ok := deps[childUID]
-		if !ok {
-			return nil, fmt.Errorf("dependent object (uid: %s) not found", childUID)
+		groupPrefix, lastIx := prefix, len(uids)-1
+		if opts.groupByKind {
+			headerPrefix := prefix + opts.glyphs.branch
+			groupPrefix = prefix + opts.glyphs.vertical
+			if groupIx == lastGroupIx {
+				headerPrefix, groupPrefix = prefix+opts.glyphs.lastBranch, prefix+opts.glyphs.indent
+			}
+			rows = append(rows, kindGroupToTableRow(group, headerPrefix, opts.showGroupFn))
+		} else if node.HiddenCompletedPods > 0 {
+			lastIx = len(uids)
 		}
-		row := nodeToTableRow(child, rset, childPrefix, showGroupFn)
-		rows = append(rows, row)
-		if maxDepth == 0 || depth < maxDepth {
-			depRows, err := nodeDepsToTableRows(nodeMap, uidSet, child, depPrefix, depth+1, maxDepth, depsIsDependencies, sortDepsFn, showGroupFn)
-			if err != nil {
-				return nil, err
+		for ix, childUID := range uids {
+			var childPrefix, depPrefix string
+			if ix != lastIx {
+				childPrefix, depPrefix = groupPrefix+opts.glyphs.branch, groupPrefix+opts.glyphs.vertical
+			} else {
+				childPrefix, depPrefix = groupPrefix+opts.glyphs.lastBranch, groupPrefix+opts.glyphs.indent
+			}
+
+			child, ok := nodeMap[childUID]
+			if !ok {
+				return nil, fmt.Errorf("dependent object (uid: %s) not found in list of fetched objects", childUID)
+			}
+			rset, ok := deps[childUID]
+			if !ok {
+				return nil, fmt.Errorf("dependent object (uid: %s) not found", childUID)
+			}
+			if podUIDs, ok := collapsed[childUID]; ok {
+				rows = append(rows, collapsedPodsToTableRow(nodeMap, deps, podUIDs, childPrefix, opts))
+				for _, uid := range podUIDs {
+					uidSet[uid] = struct{}{}
+				}
+				continue
+			}
+			row := nodeToTableRow(child, rset, childPrefix, opts)
+			if isNonControllerOwnerEdge(rset) {
+				row.Cells[0] = fmt.Sprintf("%s %s", row.Cells[0], cellNonControllerOwner)
+			}
+			// Objects that are an ancestor of themselves form a cycle & are not
+			// expanded again
+			if _, ok := pathSet[child.UID]; ok {
+				row.Cells[0] = fmt.Sprintf("%s %s", row.Cells[0], cellCycle)
+				rows = append(rows, row)
+				continue
+			}
+			// Objects reachable through multiple objects are only expanded the first
+			// time they're listed
+			if _, ok := uidSet[child.UID]; ok {
+				row.Cells[0] = fmt.Sprintf("%s %s", row.Cells[0], cellSeeAbove)
+				rows = append(rows, row)
+				continue
+			}
+			rows = append(rows, row)
+			if depth < opts.maxDepth {
+				depRows, err := nodeDepsToTableRows(nodeMap, uidSet, pathSet, child, depPrefix, depth+1, opts)
+				if err != nil {
+					return nil, err
+				}
+				rows = append(rows, depRows...)
+				continue
+			}
+			// Indicate that the child's dependencies or dependents were truncated
+			if _, ok := uidSet[child.UID]; !ok {
+				if n := len(child.GetDeps(opts.depsIsDependencies)); n > 0 {
+					rows = append(rows, truncatedDepsToTableRow(n, depPrefix+opts.glyphs.lastBranch))
+				}
 			}
-			rows = append(rows, depRows...)
 		}
 	}
+	if node.HiddenCompletedPods > 0 {
+		rows = append(rows, hiddenCompletedPodsToTableRow(node.HiddenCompletedPods, prefix+opts.glyphs.lastBranch))
+	}
 
 	return rows, nil
 }
 
-// translateTimestampSince returns the elapsed time since timestamp in
-// human-readable approximation.
-func translateTimestampSince(timestamp metav1.Time) string {
+// collapsePods returns the provided dependencies or dependents with the Pods
+// sharing the same ready & status value replaced by the first of them, along
+// with the Pods summarized by each of them. Only Pods that haven't been listed
+// yet & have no dependencies or dependents of their own are collapsed so that
+// no other object is hidden, while Pods whose ready & status value isn't
+// shared with any other Pod are left as is.
+func collapsePods(
+	nodeMap graph.NodeMap,
+	uidSet map[types.UID]struct{},
+	uids []types.UID,
+	depsIsDependencies bool,
+	readyStatusFn func(node *graph.Node) (string, string)) ([]types.UID, map[types.UID][]types.UID) {
+	type readyStatus struct {
+		ready, status string
+	}
+	podUIDsByStatus := map[readyStatus][]types.UID{}
+	for _, uid := range uids {
+		node, ok := nodeMap[uid]
+		if !ok || node.Group != corev1.GroupName || node.Kind != "Pod" || node.Unstructured == nil || node.NotFound || node.PassThrough {
+			continue
+		}
+		if _, ok := uidSet[uid]; ok || len(node.GetDeps(depsIsDependencies)) > 0 {
+			continue
+		}
+		ready, status := readyStatusFn(node)
+		k := readyStatus{ready: ready, status: status}
+		podUIDsByStatus[k] = append(podUIDsByStatus[k], uid)
+	}
+
+	collapsed := map[types.UID][]types.UID{}
+	omitted := map[types.UID]struct{}{}
+	for _, podUIDs := range podUIDsByStatus {
+		if len(podUIDs) < 2 {
+			continue
+		}
+		collapsed[podUIDs[0]] = podUIDs
+		for _, uid := range podUIDs[1:] {
+			omitted[uid] = struct{}{}
+		}
+	}
+	if len(collapsed) == 0 {
+		return uids, nil
+	}
+	result := make([]types.UID, 0, len(uids)-len(omitted))
+	for _, uid := range uids {
+		if _, ok := omitted[uid]; !ok {
+			result = append(result, uid)
+		}
+	}
+	return result, collapsed
+}
+
+// collapsedPodsToTableRow returns a table row summarizing the provided Pods
+// sharing the same ready & status value, named after the prefix shared by the
+// names of the Pods (e.g. "Pod/web-5cc79d4bf5-* (3 collapsed)"). Cells specific
+// to a single Pod are left empty.
+func collapsedPodsToTableRow(
+	nodeMap graph.NodeMap,
+	deps map[types.UID]graph.RelationshipSet,
+	podUIDs []types.UID,
+	namePrefix string,
+	opts tableOptions) metav1.TableRow {
+	names := make([]string, 0, len(podUIDs))
+	rset := graph.RelationshipSet{}
+	for _, uid := range podUIDs {
+		names = append(names, nodeMap[uid].Name)
+		for r := range deps[uid] {
+			rset[r] = struct{}{}
+		}
+	}
+	summary := *nodeMap[podUIDs[0]]
+	summary.Name = collapsedPodsName(names)
+	row := nodeToTableRow(&summary, rset, namePrefix, opts)
+	row.Object = runtime.RawExtension{}
+	row.Cells[0] = fmt.Sprintf("%s (%d collapsed)", row.Cells[0], len(podUIDs))
+	start, end := columnIndex(objectColumnDefinitions, "Age"), columnIndex(objectColumnDefinitions, "Relationships")
+	for ix := start; ix < end; ix++ {
+		row.Cells[ix] = ""
+	}
+	return row
+}
+
+// collapsedPodsName returns the longest prefix shared by the provided names up
+// to its last "-" followed by a "*", which is usually the name of the
+// ReplicaSet or Job the Pods were generated for.
+func collapsedPodsName(names []string) string {
+	prefix := names[0]
+	for _, name := range names[1:] {
+		ix := 0
+		for ix < len(prefix) && ix < len(name) && prefix[ix] == name[ix] {
+			ix++
+		}
+		prefix = prefix[:ix]
+	}
+	if ix := strings.LastIndex(prefix, "-"); ix >= 0 {
+		prefix = prefix[:ix+1]
+	}
+	return prefix + "*"
+}
+
+// colorizeReadyCell wraps the provided "Ready" cell value with the ANSI escape
+// codes matching its readiness state.
+func colorizeReadyCell(ready, status string) string {
+	switch {
+	case getReadiness(ready, status) == readinessReady:
+		return colorGreen + ready + colorReset
+	case getReadiness(ready, status) == readinessNotReady:
+		return colorRed + ready + colorReset
+	case len(ready) > 0 && ready != cellNotApplicable:
+		return colorYellow + ready + colorReset
+	default:
+		return ready
+	}
+}
+
+// escapeTableReadyCells encloses the "Ready" cell of every row in the provided
+// table that can be colorized with tabwriterEscape, so that the cells can be
+// colorized once the table is printed without the ANSI escape codes affecting
+// the column widths. The colorized cells are returned in the order of the rows.
+func escapeTableReadyCells(t *metav1.Table) []string {
+	var result []string
+	for ix, row := range t.Rows {
+		if len(row.Cells) < 2 {
+			continue
+		}
+		ready, _ := row.Cells[1].(string)
+		var status string
+		if len(row.Cells) > 2 {
+			status, _ = row.Cells[2].(string)
+		}
+		colorized := colorizeReadyCell(ready, status)
+		if colorized == ready {
+			continue
+		}
+		t.Rows[ix].Cells[1] = tabwriterEscape + ready + tabwriterEscape
+		result = append(result, colorized)
+	}
+	return result
+}
+
+// colorizeEscapedCells replaces the escaped text segments of the provided
+// printed table output with the provided colorized cells, in order.
+func colorizeEscapedCells(output string, cells []string) string {
+	var b strings.Builder
+	for ix := 0; ; ix++ {
+		start := strings.Index(output, tabwriterEscape)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(output[start+1:], tabwriterEscape)
+		if end < 0 {
+			break
+		}
+		end += start + 1
+		b.WriteString(output[:start])
+		if ix < len(cells) {
+			b.WriteString(cells[ix])
+		} else {
+			b.WriteString(output[start+1 : end])
+		}
+		output = output[end+1:]
+	}
+	b.WriteString(output)
+	return b.String()
+}
+
+// translateTimestampSince returns the elapsed time since timestamp in the
+// provided age format, either in human-readable approximation, as the
+// timestamp itself or as the number of elapsed seconds.
+func translateTimestampSince(timestamp metav1.Time, ageFormat string) string {
+	return translateTimestampSinceTime(timestamp, ageFormat, time.Now())
+}
+
+// translateTimestampSinceTime is translateTimestampSince with the elapsed time
+// measured up to now.
+func translateTimestampSinceTime(timestamp metav1.Time, ageFormat string, now time.Time) string {
 	if timestamp.IsZero() {
 		return cellUnknown
 	}
 
-	return duration.HumanDuration(time.Since(timestamp.Time))
+	switch ageFormat {
+	case ageFormatISO8601:
+		return timestamp.UTC().Format(time.RFC3339)
+	case ageFormatSeconds:
+		return strconv.FormatInt(int64(now.Sub(timestamp.Time).Seconds()), 10)
+	default:
+		return duration.HumanDuration(now.Sub(timestamp.Time))
+	}
 }
@@ -0,0 +1,921 @@
+package printers
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+// newTestTableOptions returns the provided table options with the unset
+// functions, glyphs & age format defaulting to those of the default output
+// format. The maximum depth defaults to no limit if unset.
+func newTestTableOptions(t *testing.T, nodeMap graph.NodeMap, opts tableOptions) tableOptions {
+	t.Helper()
+	if opts.maxDepth == 0 {
+		opts.maxDepth = graph.UnlimitedDepth
+	}
+	if opts.readyStatusFn == nil {
+		readyStatusFn, err := createReadyStatusFn(conditionTypeReady)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		opts.readyStatusFn = readyStatusFn
+	}
+	if opts.sortDepsFn == nil {
+		opts.sortDepsFn = createSortDepsFn(nodeMap, "", opts.readyStatusFn)
+	}
+	if opts.showGroupFn == nil {
+		opts.showGroupFn = func(string) bool { return false }
+	}
+	if opts.glyphs == (treeGlyphs{}) {
+		opts.glyphs = getTreeGlyphs(false)
+	}
+	if len(opts.ageFormat) == 0 {
+		opts.ageFormat = ageFormatHuman
+	}
+	return opts
+}
+
+// newTestTable converts the provided relationship tree into table rows, see
+// newTestTableOptions for the defaults of the provided table options.
+func newTestTable(t *testing.T, nodeMap graph.NodeMap, roots []*graph.Node, opts tableOptions) *metav1.Table {
+	t.Helper()
+	table, err := nodeMapToTable(nodeMap, roots, newTestTableOptions(t, nodeMap, opts))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return table
+}
+
+// nameColumn returns the cells of the name column of every row in the
+// provided table.
+func nameColumn(table *metav1.Table) []string {
+	output := make([]string, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		output = append(output, row.Cells[0].(string))
+	}
+	return output
+}
+
+func TestGetPodRestarts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		status   map[string]interface{}
+		expected string
+	}{
+		{"no container statuses", map[string]interface{}{}, "0"},
+		{
+			"sum of container restarts",
+			map[string]interface{}{
+				"containerStatuses": []interface{}{
+					map[string]interface{}{"name": "a", "restartCount": int64(2)},
+					map[string]interface{}{"name": "b", "restartCount": int64(3)},
+				},
+			},
+			"5",
+		},
+		{
+			"initializing pod only counts init container restarts",
+			map[string]interface{}{
+				"initContainerStatuses": []interface{}{
+					map[string]interface{}{"name": "init", "restartCount": int64(1)},
+				},
+				"containerStatuses": []interface{}{
+					map[string]interface{}{"name": "a", "restartCount": int64(4)},
+				},
+			},
+			"1",
+		},
+	}
+	for _, tt := range tests {
+		u := &unstructuredv1.Unstructured{Object: map[string]interface{}{"status": tt.status}}
+		output, err := getPodRestarts(u)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if output != tt.expected {
+			t.Fatalf("%s: expected \"%s\" got \"%s\"", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestCreateReadyStatusFn(t *testing.T) {
+	t.Parallel()
+
+	conditions := []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "False", "reason": "NotReady"},
+		map[string]interface{}{"type": "Available", "status": "True", "reason": "MinimumReplicasAvailable"},
+	}
+	tests := []struct {
+		name           string
+		conditionType  string
+		status         map[string]interface{}
+		expectedReady  string
+		expectedStatus string
+	}{
+		{"default condition type", conditionTypeReady, map[string]interface{}{"conditions": conditions}, "False", "NotReady"},
+		{"custom condition type", "Available", map[string]interface{}{"conditions": conditions}, "True", "MinimumReplicasAvailable"},
+		{"missing condition falls back to phase", "Available", map[string]interface{}{"phase": "Bound"}, "", "Bound"},
+	}
+	for _, tt := range tests {
+		readyStatusFn, err := createReadyStatusFn(tt.conditionType)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		node := &graph.Node{
+			Unstructured: &unstructuredv1.Unstructured{Object: map[string]interface{}{"status": tt.status}},
+			Group:        "example.com",
+			Kind:         "Widget",
+		}
+		ready, status := readyStatusFn(node)
+		if ready != tt.expectedReady || status != tt.expectedStatus {
+			t.Fatalf("%s: expected \"%s\" & \"%s\" got \"%s\" & \"%s\"", tt.name, tt.expectedReady, tt.expectedStatus, ready, status)
+		}
+	}
+
+	if _, err := createReadyStatusFn(`Ready")]`); err == nil {
+		t.Fatalf("expected error for invalid condition type")
+	}
+}
+
+func TestCreateReadyStatusFnKindRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		group          string
+		kind           string
+		object         map[string]interface{}
+		expectedReady  string
+		expectedStatus string
+	}{
+		{
+			name:  "available deployment",
+			group: "apps",
+			kind:  "Deployment",
+			object: map[string]interface{}{"status": map[string]interface{}{
+				"replicas":      int64(2),
+				"readyReplicas": int64(2),
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Available", "status": "True", "reason": "MinimumReplicasAvailable"},
+					map[string]interface{}{"type": "Progressing", "status": "True", "reason": "NewReplicaSetAvailable"},
+				},
+			}},
+			expectedReady: "2/2",
+		},
+		{
+			name:  "deployment exceeding its progress deadline",
+			group: "apps",
+			kind:  "Deployment",
+			object: map[string]interface{}{"status": map[string]interface{}{
+				"replicas":      int64(2),
+				"readyReplicas": int64(1),
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Available", "status": "False", "reason": "MinimumReplicasUnavailable"},
+					map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+				},
+			}},
+			expectedReady:  "1/2",
+			expectedStatus: "ProgressDeadlineExceeded",
+		},
+		{
+			name: "cordoned node",
+			kind: "Node",
+			object: map[string]interface{}{
+				"spec": map[string]interface{}{"unschedulable": true},
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "MemoryPressure", "status": "False", "reason": "KubeletHasSufficientMemory"},
+					map[string]interface{}{"type": "Ready", "status": "True", "reason": "KubeletReady"},
+				}},
+			},
+			expectedReady:  "True",
+			expectedStatus: "KubeletReady,SchedulingDisabled",
+		},
+		{
+			name:           "bound pvc",
+			kind:           "PersistentVolumeClaim",
+			object:         map[string]interface{}{"status": map[string]interface{}{"phase": "Bound"}},
+			expectedReady:  "True",
+			expectedStatus: "Bound",
+		},
+		{
+			name:           "pending pvc",
+			kind:           "PersistentVolumeClaim",
+			object:         map[string]interface{}{"status": map[string]interface{}{"phase": "Pending"}},
+			expectedReady:  "False",
+			expectedStatus: "Pending",
+		},
+	}
+	readyStatusFn, err := createReadyStatusFn(conditionTypeReady)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tt := range tests {
+		node := &graph.Node{
+			Unstructured: &unstructuredv1.Unstructured{Object: tt.object},
+			Group:        tt.group,
+			Kind:         tt.kind,
+		}
+		ready, status := readyStatusFn(node)
+		if ready != tt.expectedReady || status != tt.expectedStatus {
+			t.Fatalf("%s: expected \"%s\" & \"%s\" got \"%s\" & \"%s\"", tt.name, tt.expectedReady, tt.expectedStatus, ready, status)
+		}
+	}
+}
+
+func TestGetPodDisruptionBudgetReadyStatus(t *testing.T) {
+	t.Parallel()
+
+	u := &unstructuredv1.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"generation": int64(2)},
+		"status": map[string]interface{}{
+			"currentHealthy": int64(1),
+			"desiredHealthy": int64(2),
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":               "DisruptionAllowed",
+					"status":             "False",
+					"reason":             "InsufficientPods",
+					"observedGeneration": int64(2),
+				},
+			},
+		},
+	}}
+	ready, status, err := getPodDisruptionBudgetReadyStatus(u)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ready != "1/2" || status != "InsufficientPods" {
+		t.Fatalf("expected \"1/2\" & \"InsufficientPods\" got \"%s\" & \"%s\"", ready, status)
+	}
+}
+
+func TestNodeToBreadcrumb(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	showGroupFn := func(string) bool { return false }
+	tests := []struct {
+		uid      types.UID
+		ascii    bool
+		expected string
+	}{
+		{"uid-pod", false, "Deployment/web › ReplicaSet/web-5cc79d4bf5 › ▶ Pod/web-5cc79d4bf5-xgvkc"},
+		{"uid-pod", true, "Deployment/web -> ReplicaSet/web-5cc79d4bf5 -> > Pod/web-5cc79d4bf5-xgvkc"},
+		{"uid-deploy", false, "▶ Deployment/web"},
+	}
+	for _, tt := range tests {
+		if got := nodeToBreadcrumb(nodeMap, nodeMap[tt.uid], getTreeGlyphs(tt.ascii), showGroupFn, false); got != tt.expected {
+			t.Fatalf("%s (ascii=%t): expected \"%s\" got \"%s\"", tt.uid, tt.ascii, tt.expected, got)
+		}
+	}
+
+	// Breadcrumbs are only printed above the relationship tree of dependencies
+	f := NewFlags()
+	*f.HumanReadableFlags.Color = colorNever
+	p, err := f.ToPrinter(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, depsIsDependencies := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := p.Print(&buf, nodeMap, []types.UID{"uid-pod"}, graph.UnlimitedDepth, depsIsDependencies); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		firstLine := strings.SplitN(buf.String(), "\n", 2)[0]
+		if hasBreadcrumb := firstLine == tests[0].expected; hasBreadcrumb != depsIsDependencies {
+			t.Fatalf("dependencies=%t: unexpected first line \"%s\"", depsIsDependencies, firstLine)
+		}
+	}
+}
+
+func TestNodeMapToTableTreeGlyphs(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	deploy := nodeMap["uid-deploy"]
+	cm := newTestNode("uid-cm", "", "ConfigMap", "default", "cm", nil)
+	secret := newTestNode("uid-secret", "", "Secret", "default", "secret", nil)
+	deploy.AddDependent(cm.UID, graph.RelationshipControllerRef)
+	cm.AddDependent(secret.UID, graph.RelationshipControllerRef)
+	nodeMap[cm.UID] = cm
+	nodeMap[secret.UID] = secret
+
+	tests := []struct {
+		ascii    bool
+		expected []string
+	}{
+		{false, []string{
+			"▶ Deployment/web",
+			"├── ConfigMap/cm",
+			"│   └── Secret/secret",
+			"└── ReplicaSet/web-5cc79d4bf5",
+			"    └── Pod/web-5cc79d4bf5-xgvkc",
+		}},
+		{true, []string{
+			"> Deployment/web",
+			"|-- ConfigMap/cm",
+			"|   `-- Secret/secret",
+			"`-- ReplicaSet/web-5cc79d4bf5",
+			"    `-- Pod/web-5cc79d4bf5-xgvkc",
+		}},
+	}
+	for _, tt := range tests {
+		table := newTestTable(t, nodeMap, []*graph.Node{deploy}, tableOptions{glyphs: getTreeGlyphs(tt.ascii)})
+		output := nameColumn(table)
+		if !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("ascii=%t: expected \"%v\" got \"%v\"", tt.ascii, tt.expected, output)
+		}
+	}
+}
+
+func TestNodeMapToTableMaxDepth(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	tests := []struct {
+		maxDepth uint
+		expected []string
+	}{
+		{0, []string{
+			"▶ Deployment/web",
+			"└── ... (1 more)",
+		}},
+		{1, []string{
+			"▶ Deployment/web",
+			"└── ReplicaSet/web-5cc79d4bf5",
+			"    └── ... (1 more)",
+		}},
+		{graph.UnlimitedDepth, []string{
+			"▶ Deployment/web",
+			"└── ReplicaSet/web-5cc79d4bf5",
+			"    └── Pod/web-5cc79d4bf5-xgvkc",
+		}},
+	}
+	for _, tt := range tests {
+		opts := newTestTableOptions(t, nodeMap, tableOptions{})
+		opts.maxDepth = tt.maxDepth
+		table, err := nodeMapToTable(nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, opts)
+		if err != nil {
+			t.Fatalf("maxDepth=%d: unexpected error: %v", tt.maxDepth, err)
+		}
+		output := nameColumn(table)
+		if !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("maxDepth=%d: expected \"%v\" got \"%v\"", tt.maxDepth, tt.expected, output)
+		}
+	}
+}
+
+func TestNodeMapToTableHiddenCompletedPods(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	nodeMap["uid-rs"].HiddenCompletedPods = 3
+	table := newTestTable(t, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, tableOptions{})
+	output := nameColumn(table)
+	expected := []string{
+		"▶ Deployment/web",
+		"└── ReplicaSet/web-5cc79d4bf5",
+		"    ├── Pod/web-5cc79d4bf5-xgvkc",
+		"    └── (+3 completed hidden)",
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestNodeMapToTableGroupByKind(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	rs := nodeMap["uid-rs"]
+	for _, name := range []string{"web-5cc79d4bf5-rjc7d", "web-5cc79d4bf5-tt2zl"} {
+		pod := newTestNode("uid-"+name, "", "Pod", "default", name, nil)
+		rs.AddDependent(pod.UID, graph.RelationshipControllerRef)
+		nodeMap[pod.UID] = pod
+	}
+	svc := newTestNode("uid-svc", "", "Service", "default", "web", nil)
+	rs.AddDependent(svc.UID, graph.RelationshipService)
+	nodeMap[svc.UID] = svc
+	rs.HiddenCompletedPods = 1
+	table := newTestTable(t, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, tableOptions{groupByKind: true})
+	output := nameColumn(table)
+	expected := []string{
+		"▶ Deployment/web",
+		"└── [ReplicaSet] (1)",
+		"    └── ReplicaSet/web-5cc79d4bf5",
+		"        ├── [Pod] (3)",
+		"        │   ├── Pod/web-5cc79d4bf5-rjc7d",
+		"        │   ├── Pod/web-5cc79d4bf5-tt2zl",
+		"        │   └── Pod/web-5cc79d4bf5-xgvkc",
+		"        ├── [Service] (1)",
+		"        │   └── Service/web",
+		"        └── (+1 completed hidden)",
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestNodeMapToTableSharedObjects(t *testing.T) {
+	t.Parallel()
+
+	deploy := newTestNode("uid-deploy", "apps", "Deployment", "default", "web", nil)
+	podA := newTestNode("uid-pod-a", "", "Pod", "default", "a", nil)
+	podB := newTestNode("uid-pod-b", "", "Pod", "default", "b", nil)
+	secret := newTestNode("uid-secret", "", "Secret", "default", "foo", nil)
+	cm := newTestNode("uid-cm", "", "ConfigMap", "default", "bar", nil)
+	deploy.AddDependent(podA.UID, graph.RelationshipControllerRef)
+	deploy.AddDependent(podB.UID, graph.RelationshipControllerRef)
+	podA.AddDependent(secret.UID, graph.RelationshipPodVolume)
+	podB.AddDependent(secret.UID, graph.RelationshipPodVolume)
+	secret.AddDependent(cm.UID, graph.RelationshipControllerRef)
+	nodeMap := graph.NodeMap{deploy.UID: deploy, podA.UID: podA, podB.UID: podB, secret.UID: secret, cm.UID: cm}
+
+	table := newTestTable(t, nodeMap, []*graph.Node{deploy}, tableOptions{})
+	output := nameColumn(table)
+	expected := []string{
+		"▶ Deployment/web",
+		"├── Pod/a",
+		"│   └── Secret/foo",
+		"│       └── ConfigMap/bar",
+		"└── Pod/b",
+		"    └── Secret/foo (see above)",
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestReverseTableRows(t *testing.T) {
+	t.Parallel()
+
+	deploy := newTestNode("uid-deploy", "apps", "Deployment", "default", "web", nil)
+	podA := newTestNode("uid-pod-a", "", "Pod", "default", "a", nil)
+	podB := newTestNode("uid-pod-b", "", "Pod", "default", "b", nil)
+	secret := newTestNode("uid-secret", "", "Secret", "default", "foo", nil)
+	deploy.AddDependent(podA.UID, graph.RelationshipControllerRef)
+	deploy.AddDependent(podB.UID, graph.RelationshipControllerRef)
+	podA.AddDependent(secret.UID, graph.RelationshipPodVolume)
+	podB.AddDependent(secret.UID, graph.RelationshipPodVolume)
+	nodeMap := graph.NodeMap{deploy.UID: deploy, podA.UID: podA, podB.UID: podB, secret.UID: secret}
+
+	tests := []struct {
+		ascii    bool
+		expected []string
+	}{
+		{false, []string{
+			"    ┌── Secret/foo (see below)",
+			"┌── Pod/b",
+			"│   ┌── Secret/foo",
+			"├── Pod/a",
+			"▶ Deployment/web",
+		}},
+		{true, []string{
+			"    ,-- Secret/foo (see below)",
+			",-- Pod/b",
+			"|   ,-- Secret/foo",
+			"|-- Pod/a",
+			"> Deployment/web",
+		}},
+	}
+	for _, tt := range tests {
+		glyphs := getTreeGlyphs(tt.ascii)
+		table := newTestTable(t, nodeMap, []*graph.Node{deploy}, tableOptions{glyphs: glyphs})
+		reverseTableRows(table, glyphs)
+		output := nameColumn(table)
+		if !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("ascii=%t: expected \"%v\" got \"%v\"", tt.ascii, tt.expected, output)
+		}
+	}
+}
+
+func TestNodeMapToTableNonControllerOwners(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	cm := newTestNode("uid-cm", "", "ConfigMap", "default", "web-config", nil)
+	nodeMap[cm.UID] = cm
+	nodeMap["uid-deploy"].AddDependent(cm.UID, graph.RelationshipOwnerRef)
+	nodeMap["uid-rs"].AddDependent("uid-pod", graph.RelationshipOwnerRef)
+
+	// Only owners that aren't the controller of the object are marked
+	table := newTestTable(t, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, tableOptions{})
+	output := nameColumn(table)
+	expected := []string{
+		"▶ Deployment/web",
+		"├── ConfigMap/web-config *",
+		"└── ReplicaSet/web-5cc79d4bf5",
+		"    └── Pod/web-5cc79d4bf5-xgvkc",
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestNodeMapToTableCycle(t *testing.T) {
+	t.Parallel()
+
+	a := newTestNode("uid-a", "example.com", "Foo", "default", "a", nil)
+	b := newTestNode("uid-b", "example.com", "Bar", "default", "b", nil)
+	a.AddDependent(b.UID, graph.RelationshipControllerRef)
+	b.AddDependent(a.UID, graph.RelationshipControllerRef)
+	nodeMap := graph.NodeMap{a.UID: a, b.UID: b}
+
+	table := newTestTable(t, nodeMap, []*graph.Node{a}, tableOptions{})
+	output := nameColumn(table)
+	expected := []string{
+		"▶ Foo/a",
+		"└── Bar/b",
+		"    └── Foo/a (cycle)",
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestTranslateTimestampSince(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	created := now.Add(-90 * time.Second)
+	tests := []struct {
+		ageFormat string
+		timestamp metav1.Time
+		expected  string
+	}{
+		{ageFormatHuman, metav1.NewTime(created), "90s"},
+		{ageFormatISO8601, metav1.NewTime(created), created.UTC().Format(time.RFC3339)},
+		{ageFormatSeconds, metav1.NewTime(created), "90"},
+		{ageFormatSeconds, metav1.Time{}, cellUnknown},
+	}
+	for _, tt := range tests {
+		if output := translateTimestampSinceTime(tt.timestamp, tt.ageFormat, now); output != tt.expected {
+			t.Fatalf("%s: expected \"%s\" got \"%s\"", tt.ageFormat, tt.expected, output)
+		}
+	}
+}
+
+func TestAddOwnerColumn(t *testing.T) {
+	t.Parallel()
+
+	controller := true
+	nodeMap := newTestNodeMap()
+	nodeMap["uid-rs"].SetOwnerReferences([]metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "uid-deploy", Controller: &controller},
+	})
+	table := newTestTable(t, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, tableOptions{maxDepth: 1})
+	addOwnerColumn(table)
+
+	if name := table.ColumnDefinitions[4].Name; name != "Owner" {
+		t.Fatalf("expected column \"Owner\" after \"Age\" got \"%s\"", name)
+	}
+	expected := []string{cellNone, "Deployment/web", ""}
+	output := make([]string, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		if len(row.Cells) != len(table.ColumnDefinitions) {
+			t.Fatalf("expected %d cells got %d", len(table.ColumnDefinitions), len(row.Cells))
+		}
+		output = append(output, row.Cells[4].(string))
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestAddOwnerColumnWithoutAgeColumn(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	table, err := tableToCustomColumnsTable(newTestTable(t, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, tableOptions{}), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	addOwnerColumn(table)
+
+	// The column is added at the end of tables without an "Age" column
+	output := make([]string, 0, len(table.ColumnDefinitions))
+	for _, c := range table.ColumnDefinitions {
+		output = append(output, c.Name)
+	}
+	if expected := []string{"Name", "Owner"}; !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	for _, row := range table.Rows {
+		if len(row.Cells) != len(table.ColumnDefinitions) {
+			t.Fatalf("expected %d cells got %d", len(table.ColumnDefinitions), len(row.Cells))
+		}
+	}
+}
+
+func TestAddConditionAgeColumn(t *testing.T) {
+	t.Parallel()
+
+	deploy := newTestNode("uid-deploy", "apps", "Deployment", "default", "web", map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "False", "lastTransitionTime": "2021-09-01T10:30:00Z"},
+			},
+		},
+	})
+	rs := newTestNode("uid-rs", "apps", "ReplicaSet", "default", "web-5cc79d4bf5", map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True", "lastTransitionTime": "invalid"},
+			},
+		},
+	})
+	pod := newTestNode("uid-pod", "", "Pod", "default", "web-5cc79d4bf5-xgvkc", nil)
+	deploy.AddDependent(rs.UID, graph.RelationshipControllerRef)
+	deploy.AddDependent(pod.UID, graph.RelationshipControllerRef)
+	deploy.HiddenCompletedPods = 1
+	nodeMap := graph.NodeMap{deploy.UID: deploy, rs.UID: rs, pod.UID: pod}
+	table := newTestTable(t, nodeMap, []*graph.Node{deploy}, tableOptions{})
+	if err := addConditionAgeColumn(table, conditionTypeReady, ageFormatISO8601); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if name := table.ColumnDefinitions[4].Name; name != "Condition Age" {
+		t.Fatalf("expected column \"Condition Age\" after \"Age\" got \"%s\"", name)
+	}
+	expected := []string{"2021-09-01T10:30:00Z", cellNone, cellUnknown, ""}
+	output := make([]string, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		if len(row.Cells) != len(table.ColumnDefinitions) {
+			t.Fatalf("expected %d cells got %d", len(table.ColumnDefinitions), len(row.Cells))
+		}
+		output = append(output, row.Cells[4].(string))
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+
+	if err := addConditionAgeColumn(table, "", ageFormatISO8601); err == nil {
+		t.Fatalf("expected error for empty condition type")
+	}
+}
+
+func TestAddConditionColumns(t *testing.T) {
+	t.Parallel()
+
+	deploy := newTestNode("uid-deploy", "apps", "Deployment", "default", "web", map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "True"},
+				map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+			},
+		},
+	})
+	nodeMap := graph.NodeMap{deploy.UID: deploy}
+	deploy.HiddenCompletedPods = 1
+	table := newTestTable(t, nodeMap, []*graph.Node{deploy}, tableOptions{})
+	if err := addConditionColumns(table, []string{"Available", "Progressing", "ReplicaFailure"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	columns := make([]string, 0, len(table.ColumnDefinitions))
+	for _, c := range table.ColumnDefinitions[:7] {
+		columns = append(columns, c.Name)
+	}
+	expectedColumns := []string{"Name", "Ready", "Status", "Available", "Progressing", "ReplicaFailure", "Age"}
+	if !reflect.DeepEqual(columns, expectedColumns) {
+		t.Fatalf("expected columns \"%v\" got \"%v\"", expectedColumns, columns)
+	}
+	expected := [][]interface{}{
+		{"True", "False", cellNone},
+		{"", "", ""},
+	}
+	output := make([][]interface{}, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		if len(row.Cells) != len(table.ColumnDefinitions) {
+			t.Fatalf("expected %d cells got %d", len(table.ColumnDefinitions), len(row.Cells))
+		}
+		output = append(output, row.Cells[3:6])
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+
+	if err := addConditionColumns(table, []string{`"`}); err == nil {
+		t.Fatalf("expected error for invalid condition type")
+	}
+}
+
+func TestShowNodeColumn(t *testing.T) {
+	t.Parallel()
+
+	ds := newTestNode("uid-ds", "apps", "DaemonSet", "default", "agent", map[string]interface{}{
+		"status": map[string]interface{}{"desiredNumberScheduled": int64(3), "currentNumberScheduled": int64(1)},
+	})
+	pod := newTestNode("uid-pod", "", "Pod", "default", "agent-x7k2p", map[string]interface{}{
+		"spec": map[string]interface{}{"nodeName": "worker-1"},
+	})
+	ds.AddDependent(pod.UID, graph.RelationshipControllerRef)
+	pod.AddDependency(ds.UID, graph.RelationshipControllerRef)
+	nodeMap := graph.NodeMap{ds.UID: ds, pod.UID: pod}
+	table := newTestTable(t, nodeMap, []*graph.Node{ds}, tableOptions{})
+	showNodeColumn(table)
+
+	ix := 5
+	if c := table.ColumnDefinitions[ix]; c.Name != "Node" || c.Priority != 0 {
+		t.Fatalf("expected column \"Node\" with priority 0 got \"%s\" with priority %d", c.Name, c.Priority)
+	}
+	if c := objectColumnDefinitions[ix]; c.Priority != -1 {
+		t.Fatalf("expected shared column definitions to be left unchanged")
+	}
+	expected := []string{"(2 missing)", "worker-1"}
+	output := make([]string, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		output = append(output, row.Cells[ix].(string))
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestNodeMapToTableMaxNameWidth(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	nodeMap["uid-rs"].PassThrough = true
+	table := newTestTable(t, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, tableOptions{maxNameWidth: 8})
+
+	expected := []string{
+		"▶ Deployment/web",
+		"└── (ReplicaSet/web-5cc…)",
+		"    └── Pod/web-5cc…",
+	}
+	output := nameColumn(table)
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+
+	tests := []struct {
+		name     string
+		maxWidth uint
+		ellipsis string
+		expected string
+	}{
+		{"web", 8, "…", "web"},
+		{"web-5cc79d4bf5", 8, "...", "web-5..."},
+		{"web-5cc79d4bf5", 2, "...", "we"},
+	}
+	for _, tt := range tests {
+		if output := truncateName(tt.name, tt.maxWidth, tt.ellipsis); output != tt.expected {
+			t.Fatalf("truncateName(%q, %d, %q): expected %q got %q", tt.name, tt.maxWidth, tt.ellipsis, tt.expected, output)
+		}
+	}
+}
+
+func TestNodeMapToFlatTable(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	table, err := nodeMapToFlatTable(nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, newTestTableOptions(t, nodeMap, tableOptions{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(table.ColumnDefinitions) != len(objectColumnDefinitions)+2 {
+		t.Fatalf("expected %d columns got %d", len(objectColumnDefinitions)+2, len(table.ColumnDefinitions))
+	}
+	expected := [][]interface{}{
+		{"Deployment/web", int64(0), cellNone},
+		{"ReplicaSet/web-5cc79d4bf5", int64(1), "Deployment/web"},
+		{"Pod/web-5cc79d4bf5-xgvkc", int64(2), "ReplicaSet/web-5cc79d4bf5"},
+	}
+	output := make([][]interface{}, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		output = append(output, []interface{}{row.Cells[0], row.Cells[4], row.Cells[5]})
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestNodeMapToTableCollapsePods(t *testing.T) {
+	t.Parallel()
+
+	pod := func(name, phase string) *graph.Node {
+		return newTestNode("uid-"+name, "", "Pod", "default", name, map[string]interface{}{
+			"status": map[string]interface{}{"phase": phase},
+		})
+	}
+	rs := newTestNode("uid-rs", "apps", "ReplicaSet", "default", "web-5cc79d4bf5", nil)
+	secret := newTestNode("uid-secret", "", "Secret", "default", "foo", nil)
+	pods := []*graph.Node{
+		pod("web-5cc79d4bf5-rjc7d", "Running"),
+		pod("web-5cc79d4bf5-s8k2p", "Running"),
+		pod("web-5cc79d4bf5-xgvkc", "Running"),
+		pod("web-5cc79d4bf5-zt9qm", "Pending"),
+		// Pods with dependents of their own aren't collapsed
+		pod("web-5cc79d4bf5-zzzzz", "Running"),
+	}
+	nodeMap := graph.NodeMap{rs.UID: rs, secret.UID: secret}
+	for _, p := range pods {
+		rs.AddDependent(p.UID, graph.RelationshipControllerRef)
+		nodeMap[p.UID] = p
+	}
+	pods[4].AddDependent(secret.UID, graph.RelationshipPodVolume)
+
+	table := newTestTable(t, nodeMap, []*graph.Node{rs}, tableOptions{collapse: true})
+	output := make([]string, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		output = append(output, fmt.Sprintf("%s %s", row.Cells[0], row.Cells[2]))
+	}
+	expected := []string{
+		"▶ ReplicaSet/web-5cc79d4bf5 ",
+		"├── Pod/web-5cc79d4bf5-* (3 collapsed) Running",
+		"├── Pod/web-5cc79d4bf5-zt9qm Pending",
+		"└── Pod/web-5cc79d4bf5-zzzzz Running",
+		"    └── Secret/foo ",
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestColorizeEscapedCells(t *testing.T) {
+	t.Parallel()
+
+	// Names containing the ready state of objects aren't colorized
+	nodeMap := newTestNodeMap()
+	nodeMap["uid-deploy"].Name = "web-0/0"
+	table := newTestTable(t, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, tableOptions{})
+	cells := escapeTableReadyCells(table)
+	p, err := NewHumanPrintFlags().ToPrinter("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := p.PrintObj(table, &buf); err != nil {
+		t.Fatalf("failed to print table: %v", err)
+	}
+
+	expected := "NAME                               READY   STATUS   AGE\n" +
+		"▶ Deployment/web-0/0               " + colorGreen + "0/0" + colorReset + "              <unknown>\n" +
+		"└── ReplicaSet/web-5cc79d4bf5      " + colorGreen + "0/0" + colorReset + "              <unknown>\n" +
+		"    └── Pod/web-5cc79d4bf5-xgvkc   " + colorRed + "0/1" + colorReset + "              <unknown>\n"
+	if output := colorizeEscapedCells(buf.String(), cells); output != expected {
+		t.Fatalf("expected %q got %q", expected, output)
+	}
+}
+
+func TestNodeMapToTableShowAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	table := newTestTable(t, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, tableOptions{showAPIVersion: true})
+
+	expected := []string{
+		"▶ apps/v1/Deployment/web",
+		"└── apps/v1/ReplicaSet/web-5cc79d4bf5",
+		"    └── v1/Pod/web-5cc79d4bf5-xgvkc",
+	}
+	output := nameColumn(table)
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+}
+
+func TestAddConditionColumnsWithoutAnchorColumns(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	table, err := tableToCustomColumnsTable(newTestTable(t, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, tableOptions{}), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := addConditionAgeColumn(table, conditionTypeReady, ageFormatHuman); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := addConditionColumns(table, []string{"Available"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Columns are added at the end of tables without an "Age" or "Status"
+	// column, instead of before the names of objects
+	output := make([]string, 0, len(table.ColumnDefinitions))
+	for _, c := range table.ColumnDefinitions {
+		output = append(output, c.Name)
+	}
+	if expected := []string{"Name", conditionAgeColumnDefinition.Name, "Available"}; !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, output)
+	}
+	for _, row := range table.Rows {
+		if len(row.Cells) != len(table.ColumnDefinitions) {
+			t.Fatalf("expected %d cells got %d", len(table.ColumnDefinitions), len(row.Cells))
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package printers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+// objectTree represents a Kubernetes object & either its dependencies or
+// dependents in a relationship tree. The YAML output is converted from the JSON
+// output, so that both output formats share the same structure.
+type objectTree struct {
+	APIVersion          string    `json:"apiVersion,omitempty"`
+	Kind                string    `json:"kind,omitempty"`
+	Namespace           string    `json:"namespace,omitempty"`
+	Name                string    `json:"name,omitempty"`
+	UID                 types.UID `json:"uid,omitempty"`
+	Ready               string    `json:"ready,omitempty"`
+	Status              string    `json:"status,omitempty"`
+	PassThrough         bool      `json:"passThrough,omitempty"`
+	NotFound            bool      `json:"notFound,omitempty"`
+	HiddenCompletedPods int       `json:"hiddenCompletedPods,omitempty"`
+	Cluster             string    `json:"cluster,omitempty"`
+	// Root is set for the requested objects at the root of the relationship
+	// tree.
+	Root          bool          `json:"root,omitempty"`
+	Relationships []string      `json:"relationships,omitempty"`
+	Dependencies  []*objectTree `json:"dependencies,omitempty"`
+	Dependents    []*objectTree `json:"dependents,omitempty"`
+	// Ref is set to the UID of the object in place of its content when the
+	// object has already been included elsewhere in the tree.
+	Ref types.UID `json:"ref,omitempty"`
+	// Cycle is set along with Ref when the object is an ancestor of itself.
+	Cycle bool `json:"cycle,omitempty"`
+}
+
+// objectLine represents a Kubernetes object printed as a single line in the
+// jsonl output format, along with its position in the relationship tree.
+type objectLine struct {
+	*objectTree
+	ParentUID types.UID `json:"parentUID,omitempty"`
+	Depth     uint      `json:"depth"`
+}
+
+type jsonYamlPrinter struct {
+	outputFormat  string
+	readyStatusFn func(node *graph.Node) (string, string)
+	sortBy        string
+}
+
+func (p *jsonYamlPrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool) error {
+	roots, err := getRootNodes(nodeMap, rootUIDs)
+	if err != nil {
+		return err
+	}
+
+	sortDepsFn := createSortDepsFn(nodeMap, p.sortBy, p.readyStatusFn)
+	// Objects are streamed as the relationship tree is walked, instead of
+	// building the entire tree before printing it
+	if p.outputFormat == outputFormatJSONL {
+		return printObjectLines(w, nodeMap, roots, maxDepth, depsIsDependencies, sortDepsFn, p.readyStatusFn)
+	}
+	trees, err := nodeMapToObjectTrees(nodeMap, roots, maxDepth, depsIsDependencies, sortDepsFn, p.readyStatusFn)
+	if err != nil {
+		return err
+	}
+	// Print a single tree as an object & multiple trees as a list
+	var v interface{} = trees
+	if len(trees) == 1 {
+		v = trees[0]
+	}
+
+	switch p.outputFormat {
+	case outputFormatJSON:
+		data, err := json.MarshalIndent(v, "", "    ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+		_, err = w.Write(data)
+		return err
+	case outputFormatYAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		return fmt.Errorf("output format \"%s\" not supported", p.outputFormat)
+	}
+}
+
+// nodeToObjectTree converts the provided node into an object tree without any
+// dependencies or dependents.
+func nodeToObjectTree(node *graph.Node, readyStatusFn func(node *graph.Node) (string, string)) *objectTree {
+	ready, status := readyStatusFn(node)
+	t := objectTree{
+		Kind:      node.Kind,
+		Namespace: node.Namespace,
+		Name:      node.Name,
+		UID:       node.UID,
+		Ready:     ready,
+		Status:    status,
+
+		PassThrough:         node.PassThrough,
+		NotFound:            node.NotFound,
+		HiddenCompletedPods: node.HiddenCompletedPods,
+		Cluster:             node.Cluster,
+	}
+	if len(node.Kind) > 0 {
+		t.APIVersion = schema.GroupVersion{Group: node.Group, Version: node.Version}.String()
+	}
+	return &t
+}
+
+// printObjectLines writes the provided nodes & either their dependencies or
+// dependents as one JSON object per line while walking the relationship tree,
+// in the same order as the tree. Objects are written once, along with the UID
+// of the first object they're reached through & their depth. Objects reached
+// again through another object are written as a reference to their UID, so
+// that every relationship of the tree is included.
+func printObjectLines(
+	w io.Writer,
+	nodeMap graph.NodeMap,
+	roots []*graph.Node,
+	maxDepth uint,
+	depsIsDependencies bool,
+	sortDepsFn func(d map[types.UID]graph.RelationshipSet) []types.UID,
+	readyStatusFn func(node *graph.Node) (string, string)) error {
+	enc := json.NewEncoder(w)
+	uidSet := map[types.UID]struct{}{}
+	pathSet := map[types.UID]struct{}{}
+	var walkFn func(node, parent *graph.Node, rset graph.RelationshipSet, depth uint) error
+	walkFn = func(node, parent *graph.Node, rset graph.RelationshipSet, depth uint) error {
+		// Guard against possible cycles
+		if _, ok := uidSet[node.UID]; ok {
+			if parent == nil {
+				return nil
+			}
+			_, cycle := pathSet[node.UID]
+			ref := objectLine{
+				objectTree: &objectTree{Ref: node.UID, Cycle: cycle, Relationships: rset.List()},
+				ParentUID:  parent.UID,
+				Depth:      depth,
+			}
+			return enc.Encode(ref)
+		}
+		uidSet[node.UID] = struct{}{}
+		pathSet[node.UID] = struct{}{}
+		defer delete(pathSet, node.UID)
+
+		line := objectLine{objectTree: nodeToObjectTree(node, readyStatusFn), Depth: depth}
+		if parent != nil {
+			line.ParentUID = parent.UID
+			line.Relationships = rset.List()
+		} else {
+			line.Root = true
+		}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+		if depth >= maxDepth {
+			return nil
+		}
+
+		deps := node.GetDeps(depsIsDependencies)
+		for _, childUID := range sortDepsFn(deps) {
+			child, ok := nodeMap[childUID]
+			if !ok {
+				return fmt.Errorf("dependent object (uid: %s) not found in list of fetched objects", childUID)
+			}
+			if err := walkFn(child, node, deps[childUID], depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, root := range roots {
+		if err := walkFn(root, nil, nil, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nodeMapToObjectTrees converts the provided nodes & either their dependencies
+// or dependents into object trees, one for each of the provided nodes.
+func nodeMapToObjectTrees(
+	nodeMap graph.NodeMap,
+	roots []*graph.Node,
+	maxDepth uint,
+	depsIsDependencies bool,
+	sortDepsFn func(d map[types.UID]graph.RelationshipSet) []types.UID,
+	readyStatusFn func(node *graph.Node) (string, string)) ([]*objectTree, error) {
+	uidSet := map[types.UID]struct{}{}
+	pathSet := map[types.UID]struct{}{}
+	trees := make([]*objectTree, 0, len(roots))
+	for _, root := range roots {
+		t, err := nodeDepsToObjectTree(nodeMap, uidSet, pathSet, root, 0, maxDepth, depsIsDependencies, sortDepsFn, readyStatusFn)
+		if err != nil {
+			return nil, err
+		}
+		t.Root = true
+		trees = append(trees, t)
+	}
+	return trees, nil
+}
+
+// nodeDepsToObjectTree converts the provided node & either its dependencies or
+// dependents into an object tree. Objects that have already been included in
+// the tree are replaced with a reference to their UID, which is marked as a
+// cycle if the object is in pathSet (i.e. an ancestor of itself).
+func nodeDepsToObjectTree(
+	nodeMap graph.NodeMap,
+	uidSet map[types.UID]struct{},
+	pathSet map[types.UID]struct{},
+	node *graph.Node,
+	depth uint,
+	maxDepth uint,
+	depsIsDependencies bool,
+	sortDepsFn func(d map[types.UID]graph.RelationshipSet) []types.UID,
+	readyStatusFn func(node *graph.Node) (string, string)) (*objectTree, error) {
+	// Guard against possible cycles
+	if _, ok := uidSet[node.UID]; ok {
+		_, cycle := pathSet[node.UID]
+		return &objectTree{Ref: node.UID, Cycle: cycle}, nil
+	}
+	uidSet[node.UID] = struct{}{}
+	pathSet[node.UID] = struct{}{}
+	defer delete(pathSet, node.UID)
+
+	t := nodeToObjectTree(node, readyStatusFn)
+	if depth >= maxDepth {
+		return t, nil
+	}
+
+	deps := node.GetDeps(depsIsDependencies)
+	children := make([]*objectTree, 0, len(deps))
+	for _, childUID := range sortDepsFn(deps) {
+		child, ok := nodeMap[childUID]
+		if !ok {
+			return nil, fmt.Errorf("dependent object (uid: %s) not found in list of fetched objects", childUID)
+		}
+		ct, err := nodeDepsToObjectTree(nodeMap, uidSet, pathSet, child, depth+1, maxDepth, depsIsDependencies, sortDepsFn, readyStatusFn)
+		if err != nil {
+			return nil, err
+		}
+		// Record the relationships the object has with its parent
+		if rset, ok := deps[childUID]; ok {
+			ct.Relationships = rset.List()
+		}
+		children = append(children, ct)
+	}
+	if depsIsDependencies {
+		t.Dependencies = children
+	} else {
+		t.Dependents = children
+	}
+
+	return t, nil
+}
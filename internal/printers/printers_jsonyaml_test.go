@@ -0,0 +1,258 @@
+package printers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+func TestNodeMapToObjectTreesWithSharedObjects(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	sortDepsFn := createSortDepsFn(nodeMap, "", readyStatusFn)
+	roots := []*graph.Node{nodeMap["uid-deploy"], nodeMap["uid-rs"]}
+	trees, err := nodeMapToObjectTrees(nodeMap, roots, graph.UnlimitedDepth, false, sortDepsFn, readyStatusFn)
+	if err != nil {
+		t.Fatalf("failed to convert node map to object trees: %v", err)
+	}
+
+	if len(trees) != 2 {
+		t.Fatalf("expected 2 trees got %d", len(trees))
+	}
+	if len(trees[0].Dependents) != 1 || trees[0].Dependents[0].UID != "uid-rs" || len(trees[0].Dependents[0].Dependents) != 1 {
+		t.Fatalf("expected shared object to be expanded in the first tree, got %+v", trees[0])
+	}
+	if trees[1].Ref != "uid-rs" || len(trees[1].Name) > 0 {
+		t.Fatalf("expected shared object to be referenced in the second tree, got %+v", trees[1])
+	}
+}
+
+func TestNodeMapToObjectTreesRelationships(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	sortDepsFn := createSortDepsFn(nodeMap, "", readyStatusFn)
+	trees, err := nodeMapToObjectTrees(nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, graph.UnlimitedDepth, false, sortDepsFn, readyStatusFn)
+	if err != nil {
+		t.Fatalf("failed to convert node map to object trees: %v", err)
+	}
+
+	if len(trees[0].Relationships) != 0 {
+		t.Fatalf("expected root object to have no relationships, got %v", trees[0].Relationships)
+	}
+	rs := trees[0].Dependents[0]
+	if len(rs.Relationships) != 1 || rs.Relationships[0] != string(graph.RelationshipControllerRef) {
+		t.Fatalf("expected relationships \"%v\" got \"%v\"", []string{string(graph.RelationshipControllerRef)}, rs.Relationships)
+	}
+}
+
+func TestJSONYamlPrinterJSON(t *testing.T) {
+	t.Parallel()
+
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	p := &jsonYamlPrinter{outputFormat: outputFormatJSON, readyStatusFn: readyStatusFn}
+	var buf bytes.Buffer
+	if err := p.Print(&buf, newTestNodeMap(), []types.UID{"uid-deploy"}, graph.UnlimitedDepth, false); err != nil {
+		t.Fatalf("failed to print: %v", err)
+	}
+
+	expected := `{
+    "apiVersion": "apps/v1",
+    "kind": "Deployment",
+    "namespace": "default",
+    "name": "web",
+    "uid": "uid-deploy",
+    "ready": "0/0",
+    "root": true,
+    "dependents": [
+        {
+            "apiVersion": "apps/v1",
+            "kind": "ReplicaSet",
+            "namespace": "default",
+            "name": "web-5cc79d4bf5",
+            "uid": "uid-rs",
+            "ready": "0/0",
+            "relationships": [
+                "ControllerReference"
+            ],
+            "dependents": [
+                {
+                    "apiVersion": "v1",
+                    "kind": "Pod",
+                    "namespace": "default",
+                    "name": "web-5cc79d4bf5-xgvkc",
+                    "uid": "uid-pod",
+                    "ready": "0/1",
+                    "relationships": [
+                        "ControllerReference"
+                    ]
+                }
+            ]
+        }
+    ]
+}
+`
+	if output := buf.String(); output != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, output)
+	}
+}
+
+func TestJSONYamlPrinterYAML(t *testing.T) {
+	t.Parallel()
+
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	p := &jsonYamlPrinter{outputFormat: outputFormatYAML, readyStatusFn: readyStatusFn}
+	var buf bytes.Buffer
+	if err := p.Print(&buf, newTestNodeMap(), []types.UID{"uid-deploy"}, graph.UnlimitedDepth, false); err != nil {
+		t.Fatalf("failed to print: %v", err)
+	}
+
+	// Fields are named after their JSON tags
+	expected := `apiVersion: apps/v1
+dependents:
+- apiVersion: apps/v1
+  dependents:
+  - apiVersion: v1
+    kind: Pod
+    name: web-5cc79d4bf5-xgvkc
+    namespace: default
+    ready: 0/1
+    relationships:
+    - ControllerReference
+    uid: uid-pod
+  kind: ReplicaSet
+  name: web-5cc79d4bf5
+  namespace: default
+  ready: 0/0
+  relationships:
+  - ControllerReference
+  uid: uid-rs
+kind: Deployment
+name: web
+namespace: default
+ready: 0/0
+root: true
+uid: uid-deploy
+`
+	if output := buf.String(); output != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, output)
+	}
+}
+
+func TestPrintObjectLines(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	sortDepsFn := createSortDepsFn(nodeMap, "", readyStatusFn)
+	roots := []*graph.Node{nodeMap["uid-deploy"], nodeMap["uid-rs"]}
+	var buf bytes.Buffer
+	if err := printObjectLines(&buf, nodeMap, roots, graph.UnlimitedDepth, false, sortDepsFn, readyStatusFn); err != nil {
+		t.Fatalf("failed to print object lines: %v", err)
+	}
+
+	// Root objects that have already been printed aren't printed again
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines got %d: %q", len(lines), lines)
+	}
+	expected := []struct {
+		uid       types.UID
+		parentUID types.UID
+		depth     uint
+	}{
+		{"uid-deploy", "", 0},
+		{"uid-rs", "uid-deploy", 1},
+		{"uid-pod", "uid-rs", 2},
+	}
+	for ix, line := range lines {
+		var obj struct {
+			UID           types.UID `json:"uid"`
+			ParentUID     types.UID `json:"parentUID"`
+			Depth         uint      `json:"depth"`
+			Relationships []string  `json:"relationships"`
+		}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Fatalf("failed to decode line %q: %v", line, err)
+		}
+		if e := expected[ix]; obj.UID != e.uid || obj.ParentUID != e.parentUID || obj.Depth != e.depth {
+			t.Fatalf("expected uid \"%s\", parent \"%s\" & depth %d got %q", e.uid, e.parentUID, e.depth, line)
+		}
+		if ix > 0 && !reflect.DeepEqual(obj.Relationships, []string{string(graph.RelationshipControllerRef)}) {
+			t.Fatalf("expected relationships \"%v\" got %q", []string{string(graph.RelationshipControllerRef)}, line)
+		}
+	}
+}
+
+func TestPrintObjectLinesSharedObject(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	rs := newTestNode("uid-rs2", "apps", "ReplicaSet", "default", "web-6dd8f5c7b9", nil)
+	nodeMap[rs.UID] = rs
+	nodeMap["uid-deploy"].AddDependent(rs.UID, graph.RelationshipControllerRef)
+	rs.AddDependency("uid-deploy", graph.RelationshipControllerRef)
+	rs.AddDependent("uid-pod", graph.RelationshipOwnerRef)
+	nodeMap["uid-pod"].AddDependency(rs.UID, graph.RelationshipOwnerRef)
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	sortDepsFn := createSortDepsFn(nodeMap, "", readyStatusFn)
+	var buf bytes.Buffer
+	if err := printObjectLines(&buf, nodeMap, []*graph.Node{nodeMap["uid-deploy"]}, graph.UnlimitedDepth, false, sortDepsFn, readyStatusFn); err != nil {
+		t.Fatalf("failed to print object lines: %v", err)
+	}
+
+	// Objects shared by multiple objects are printed once & referenced by UID
+	// for each of the other objects
+	expected := `{"apiVersion":"apps/v1","kind":"Deployment","namespace":"default","name":"web","uid":"uid-deploy","ready":"0/0","root":true,"depth":0}
+{"apiVersion":"apps/v1","kind":"ReplicaSet","namespace":"default","name":"web-5cc79d4bf5","uid":"uid-rs","ready":"0/0","relationships":["ControllerReference"],"parentUID":"uid-deploy","depth":1}
+{"apiVersion":"v1","kind":"Pod","namespace":"default","name":"web-5cc79d4bf5-xgvkc","uid":"uid-pod","ready":"0/1","relationships":["ControllerReference"],"parentUID":"uid-rs","depth":2}
+{"apiVersion":"apps/v1","kind":"ReplicaSet","namespace":"default","name":"web-6dd8f5c7b9","uid":"uid-rs2","ready":"0/0","relationships":["ControllerReference"],"parentUID":"uid-deploy","depth":1}
+{"relationships":["OwnerReference"],"ref":"uid-pod","parentUID":"uid-rs2","depth":2}
+`
+	if output := buf.String(); output != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, output)
+	}
+}
+
+func TestNodeMapToObjectTreesCycle(t *testing.T) {
+	t.Parallel()
+
+	a := newTestNode("uid-a", "example.com", "Foo", "default", "a", nil)
+	b := newTestNode("uid-b", "example.com", "Bar", "default", "b", nil)
+	a.AddDependent(b.UID, graph.RelationshipOwnerRef)
+	b.AddDependent(a.UID, graph.RelationshipOwnerRef)
+	nodeMap := graph.NodeMap{a.UID: a, b.UID: b}
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	sortDepsFn := createSortDepsFn(nodeMap, "", readyStatusFn)
+
+	trees, err := nodeMapToObjectTrees(nodeMap, []*graph.Node{a, b}, graph.UnlimitedDepth, false, sortDepsFn, readyStatusFn)
+	if err != nil {
+		t.Fatalf("failed to convert node map to object trees: %v", err)
+	}
+	if ref := trees[0].Dependents[0].Dependents[0]; ref.Ref != "uid-a" || !ref.Cycle {
+		t.Fatalf("expected the ancestor to be referenced as a cycle, got %+v", ref)
+	}
+	// Objects included elsewhere in the tree aren't cycles
+	if trees[1].Ref != "uid-b" || trees[1].Cycle {
+		t.Fatalf("expected the shared object to be referenced without a cycle, got %+v", trees[1])
+	}
+
+	var buf bytes.Buffer
+	if err := printObjectLines(&buf, nodeMap, []*graph.Node{a}, graph.UnlimitedDepth, false, sortDepsFn, readyStatusFn); err != nil {
+		t.Fatalf("failed to print object lines: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	expected := `{"relationships":["OwnerReference"],"ref":"uid-a","cycle":true,"parentUID":"uid-b","depth":2}`
+	if len(lines) != 3 || lines[2] != expected {
+		t.Fatalf("expected last line \"%s\" got %q", expected, lines)
+	}
+}
@@ -0,0 +1,106 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+// objectIdentityList is the "v1" List of the yaml-list output format, which can
+// be passed as-is to other kubectl commands (eg. "kubectl delete -f").
+type objectIdentityList struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Items      []objectIdentity `json:"items"`
+}
+
+// objectIdentity identifies a Kubernetes object in the yaml-list output format.
+type objectIdentity struct {
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Metadata   objectIdentityMetadata `json:"metadata"`
+}
+
+// objectIdentityMetadata is the metadata identifying a Kubernetes object in
+// the yaml-list output format.
+type objectIdentityMetadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type namePrinter struct {
+	outputFormat  string
+	readyStatusFn func(node *graph.Node) (string, string)
+	sortBy        string
+}
+
+func (p *namePrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool) error {
+	roots, err := getRootNodes(nodeMap, rootUIDs)
+	if err != nil {
+		return err
+	}
+
+	sortDepsFn := createSortDepsFn(nodeMap, p.sortBy, p.readyStatusFn)
+	nodes, _, err := nodeMapToGraph(nodeMap, roots, maxDepth, depsIsDependencies, sortDepsFn)
+	if err != nil {
+		return err
+	}
+	identities := []objectIdentity{}
+	for _, node := range nodes {
+		// Synthetic objects (e.g. RBAC users & groups) aren't backed by any API
+		// resource & objects that weren't found don't exist, so neither can be
+		// referenced by other kubectl commands
+		if len(node.Resource) == 0 || node.NotFound {
+			continue
+		}
+		if p.outputFormat == outputFormatYAMLList {
+			identities = append(identities, nodeToObjectIdentity(node))
+			continue
+		}
+		name := nodeToName(node)
+		if p.outputFormat == outputFormatNamespacedName && node.Namespaced {
+			name = fmt.Sprintf("-n %s %s", node.Namespace, name)
+		}
+		if _, err := fmt.Fprintln(w, name); err != nil {
+			return err
+		}
+	}
+	if p.outputFormat != outputFormatYAMLList {
+		return nil
+	}
+	data, err := yaml.Marshal(objectIdentityList{APIVersion: "v1", Kind: "List", Items: identities})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// nodeToObjectIdentity returns the identity of the provided node, omitting the
+// namespace of cluster-scoped objects.
+func nodeToObjectIdentity(node *graph.Node) objectIdentity {
+	id := objectIdentity{
+		APIVersion: schema.GroupVersion{Group: node.Group, Version: node.Version}.String(),
+		Kind:       node.Kind,
+		Metadata:   objectIdentityMetadata{Name: node.Name},
+	}
+	if node.Namespaced {
+		id.Metadata.Namespace = node.Namespace
+	}
+	return id
+}
+
+// nodeToName returns the identifier of the provided node in the same format as
+// "kubectl get -o name" (eg. "deployment.apps/coredns"). The namespaced-name
+// output format prefixes the identifiers of namespaced objects with their
+// namespace flag (eg. "-n kube-system deployment.apps/coredns").
+func nodeToName(node *graph.Node) string {
+	gk := schema.GroupKind{Group: node.Group, Kind: node.Kind}
+	return fmt.Sprintf("%s/%s", strings.ToLower(gk.String()), node.Name)
+}
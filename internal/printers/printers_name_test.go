@@ -0,0 +1,90 @@
+package printers
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+func TestNamePrinter(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	nodeMap["uid-deploy"].Resource = "deployments"
+	nodeMap["uid-rs"].Resource = "replicasets"
+	nodeMap["uid-pod"].Resource = "pods"
+	ns := newTestNode("uid-ns", "", "Namespace", "", "default", nil)
+	ns.Resource = "namespaces"
+	user := newTestNode("uid-user", "rbac.authorization.k8s.io", "User", "", "jane", nil)
+	nodeMap["uid-deploy"].AddDependent(ns.UID, graph.RelationshipOwnerRef)
+	nodeMap["uid-deploy"].AddDependent(user.UID, graph.RelationshipClusterRoleBindingSubject)
+	nodeMap[ns.UID] = ns
+	nodeMap[user.UID] = user
+
+	readyStatusFn, err := createReadyStatusFn(conditionTypeReady)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := &namePrinter{readyStatusFn: readyStatusFn}
+	var buf bytes.Buffer
+	if err := p.Print(&buf, nodeMap, []types.UID{"uid-deploy"}, graph.UnlimitedDepth, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `deployment.apps/web
+namespace/default
+replicaset.apps/web-5cc79d4bf5
+pod/web-5cc79d4bf5-xgvkc
+`
+	if got := buf.String(); got != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, got)
+	}
+
+	p = &namePrinter{outputFormat: outputFormatNamespacedName, readyStatusFn: readyStatusFn}
+	buf.Reset()
+	if err := p.Print(&buf, nodeMap, []types.UID{"uid-deploy"}, graph.UnlimitedDepth, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = `-n default deployment.apps/web
+namespace/default
+-n default replicaset.apps/web-5cc79d4bf5
+-n default pod/web-5cc79d4bf5-xgvkc
+`
+	if got := buf.String(); got != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, got)
+	}
+
+	p = &namePrinter{outputFormat: outputFormatYAMLList, readyStatusFn: readyStatusFn}
+	buf.Reset()
+	if err := p.Print(&buf, nodeMap, []types.UID{"uid-deploy"}, graph.UnlimitedDepth, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = `apiVersion: v1
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: web
+    namespace: default
+- apiVersion: v1
+  kind: Namespace
+  metadata:
+    name: default
+- apiVersion: apps/v1
+  kind: ReplicaSet
+  metadata:
+    name: web-5cc79d4bf5
+    namespace: default
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: web-5cc79d4bf5-xgvkc
+    namespace: default
+kind: List
+`
+	if got := buf.String(); got != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, got)
+	}
+}
@@ -0,0 +1,69 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+// summaryPrinter prints the output of the wrapped printer followed by a
+// summary of the objects in the relationship tree.
+type summaryPrinter struct {
+	printer       Interface
+	readyStatusFn func(node *graph.Node) (string, string)
+}
+
+func (p *summaryPrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool) error {
+	if err := p.printer.Print(w, nodeMap, rootUIDs, maxDepth, depsIsDependencies); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, nodeMapToSummary(nodeMap, maxDepth, p.readyStatusFn))
+	return err
+}
+
+// nodeMapToSummary returns a summary of the objects in the relationship tree
+// that are within the provided maximum depth, listing the total number of
+// objects & the number of objects of each kind & of each readiness state.
+func nodeMapToSummary(nodeMap graph.NodeMap, maxDepth uint, readyStatusFn func(node *graph.Node) (string, string)) string {
+	total := 0
+	kindCounts := map[string]int{}
+	readyCount, notReadyCount, unknownCount := 0, 0, 0
+	for _, node := range nodeMap {
+		if node.Depth > maxDepth {
+			continue
+		}
+		total++
+		kindCounts[schema.GroupKind{Group: node.Group, Kind: node.Kind}.String()]++
+		ready, status := readyStatusFn(node)
+		switch getReadiness(ready, status) {
+		case readinessReady:
+			readyCount++
+		case readinessNotReady:
+			notReadyCount++
+		default:
+			unknownCount++
+		}
+	}
+
+	kinds := make([]string, 0, len(kindCounts))
+	for k := range kindCounts {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	kindSummaries := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		kindSummaries = append(kindSummaries, fmt.Sprintf("%s (%d)", k, kindCounts[k]))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nTotal: %d object(s)\n", total)
+	fmt.Fprintf(&b, "Kinds: %s\n", strings.Join(kindSummaries, ", "))
+	fmt.Fprintf(&b, "Status: Ready (%d), NotReady (%d), Unknown (%d)\n", readyCount, notReadyCount, unknownCount)
+	return b.String()
+}
@@ -0,0 +1,33 @@
+package printers
+
+import (
+	"testing"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+func TestNodeMapToSummary(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	nodeMap["uid-rs"].Depth = 1
+	nodeMap["uid-pod"].Depth = 2
+	readyStatusFn, err := createReadyStatusFn(conditionTypeReady)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		maxDepth uint
+		expected string
+	}{
+		{graph.UnlimitedDepth, "\nTotal: 3 object(s)\nKinds: Deployment.apps (1), Pod (1), ReplicaSet.apps (1)\nStatus: Ready (2), NotReady (1), Unknown (0)\n"},
+		{1, "\nTotal: 2 object(s)\nKinds: Deployment.apps (1), ReplicaSet.apps (1)\nStatus: Ready (2), NotReady (0), Unknown (0)\n"},
+		{0, "\nTotal: 1 object(s)\nKinds: Deployment.apps (1)\nStatus: Ready (1), NotReady (0), Unknown (0)\n"},
+	}
+	for _, tt := range tests {
+		if output := nodeMapToSummary(nodeMap, tt.maxDepth, readyStatusFn); output != tt.expected {
+			t.Fatalf("maxDepth=%d: expected %q got %q", tt.maxDepth, tt.expected, output)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+// templateNode holds the values in scope when rendering a Kubernetes object
+// in the relationship tree with a Go template.
+type templateNode struct {
+	// Object is the unstructured content of the object.
+	Object map[string]interface{}
+	// Group, Kind, Namespace & Name identify the object.
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+	// Ready, Status & Age hold the computed table cell values of the object.
+	Ready  string
+	Status string
+	Age    string
+	// Depth is the depth of the object in the relationship tree, starting
+	// from 0 for the root object.
+	Depth uint
+	// Relationships are the relationships the object has with its parent.
+	Relationships []string
+	// SeeAbove is true if the object has already been included elsewhere in
+	// the tree.
+	SeeAbove bool
+	// Dependencies or Dependents hold the children of the object, depending
+	// on which relationships are listed. Objects that have already been
+	// included elsewhere in the tree don't include their children.
+	Dependencies []*templateNode
+	Dependents   []*templateNode
+}
+
+type templatePrinter struct {
+	ageFormat     string
+	readyStatusFn func(node *graph.Node) (string, string)
+	sortBy        string
+	template      *template.Template
+}
+
+// Print renders the template once for each object in the relationship tree, in
+// depth-first order.
+func (p *templatePrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool) error {
+	roots, err := getRootNodes(nodeMap, rootUIDs)
+	if err != nil {
+		return err
+	}
+
+	sortDepsFn := createSortDepsFn(nodeMap, p.sortBy, p.readyStatusFn)
+	uidSet := map[types.UID]struct{}{}
+	trees := make([]*templateNode, 0, len(roots))
+	for _, root := range roots {
+		t, err := nodeDepsToTemplateNode(nodeMap, uidSet, root, nil, 0, maxDepth, depsIsDependencies, sortDepsFn, p.ageFormat, p.readyStatusFn)
+		if err != nil {
+			return err
+		}
+		trees = append(trees, t)
+	}
+
+	var walkFn func(t *templateNode) error
+	walkFn = func(t *templateNode) error {
+		if err := p.template.Execute(w, t); err != nil {
+			return fmt.Errorf("error executing template: %w", err)
+		}
+		children := t.Dependents
+		if depsIsDependencies {
+			children = t.Dependencies
+		}
+		for _, child := range children {
+			if err := walkFn(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, t := range trees {
+		if err := walkFn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nodeDepsToTemplateNode converts the provided node & either its dependencies
+// or dependents into a tree of template nodes.
+func nodeDepsToTemplateNode(
+	nodeMap graph.NodeMap,
+	uidSet map[types.UID]struct{},
+	node *graph.Node,
+	rset graph.RelationshipSet,
+	depth uint,
+	maxDepth uint,
+	depsIsDependencies bool,
+	sortDepsFn func(d map[types.UID]graph.RelationshipSet) []types.UID,
+	ageFormat string,
+	readyStatusFn func(node *graph.Node) (string, string)) (*templateNode, error) {
+	ready, status := readyStatusFn(node)
+	t := templateNode{
+		Group:         node.Group,
+		Kind:          node.Kind,
+		Namespace:     node.Namespace,
+		Name:          node.Name,
+		Ready:         ready,
+		Status:        status,
+		Age:           cellUnknown,
+		Depth:         depth,
+		Relationships: []string{},
+	}
+	if node.Unstructured != nil {
+		t.Object = node.UnstructuredContent()
+		t.Age = translateTimestampSince(node.GetCreationTimestamp(), ageFormat)
+	}
+	if rset != nil {
+		t.Relationships = rset.List()
+	}
+
+	// Guard against possible cycles
+	if _, ok := uidSet[node.UID]; ok {
+		t.SeeAbove = true
+		return &t, nil
+	}
+	uidSet[node.UID] = struct{}{}
+	if depth >= maxDepth {
+		return &t, nil
+	}
+
+	deps := node.GetDeps(depsIsDependencies)
+	children := make([]*templateNode, 0, len(deps))
+	for _, childUID := range sortDepsFn(deps) {
+		child, ok := nodeMap[childUID]
+		if !ok {
+			return nil, fmt.Errorf("dependent object (uid: %s) not found in list of fetched objects", childUID)
+		}
+		ct, err := nodeDepsToTemplateNode(nodeMap, uidSet, child, deps[childUID], depth+1, maxDepth, depsIsDependencies, sortDepsFn, ageFormat, readyStatusFn)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, ct)
+	}
+	if depsIsDependencies {
+		t.Dependencies = children
+	} else {
+		t.Dependents = children
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,41 @@
+package printers
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+func TestTemplatePrinter(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	nodeMap["uid-rs"].AddDependent("uid-pod", graph.RelationshipOwnerRef)
+	tmpl, err := NewTemplatePrintFlags().ToTemplate(`go-template={{.Depth}} {{.Kind}}/{{.Name}} {{.Ready}} {{.Relationships}}{{if .SeeAbove}} (see above){{end}}{{"\n"}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	readyStatusFn, err := createReadyStatusFn(conditionTypeReady)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := &templatePrinter{ageFormat: ageFormatHuman, readyStatusFn: readyStatusFn, template: tmpl}
+	var buf bytes.Buffer
+	if err := p.Print(&buf, nodeMap, []types.UID{"uid-deploy", "uid-rs"}, graph.UnlimitedDepth, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Objects that have already been rendered are rendered again without their
+	// children
+	expected := `0 Deployment/web 0/0 []
+1 ReplicaSet/web-5cc79d4bf5 0/0 [ControllerReference]
+2 Pod/web-5cc79d4bf5-xgvkc 0/1 [ControllerReference OwnerReference]
+0 ReplicaSet/web-5cc79d4bf5 0/0 [] (see above)
+`
+	if output := buf.String(); output != expected {
+		t.Fatalf("expected \"%s\" got \"%s\"", expected, output)
+	}
+}
@@ -0,0 +1,325 @@
+package printers
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/printers"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+func newTestNode(uid, group, kind, ns, name string, content map[string]interface{}) *graph.Node {
+	if content == nil {
+		content = map[string]interface{}{}
+	}
+	u := &unstructuredv1.Unstructured{Object: content}
+	u.SetUID(types.UID(uid))
+	u.SetNamespace(ns)
+	u.SetName(name)
+	return &graph.Node{
+		Unstructured: u,
+		UID:          types.UID(uid),
+		Group:        group,
+		Version:      "v1",
+		Kind:         kind,
+		Namespaced:   ns != "",
+		Namespace:    ns,
+		Name:         name,
+		Dependencies: map[types.UID]graph.RelationshipSet{},
+		Dependents:   map[types.UID]graph.RelationshipSet{},
+	}
+}
+
+func newTestNodeMap() graph.NodeMap {
+	podContent := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "web"},
+			},
+		},
+	}
+	deploy := newTestNode("uid-deploy", "apps", "Deployment", "default", "web", nil)
+	rs := newTestNode("uid-rs", "apps", "ReplicaSet", "default", "web-5cc79d4bf5", nil)
+	pod := newTestNode("uid-pod", "", "Pod", "default", "web-5cc79d4bf5-xgvkc", podContent)
+	deploy.AddDependent(rs.UID, graph.RelationshipControllerRef)
+	rs.AddDependency(deploy.UID, graph.RelationshipControllerRef)
+	rs.AddDependent(pod.UID, graph.RelationshipControllerRef)
+	pod.AddDependency(rs.UID, graph.RelationshipControllerRef)
+
+	return graph.NodeMap{
+		deploy.UID: deploy,
+		rs.UID:     rs,
+		pod.UID:    pod,
+	}
+}
+
+func TestCreateSortDepsFn(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	readyPodContent := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "web"},
+			},
+		},
+		"status": map[string]interface{}{
+			"containerStatuses": []interface{}{
+				map[string]interface{}{
+					"name":  "web",
+					"ready": true,
+					"state": map[string]interface{}{"running": map[string]interface{}{}},
+				},
+			},
+		},
+	}
+	notReadyPodContent := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "web"},
+			},
+		},
+	}
+	nodes := []*graph.Node{
+		newTestNode("uid-cm", "", "ConfigMap", "default", "b", nil),
+		newTestNode("uid-pod-a", "", "Pod", "default", "a", readyPodContent),
+		newTestNode("uid-pod-c", "", "Pod", "default", "c", notReadyPodContent),
+		newTestNode("uid-secret", "", "Secret", "default", "d", nil),
+	}
+	nodeMap := graph.NodeMap{}
+	deps := map[types.UID]graph.RelationshipSet{}
+	for ix, node := range nodes {
+		node.SetCreationTimestamp(metav1.NewTime(now.Add(time.Duration(-ix) * time.Hour)))
+		nodeMap[node.UID] = node
+		deps[node.UID] = graph.RelationshipSet{}
+	}
+
+	tests := []struct {
+		sortBy   string
+		expected []types.UID
+	}{
+		{"", []types.UID{"uid-cm", "uid-pod-a", "uid-pod-c", "uid-secret"}},
+		{"kind", []types.UID{"uid-cm", "uid-pod-a", "uid-pod-c", "uid-secret"}},
+		{"-kind", []types.UID{"uid-secret", "uid-pod-a", "uid-pod-c", "uid-cm"}},
+		{"name", []types.UID{"uid-pod-a", "uid-cm", "uid-pod-c", "uid-secret"}},
+		{"age", []types.UID{"uid-secret", "uid-pod-c", "uid-pod-a", "uid-cm"}},
+		{"-age", []types.UID{"uid-cm", "uid-pod-a", "uid-pod-c", "uid-secret"}},
+		{"status", []types.UID{"uid-pod-c", "uid-cm", "uid-pod-a", "uid-secret"}},
+	}
+	readyStatusFn, _ := createReadyStatusFn(conditionTypeReady)
+	for _, tt := range tests {
+		if output := createSortDepsFn(nodeMap, tt.sortBy, readyStatusFn)(deps); !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("expected \"%v\" got \"%v\" when sorting by \"%s\"", tt.expected, output, tt.sortBy)
+		}
+	}
+}
+
+func TestGetReadiness(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ready    string
+		status   string
+		expected readiness
+	}{
+		{"True", "", readinessReady},
+		{"False", "", readinessNotReady},
+		{"1/1", "Running", readinessReady},
+		{"0/1", "CrashLoopBackOff", readinessNotReady},
+		{"0/1", "Completed", readinessReady},
+		{"1/1", "Complete", readinessReady},
+		{"0/1", "Failed: BackoffLimitExceeded", readinessNotReady},
+		{"", "", readinessUnknown},
+	}
+	for _, tt := range tests {
+		if output := getReadiness(tt.ready, tt.status); output != tt.expected {
+			t.Fatalf("getReadiness(%q, %q): expected \"%v\" got \"%v\"", tt.ready, tt.status, tt.expected, output)
+		}
+	}
+}
+
+func TestNotReadyObjects(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	nodeMap["uid-pod"].Depth = 2
+	podGK := map[schema.GroupKind]struct{}{{Kind: "Pod"}: {}}
+	deployGK := map[schema.GroupKind]struct{}{{Group: "apps", Kind: "Deployment"}: {}}
+
+	tests := []struct {
+		name     string
+		maxDepth uint
+		gkSet    map[schema.GroupKind]struct{}
+		expected []types.UID
+	}{
+		{"all kinds", graph.UnlimitedDepth, nil, []types.UID{"uid-pod"}},
+		{"matching kind", graph.UnlimitedDepth, podGK, []types.UID{"uid-pod"}},
+		{"other kind", graph.UnlimitedDepth, deployGK, []types.UID{}},
+		{"beyond depth", 1, nil, []types.UID{}},
+	}
+	for _, tt := range tests {
+		notReady, err := NewFlags().NotReadyObjects(nodeMap, tt.maxDepth, tt.gkSet)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		output := []types.UID{}
+		for _, node := range notReady {
+			output = append(output, node.UID)
+		}
+		if !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("%s: expected %v got %v", tt.name, tt.expected, output)
+		}
+	}
+}
+
+func TestToPrinterQuiet(t *testing.T) {
+	t.Parallel()
+
+	f := NewFlags()
+	*f.OutputFormat = outputFormatCSV
+	*f.Quiet = true
+	p, err := f.ToPrinter(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := p.Print(&buf, newTestNodeMap(), []types.UID{"uid-deploy"}, graph.UnlimitedDepth, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output := buf.String(); strings.HasPrefix(output, "NAMESPACE,") {
+		t.Fatalf("expected no headers got %q", output)
+	}
+	if *f.HumanReadableFlags.NoHeaders {
+		t.Fatalf("expected --no-headers to be left unchanged")
+	}
+
+	*f.Summary = true
+	if _, err := f.ToPrinter(nil); err == nil {
+		t.Fatalf("expected error for --summary with --quiet")
+	}
+}
+
+func TestToPrinterLabelColumns(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	nodeMap["uid-deploy"].SetLabels(map[string]string{"version": "v2"})
+	nodeMap["uid-pod"].SetLabels(map[string]string{"version": "v2", "pod-template-hash": "5cc79d4bf5"})
+
+	f := NewFlags()
+	*f.HumanReadableFlags.ColumnLabels = []string{"version", "pod-template-hash"}
+	*f.HumanReadableFlags.Color = colorNever
+	p, err := f.ToPrinter(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := p.Print(&buf, nodeMap, []types.UID{"uid-deploy"}, graph.UnlimitedDepth, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"NAME                               READY   STATUS   AGE         VERSION   POD-TEMPLATE-HASH",
+		"▶ Deployment/web                   0/0              <unknown>   v2",
+		"└── ReplicaSet/web-5cc79d4bf5      0/0              <unknown>",
+		"    └── Pod/web-5cc79d4bf5-xgvkc   0/1              <unknown>   v2        5cc79d4bf5",
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, lines)
+	}
+}
+
+func TestPrintTableWithinWidth(t *testing.T) {
+	t.Parallel()
+
+	newPrinterFn := func() (printers.ResourcePrinter, error) {
+		return NewHumanPrintFlags().ToPrinter("")
+	}
+	tests := []struct {
+		width        uint
+		maxNameWidth uint
+		expected     string
+	}{
+		{0, 0, "NAME                      READY   STATUS\nDeployment/web-frontend   0/1     ProgressDeadlineExceeded\n"},
+		{80, 0, "NAME                      READY   STATUS\nDeployment/web-frontend   0/1     ProgressDeadlineExceeded\n"},
+		{50, 0, "NAME                      READY   STATUS\nDeployment/web-frontend   0/1     ProgressDeadlin…\n"},
+		{40, 0, "NAME                    READY   STATUS\nDeployment/web-front…   0/1     Progres…\n"},
+		{10, 0, "NAME                  READY   STATUS\nDeployment/web-fro…   0/1     Progres…\n"},
+		{0, 8, "NAME                  READY   STATUS\nDeployment/web-fro…   0/1     ProgressDeadlineExceeded\n"},
+	}
+	newTableFn := func(maxNameWidth uint) (*metav1.Table, error) {
+		obj := &unstructuredv1.Unstructured{}
+		obj.SetKind("Deployment")
+		obj.SetName("web-frontend")
+		name := obj.GetName()
+		if maxNameWidth > 0 {
+			name = truncateName(name, maxNameWidth, "…")
+		}
+		return &metav1.Table{
+			ColumnDefinitions: objectColumnDefinitions[:3],
+			Rows: []metav1.TableRow{
+				{
+					Cells:  []interface{}{"Deployment/" + name, "0/1", "ProgressDeadlineExceeded"},
+					Object: runtime.RawExtension{Object: obj},
+				},
+			},
+		}, nil
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if _, err := printTableWithinWidth(&buf, newPrinterFn, newTableFn, tt.width, tt.maxNameWidth, "…"); err != nil {
+			t.Fatalf("width %d: unexpected error: %v", tt.width, err)
+		}
+		if output := buf.String(); output != tt.expected {
+			t.Fatalf("width %d: expected %q got %q", tt.width, tt.expected, output)
+		}
+	}
+}
+
+func TestNodeMapToTreeNodeMaps(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := newTestNodeMap()
+	cm := newTestNode("uid-cm", "", "ConfigMap", "default", "web-config", nil)
+	nodeMap[cm.UID] = cm
+	cm.AddDependent("uid-pod", graph.RelationshipPodVolume)
+	nodeMap["uid-pod"].AddDependency(cm.UID, graph.RelationshipPodVolume)
+	roots := []*graph.Node{nodeMap["uid-deploy"], cm, nodeMap["uid-rs"]}
+
+	// Objects shared by multiple trees are only included in the first tree &
+	// root objects included in a preceding tree are left with an empty tree
+	tests := []struct {
+		maxDepth uint
+		expected [][]string
+	}{
+		{graph.UnlimitedDepth, [][]string{{"uid-deploy", "uid-pod", "uid-rs"}, {"uid-cm"}, {}}},
+		{1, [][]string{{"uid-deploy", "uid-rs"}, {"uid-cm", "uid-pod"}, {}}},
+	}
+	for _, tt := range tests {
+		output := [][]string{}
+		for _, m := range nodeMapToTreeNodeMaps(nodeMap, roots, tt.maxDepth, false) {
+			uids := []string{}
+			for uid := range m {
+				uids = append(uids, string(uid))
+			}
+			sort.Strings(uids)
+			output = append(output, uids)
+		}
+		if !reflect.DeepEqual(output, tt.expected) {
+			t.Fatalf("depth %d: expected \"%v\" got \"%v\"", tt.maxDepth, tt.expected, output)
+		}
+	}
+}
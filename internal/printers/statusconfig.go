@@ -0,0 +1,113 @@
+package printers
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/kubectl/pkg/cmd/get"
+	"sigs.k8s.io/yaml"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+// statusConfig is the format of the file provided with --status-config, which
+// configures how the ready & status value of objects of specific kinds are
+// determined.
+type statusConfig struct {
+	Kinds []statusConfigKind `json:"kinds"`
+}
+
+// statusConfigKind configures how the ready & status value of objects of a
+// single kind are determined. The JSON paths default to the status & reason
+// of the status condition with the provided type, which itself defaults to the
+// --status-condition-type value.
+type statusConfigKind struct {
+	Group          string `json:"group"`
+	Kind           string `json:"kind"`
+	ConditionType  string `json:"conditionType"`
+	StatusJSONPath string `json:"statusJSONPath"`
+	ReasonJSONPath string `json:"reasonJSONPath"`
+}
+
+// statusRule holds the JSON paths to get the ready & status value of objects.
+type statusRule struct {
+	statusJP *jsonpath.JSONPath
+	reasonJP *jsonpath.JSONPath
+}
+
+// loadStatusConfig reads the status config file at the provided path & returns
+// the rules of every configured kind.
+func loadStatusConfig(path, defaultConditionType string) (map[schema.GroupKind]statusRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading status config %s, %w", path, err)
+	}
+	var config statusConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing status config %s, %w", path, err)
+	}
+
+	rules := map[schema.GroupKind]statusRule{}
+	for _, k := range config.Kinds {
+		if len(k.Kind) == 0 {
+			return nil, fmt.Errorf("error parsing status config %s, kind must be specified for every entry", path)
+		}
+		gk := schema.GroupKind{Group: k.Group, Kind: k.Kind}
+		if _, ok := rules[gk]; ok {
+			return nil, fmt.Errorf("error parsing status config %s, %s is specified more than once", path, gk)
+		}
+		conditionType := k.ConditionType
+		if len(conditionType) == 0 {
+			conditionType = defaultConditionType
+		}
+		statusJP, reasonJP, err := newConditionJSONPaths(conditionType)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing status config %s, %s: %w", path, gk, err)
+		}
+		if len(k.StatusJSONPath) > 0 {
+			if statusJP, err = newStatusConfigJSONPath("status", k.StatusJSONPath); err != nil {
+				return nil, fmt.Errorf("error parsing status config %s, %s: %w", path, gk, err)
+			}
+		}
+		if len(k.ReasonJSONPath) > 0 {
+			if reasonJP, err = newStatusConfigJSONPath("reason", k.ReasonJSONPath); err != nil {
+				return nil, fmt.Errorf("error parsing status config %s, %s: %w", path, gk, err)
+			}
+		}
+		rules[gk] = statusRule{statusJP: statusJP, reasonJP: reasonJP}
+	}
+	return rules, nil
+}
+
+// newStatusConfigJSONPath parses the provided JSON path, which may omit the
+// surrounding braces the same way as custom-columns (e.g. ".status.phase").
+func newStatusConfigJSONPath(name, expr string) (*jsonpath.JSONPath, error) {
+	relaxed, err := get.RelaxedJSONPathExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+	jp := jsonpath.New(name).AllowMissingKeys(true)
+	if err := jp.Parse(relaxed); err != nil {
+		return nil, err
+	}
+	return jp, nil
+}
+
+// withStatusRules returns a function that computes the ready & status value of
+// objects with the provided rules if their kind has one, or with the provided
+// function otherwise.
+func withStatusRules(rules map[schema.GroupKind]statusRule, readyStatusFn func(node *graph.Node) (string, string)) func(node *graph.Node) (string, string) {
+	return func(node *graph.Node) (string, string) {
+		if node.NotFound || node.Unstructured == nil {
+			return readyStatusFn(node)
+		}
+		r, ok := rules[schema.GroupKind{Group: node.Group, Kind: node.Kind}]
+		if !ok {
+			return readyStatusFn(node)
+		}
+		ready, status, _ := getObjectReadyStatus(node.Unstructured, r.statusJP, r.reasonJP)
+		return ready, status
+	}
+}
@@ -0,0 +1,86 @@
+package printers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+func TestLoadStatusConfig(t *testing.T) {
+	t.Parallel()
+
+	config := `kinds:
+- group: example.com
+  kind: Widget
+  conditionType: Synced
+- group: example.com
+  kind: Gadget
+  statusJSONPath: .status.healthy
+  reasonJSONPath: "{.status.message}"
+- group: apps
+  kind: Deployment
+  conditionType: Progressing
+`
+	path := filepath.Join(t.TempDir(), "status.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatalf("failed to write status config: %v", err)
+	}
+	rules, err := loadStatusConfig(path, conditionTypeReady)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defaultFn, err := createReadyStatusFn(conditionTypeReady)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	readyStatusFn := withStatusRules(rules, defaultFn)
+
+	conditions := []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "False", "reason": "NotReady"},
+		map[string]interface{}{"type": "Synced", "status": "True", "reason": "ReconcileSuccess"},
+		map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+	}
+	tests := []struct {
+		name           string
+		group          string
+		kind           string
+		status         map[string]interface{}
+		expectedReady  string
+		expectedStatus string
+	}{
+		{"configured condition type", "example.com", "Widget", map[string]interface{}{"conditions": conditions}, "True", "ReconcileSuccess"},
+		{"configured json paths", "example.com", "Gadget", map[string]interface{}{"healthy": "False", "message": "Degraded"}, "False", "Degraded"},
+		{"configured kind with a built-in rule", "apps", "Deployment", map[string]interface{}{"conditions": conditions}, "False", "ProgressDeadlineExceeded"},
+		{"kind that isn't configured", "example.com", "Gizmo", map[string]interface{}{"conditions": conditions}, "False", "NotReady"},
+	}
+	for _, tt := range tests {
+		node := &graph.Node{
+			Unstructured: &unstructuredv1.Unstructured{Object: map[string]interface{}{"status": tt.status}},
+			Group:        tt.group,
+			Kind:         tt.kind,
+		}
+		ready, status := readyStatusFn(node)
+		if ready != tt.expectedReady || status != tt.expectedStatus {
+			t.Fatalf("%s: expected \"%s\" & \"%s\" got \"%s\" & \"%s\"", tt.name, tt.expectedReady, tt.expectedStatus, ready, status)
+		}
+	}
+
+	for _, invalid := range []string{
+		"kinds:\n- group: example.com\n",
+		"kinds:\n- kind: Widget\n- kind: Widget\n",
+		"kinds:\n- kind: Widget\n  conditionTypes: Ready\n",
+		"kinds:\n- kind: Widget\n  statusJSONPath: \"{.status\"\n",
+	} {
+		path := filepath.Join(t.TempDir(), "status.yaml")
+		if err := os.WriteFile(path, []byte(invalid), 0o600); err != nil {
+			t.Fatalf("failed to write status config: %v", err)
+		}
+		if _, err := loadStatusConfig(path, conditionTypeReady); err == nil {
+			t.Fatalf("expected error for status config %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+// refreshInterval is the interval between redraws of the progress indicator.
+const refreshInterval = 100 * time.Millisecond
+
+// spinnerFrames are the frames of the spinner drawn before the object count.
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// Indicator draws a spinner along with the number of objects discovered so far
+// on a single line of the provided writer, until it is stopped. All methods
+// are no-ops on a nil Indicator.
+type Indicator struct {
+	w      io.Writer
+	stopCh chan struct{}
+	doneCh chan struct{}
+
+	mu      sync.Mutex
+	objects int
+}
+
+// Start returns an Indicator that draws its progress on the provided writer
+// until Stop is called. Returns nil if the writer isn't a terminal, so that the
+// progress is never written to files or pipes.
+func Start(w io.Writer) *Indicator {
+	if !term.IsTerminal(w) {
+		return nil
+	}
+	i := &Indicator{
+		w:      w,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	ticker := time.NewTicker(refreshInterval)
+	go func() {
+		defer ticker.Stop()
+		i.run(ticker.C)
+	}()
+	return i
+}
+
+// SetObjects sets the number of objects discovered so far.
+func (i *Indicator) SetObjects(objects int) {
+	if i == nil {
+		return
+	}
+	i.mu.Lock()
+	i.objects = objects
+	i.mu.Unlock()
+}
+
+// Stop stops drawing the progress & clears the line it was drawn on.
+func (i *Indicator) Stop() {
+	if i == nil {
+		return
+	}
+	select {
+	case <-i.stopCh:
+	default:
+		close(i.stopCh)
+	}
+	<-i.doneCh
+}
+
+// run redraws the progress on every tick of the provided channel until the
+// Indicator is stopped.
+func (i *Indicator) run(tickCh <-chan time.Time) {
+	defer close(i.doneCh)
+	for frame := 0; ; frame++ {
+		select {
+		case <-i.stopCh:
+			// Erase the line so that nothing is left behind once the output
+			// is printed
+			if frame > 0 {
+				fmt.Fprint(i.w, "\r\033[K")
+			}
+			return
+		case <-tickCh:
+			i.mu.Lock()
+			objects := i.objects
+			i.mu.Unlock()
+			fmt.Fprintf(i.w, "\r\033[K%s Discovered %d objects...", spinnerFrames[frame%len(spinnerFrames)], objects)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package progress
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestStartNotTerminal(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	indicator := Start(&buf)
+	if indicator != nil {
+		t.Fatalf("expected no indicator for writers that aren't a terminal")
+	}
+	indicator.SetObjects(42)
+	indicator.Stop()
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output got %q", buf.String())
+	}
+}
+
+func TestIndicator(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	indicator := &Indicator{w: &buf, stopCh: make(chan struct{}), doneCh: make(chan struct{})}
+	tickCh := make(chan time.Time)
+	go indicator.run(tickCh)
+	indicator.SetObjects(42)
+	tickCh <- time.Now()
+	tickCh <- time.Now()
+	indicator.Stop()
+	indicator.Stop()
+
+	output := buf.String()
+	expected := "\r\033[K| Discovered 42 objects...\r\033[K/ Discovered 42 objects...\r\033[K"
+	if output != expected {
+		t.Fatalf("expected %q got %q", expected, output)
+	}
+}
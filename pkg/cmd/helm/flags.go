@@ -2,32 +2,68 @@ package helm
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 
 	"github.com/tohjustin/kube-lineage/internal/completion"
+	"github.com/tohjustin/kube-lineage/internal/graph"
 )
 
 const (
-	flagAllNamespaces          = "all-namespaces"
-	flagAllNamespacesShorthand = "A"
-	flagDepth                  = "depth"
-	flagDepthShorthand         = "d"
-	flagExcludeTypes           = "exclude-types"
-	flagIncludeTypes           = "include-types"
-	flagScopes                 = "scopes"
-	flagScopesShorthand        = "S"
+	flagAllNamespaces            = "all-namespaces"
+	flagAllNamespacesShorthand   = "A"
+	flagArgoCDInstanceLabel      = "argocd-instance-label"
+	flagArgoCDNamespace          = "argocd-namespace"
+	flagArgoCDTrackingAnnotation = "argocd-tracking-annotation"
+	flagDepth                    = "depth"
+	flagDepthShorthand           = "d"
+	flagDump                     = "dump"
+	flagExcludeKinds             = "exclude-kinds"
+	flagExcludeRelationships     = "exclude-relationships"
+	flagExcludeTypes             = "exclude-types"
+	flagHideCompleted            = "hide-completed"
+	flagIncludeKinds             = "include-kinds"
+	flagIncludeTypes             = "include-types"
+	flagInferOwners              = "infer-owners"
+	flagInvertSince              = "invert-since"
+	flagMaxConcurrency           = "max-concurrency"
+	flagMaxNodes                 = "max-nodes"
+	flagOnlyProblems             = "only-problems"
+	flagScopes                   = "scopes"
+	flagScopesShorthand          = "S"
+	flagSelector                 = "selector"
+	flagSelectorShorthand        = "l"
+	flagSince                    = "since"
+	flagTimeout                  = "timeout"
 )
 
 // Flags composes common configuration flag structs used in the command.
 type Flags struct {
-	AllNamespaces *bool
-	Depth         *uint
-	ExcludeTypes  *[]string
-	IncludeTypes  *[]string
-	Scopes        *[]string
+	AllNamespaces            *bool
+	ArgoCDInstanceLabel      *string
+	ArgoCDNamespace          *string
+	ArgoCDTrackingAnnotation *string
+	Depth                    *int
+	Dump                     *string
+	ExcludeKinds             *[]string
+	ExcludeRelationships     *[]string
+	ExcludeTypes             *[]string
+	HideCompleted            *bool
+	IncludeKinds             *[]string
+	IncludeTypes             *[]string
+	InferOwners              *bool
+	InvertSince              *bool
+	MaxConcurrency           *uint
+	MaxNodes                 *uint
+	OnlyProblems             *bool
+	Scopes                   *[]string
+	Selector                 *string
+	Since                    *time.Duration
+	Timeout                  *time.Duration
 }
 
 // Copy returns a copy of Flags for mutation.
@@ -42,26 +78,78 @@ func (f *Flags) AddFlags(flags *pflag.FlagSet) {
 	if f.AllNamespaces != nil {
 		flags.BoolVarP(f.AllNamespaces, flagAllNamespaces, flagAllNamespacesShorthand, *f.AllNamespaces, "If present, list object relationships across all namespaces")
 	}
+	if f.ArgoCDInstanceLabel != nil {
+		flags.StringVar(f.ArgoCDInstanceLabel, flagArgoCDInstanceLabel, *f.ArgoCDInstanceLabel, "Label set to the name of the Argo CD Application managing each object (eg. \"app.kubernetes.io/instance\"), objects aren't tracked by label if empty")
+	}
+	if f.ArgoCDNamespace != nil {
+		flags.StringVar(f.ArgoCDNamespace, flagArgoCDNamespace, *f.ArgoCDNamespace, "Namespace Argo CD is installed in, which contains the Argo CD Applications tracking objects by name only")
+	}
+	if f.ArgoCDTrackingAnnotation != nil {
+		flags.StringVar(f.ArgoCDTrackingAnnotation, flagArgoCDTrackingAnnotation, *f.ArgoCDTrackingAnnotation, "Annotation set to the Argo CD tracking ID of each object, objects aren't tracked by annotation if empty")
+	}
 	if f.Depth != nil {
-		flags.UintVarP(f.Depth, flagDepth, flagDepthShorthand, *f.Depth, "Maximum depth to find relationships")
+		flags.IntVarP(f.Depth, flagDepth, flagDepthShorthand, *f.Depth, "Maximum depth to find relationships, no limit is applied if negative")
+	}
+	if f.Dump != nil {
+		flags.StringVar(f.Dump, flagDump, *f.Dump, "If non-empty, write a snapshot of the relationship tree before any filter is applied to the provided file, which can be printed without access to the cluster with the --from-snapshot flag of the lineage command")
+	}
+	if f.ExcludeKinds != nil {
+		usage := fmt.Sprintf("Accepts a comma separated list of resource types to exclude from the relationship tree, their dependencies or dependents are attached to their nearest remaining ancestor instead. You can also use multiple flag options like --%s kind1 --%s kind2...", flagExcludeKinds, flagExcludeKinds)
+		flags.StringSliceVar(f.ExcludeKinds, flagExcludeKinds, *f.ExcludeKinds, usage)
+	}
+	if f.ExcludeRelationships != nil {
+		usage := fmt.Sprintf("Accepts a comma separated list of relationship categories to exclude from the relationship tree, along with the objects only reachable through them. One of: %s. You can also use multiple flag options like --%s selector --%s volume...", strings.Join(graph.RelationshipCategories(), "|"), flagExcludeRelationships, flagExcludeRelationships)
+		flags.StringSliceVar(f.ExcludeRelationships, flagExcludeRelationships, *f.ExcludeRelationships, usage)
 	}
 	if f.ExcludeTypes != nil {
 		usage := fmt.Sprintf("Accepts a comma separated list of resource types to exclude from relationship discovery. You can also use multiple flag options like --%s kind1 --%s kind1...", flagExcludeTypes, flagExcludeTypes)
 		flags.StringSliceVar(f.ExcludeTypes, flagExcludeTypes, *f.ExcludeTypes, usage)
 	}
+	if f.HideCompleted != nil {
+		flags.BoolVar(f.HideCompleted, flagHideCompleted, *f.HideCompleted, "If present, hide Pods that ran to completion from the relationship tree, along with the objects only related to them")
+	}
+	if f.IncludeKinds != nil {
+		usage := fmt.Sprintf("Accepts a comma separated list of resource types to only include in the relationship tree, along with the objects leading to them. You can also use multiple flag options like --%s kind1 --%s kind2...", flagIncludeKinds, flagIncludeKinds)
+		flags.StringSliceVar(f.IncludeKinds, flagIncludeKinds, *f.IncludeKinds, usage)
+	}
 	if f.IncludeTypes != nil {
 		usage := fmt.Sprintf("Accepts a comma separated list of resource types to only include in relationship discovery. You can also use multiple flag options like --%s kind1 --%s kind1...", flagIncludeTypes, flagIncludeTypes)
 		flags.StringSliceVar(f.IncludeTypes, flagIncludeTypes, *f.IncludeTypes, usage)
 	}
+	if f.InferOwners != nil {
+		flags.BoolVar(f.InferOwners, flagInferOwners, *f.InferOwners, "If present, infer the owners of ReplicaSets & Pods without owner references from their \"pod-template-hash\" label")
+	}
+	if f.InvertSince != nil {
+		flags.BoolVar(f.InvertSince, flagInvertSince, *f.InvertSince, fmt.Sprintf("If present, --%s keeps the objects created before the duration instead of the ones created within it", flagSince))
+	}
+	if f.MaxConcurrency != nil {
+		flags.UintVar(f.MaxConcurrency, flagMaxConcurrency, *f.MaxConcurrency, "Maximum number of concurrent requests to list objects from the server, no limit is applied if set to 0")
+	}
+	if f.MaxNodes != nil {
+		flags.UintVar(f.MaxNodes, flagMaxNodes, *f.MaxNodes, "Maximum number of objects in the relationship tree before aborting, no limit is applied if set to 0")
+	}
+	if f.OnlyProblems != nil {
+		flags.BoolVar(f.OnlyProblems, flagOnlyProblems, *f.OnlyProblems, "If present, only keep the objects in the relationship tree that aren't ready, along with the objects leading to them")
+	}
 	if f.Scopes != nil {
 		usage := fmt.Sprintf("Accepts a comma separated list of additional namespaces to find relationships. You can also use multiple flag options like -%s namespace1 -%s namespace2...", flagScopesShorthand, flagScopesShorthand)
 		flags.StringSliceVarP(f.Scopes, flagScopes, flagScopesShorthand, *f.Scopes, usage)
 	}
+	if f.Selector != nil {
+		flags.StringVarP(f.Selector, flagSelector, flagSelectorShorthand, *f.Selector, "Selector (label query) to filter the relationship tree on, supports '=', '==', and '!=' (e.g. -l key1=value1,key2=value2). Objects that don't match are only kept if they lead to objects that do")
+	}
+	if f.Since != nil {
+		flags.DurationVar(f.Since, flagSince, *f.Since, "If non-zero, only keep objects created within the provided duration (e.g. 10m). Objects that don't match are only kept if they lead to objects that do")
+	}
+	if f.Timeout != nil {
+		flags.DurationVar(f.Timeout, flagTimeout, *f.Timeout, "If non-zero, the maximum duration for building the relationship tree (e.g. 30s). Once exceeded, the relationship tree of the objects listed so far is printed along with an error")
+	}
 }
 
 // RegisterFlagCompletionFunc receives a *cobra.Command & register functions to
 // to provide completion for flags related to configuration.
 func (*Flags) RegisterFlagCompletionFunc(cmd *cobra.Command, f cmdutil.Factory) {
+	cmdutil.CheckErr(cmd.MarkFlagFilename(flagDump, "json"))
 	cmdutil.CheckErr(cmd.RegisterFlagCompletionFunc(
 		flagScopes,
 		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -73,16 +161,48 @@ func (*Flags) RegisterFlagCompletionFunc(cmd *cobra.Command, f cmdutil.Factory)
 // with default values set.
 func NewFlags() *Flags {
 	allNamespaces := false
-	depth := uint(0)
+	argoCDInstanceLabel := ""
+	argoCDNamespace := graph.DefaultArgoCDNamespace
+	argoCDTrackingAnnotation := graph.DefaultArgoCDTrackingAnnotation
+	depth := -1
+	dump := ""
+	excludeKinds := []string{}
+	excludeRelationships := []string{}
 	excludeTypes := []string{}
+	hideCompleted := false
+	includeKinds := []string{}
 	includeTypes := []string{}
+	inferOwners := false
+	invertSince := false
+	maxConcurrency := uint(0)
+	maxNodes := uint(10000)
+	onlyProblems := false
 	scopes := []string{}
+	selector := ""
+	since := time.Duration(0)
+	timeout := time.Duration(0)
 
 	return &Flags{
-		AllNamespaces: &allNamespaces,
-		Depth:         &depth,
-		ExcludeTypes:  &excludeTypes,
-		IncludeTypes:  &includeTypes,
-		Scopes:        &scopes,
+		AllNamespaces:            &allNamespaces,
+		ArgoCDInstanceLabel:      &argoCDInstanceLabel,
+		ArgoCDNamespace:          &argoCDNamespace,
+		ArgoCDTrackingAnnotation: &argoCDTrackingAnnotation,
+		Depth:                    &depth,
+		Dump:                     &dump,
+		ExcludeKinds:             &excludeKinds,
+		ExcludeRelationships:     &excludeRelationships,
+		ExcludeTypes:             &excludeTypes,
+		HideCompleted:            &hideCompleted,
+		IncludeKinds:             &includeKinds,
+		IncludeTypes:             &includeTypes,
+		InferOwners:              &inferOwners,
+		InvertSince:              &invertSince,
+		MaxConcurrency:           &maxConcurrency,
+		MaxNodes:                 &maxNodes,
+		OnlyProblems:             &onlyProblems,
+		Scopes:                   &scopes,
+		Selector:                 &selector,
+		Since:                    &since,
+		Timeout:                  &timeout,
 	}
 }
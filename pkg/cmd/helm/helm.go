@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"helm.sh/helm/v3/pkg/action"
@@ -12,6 +13,7 @@ import (
 	"helm.sh/helm/v3/pkg/storage"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -21,10 +23,13 @@ import (
 	"k8s.io/kubectl/pkg/util"
 	"k8s.io/kubectl/pkg/util/templates"
 
+	"github.com/tohjustin/kube-lineage/internal/cli"
 	"github.com/tohjustin/kube-lineage/internal/client"
 	"github.com/tohjustin/kube-lineage/internal/graph"
 	"github.com/tohjustin/kube-lineage/internal/log"
 	lineageprinters "github.com/tohjustin/kube-lineage/internal/printers"
+	"github.com/tohjustin/kube-lineage/internal/progress"
+	"github.com/tohjustin/kube-lineage/pkg/lineage"
 )
 
 var (
@@ -42,7 +47,7 @@ var (
 		%CMD_PATH% bar --output=wide
 
 		# List all resources associated with release named "bar", excluding event & secret resource types
-		%CMD_PATH% pv/disk --dependencies --exclude-types=ev,secret
+		%CMD_PATH% bar --exclude-types=ev,secret
 
 		# List only resources provisioned by the release named "bar"
 		%CMD_PATH% bar --depth=1`)
@@ -68,6 +73,10 @@ type CmdOptions struct {
 	Printer    lineageprinters.Interface
 	PrintFlags *lineageprinters.Flags
 
+	Selector labels.Selector
+	// MaxDepth is the maximum depth of the relationship tree to print.
+	MaxDepth uint
+
 	genericclioptions.IOStreams
 }
 
@@ -153,6 +162,16 @@ func (o *CmdOptions) Complete(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Setup selector
+	o.Selector = labels.Everything()
+	if o.Flags.Selector != nil && len(*o.Flags.Selector) > 0 {
+		o.Selector, err = labels.Parse(*o.Flags.Selector)
+		if err != nil {
+			return err
+		}
+	}
+	o.MaxDepth = cli.MaxDepth(*o.Flags.Depth)
+
 	// Setup printer
 	o.Printer, err = o.PrintFlags.ToPrinter(o.Client)
 	if err != nil {
@@ -168,20 +187,65 @@ func (o *CmdOptions) Validate() error {
 		return fmt.Errorf("release name must be specified\nSee '%s -h' for help and examples", cmdPath)
 	}
 
+	if *o.Flags.Since < 0 {
+		return fmt.Errorf("--%s must not be negative", flagSince)
+	}
+	if *o.Flags.Timeout < 0 {
+		return fmt.Errorf("--%s must not be negative", flagTimeout)
+	}
+
 	klog.V(4).Infof("Namespace: %s", o.Namespace)
 	klog.V(4).Infof("RequestRelease: %v", o.RequestRelease)
 	klog.V(4).Infof("Flags.AllNamespaces: %t", *o.Flags.AllNamespaces)
+	klog.V(4).Infof("Flags.ArgoCDInstanceLabel: %s", *o.Flags.ArgoCDInstanceLabel)
+	klog.V(4).Infof("Flags.ArgoCDNamespace: %s", *o.Flags.ArgoCDNamespace)
+	klog.V(4).Infof("Flags.ArgoCDTrackingAnnotation: %s", *o.Flags.ArgoCDTrackingAnnotation)
 	klog.V(4).Infof("Flags.Depth: %v", *o.Flags.Depth)
+	klog.V(4).Infof("Flags.Dump: %s", *o.Flags.Dump)
+	klog.V(4).Infof("Flags.ExcludeKinds: %v", *o.Flags.ExcludeKinds)
+	klog.V(4).Infof("Flags.ExcludeRelationships: %v", *o.Flags.ExcludeRelationships)
 	klog.V(4).Infof("Flags.ExcludeTypes: %v", *o.Flags.ExcludeTypes)
+	klog.V(4).Infof("Flags.HideCompleted: %t", *o.Flags.HideCompleted)
+	klog.V(4).Infof("Flags.IncludeKinds: %v", *o.Flags.IncludeKinds)
 	klog.V(4).Infof("Flags.IncludeTypes: %v", *o.Flags.IncludeTypes)
+	klog.V(4).Infof("Flags.InferOwners: %t", *o.Flags.InferOwners)
+	klog.V(4).Infof("Flags.InvertSince: %t", *o.Flags.InvertSince)
+	klog.V(4).Infof("Flags.MaxConcurrency: %v", *o.Flags.MaxConcurrency)
+	klog.V(4).Infof("Flags.MaxNodes: %v", *o.Flags.MaxNodes)
+	klog.V(4).Infof("Flags.OnlyProblems: %t", *o.Flags.OnlyProblems)
 	klog.V(4).Infof("Flags.Scopes: %v", *o.Flags.Scopes)
+	klog.V(4).Infof("Flags.Selector: %s", *o.Flags.Selector)
+	klog.V(4).Infof("Flags.Since: %v", *o.Flags.Since)
+	klog.V(4).Infof("Flags.Timeout: %v", *o.Flags.Timeout)
 	klog.V(4).Infof("ClientFlags.Context: %s", *o.ClientFlags.Context)
 	klog.V(4).Infof("ClientFlags.Namespace: %s", *o.ClientFlags.Namespace)
+	klog.V(4).Infof("ClientFlags.Impersonate: %s", *o.ClientFlags.Impersonate)
+	klog.V(4).Infof("ClientFlags.ImpersonateGroup: %v", *o.ClientFlags.ImpersonateGroup)
+	klog.V(4).Infof("PrintFlags.AgeFormat: %s", *o.PrintFlags.HumanReadableFlags.AgeFormat)
+	klog.V(4).Infof("PrintFlags.ASCII: %t", *o.PrintFlags.HumanReadableFlags.ASCII)
+	klog.V(4).Infof("PrintFlags.Collapse: %t", *o.PrintFlags.HumanReadableFlags.Collapse)
+	klog.V(4).Infof("PrintFlags.Color: %s", *o.PrintFlags.HumanReadableFlags.Color)
+	klog.V(4).Infof("PrintFlags.ColumnLabels: %v", *o.PrintFlags.HumanReadableFlags.ColumnLabels)
+	klog.V(4).Infof("PrintFlags.ConditionColumns: %v", *o.PrintFlags.HumanReadableFlags.ConditionColumns)
+	klog.V(4).Infof("PrintFlags.GroupByKind: %t", *o.PrintFlags.HumanReadableFlags.GroupByKind)
+	klog.V(4).Infof("PrintFlags.OutputFile: %s", *o.PrintFlags.OutputFile)
 	klog.V(4).Infof("PrintFlags.OutputFormat: %s", *o.PrintFlags.OutputFormat)
+	klog.V(4).Infof("PrintFlags.MaxNameWidth: %d", *o.PrintFlags.HumanReadableFlags.MaxNameWidth)
 	klog.V(4).Infof("PrintFlags.NoHeaders: %t", *o.PrintFlags.HumanReadableFlags.NoHeaders)
+	klog.V(4).Infof("PrintFlags.Quiet: %t", *o.PrintFlags.Quiet)
+	klog.V(4).Infof("PrintFlags.Reverse: %t", *o.PrintFlags.HumanReadableFlags.Reverse)
+	klog.V(4).Infof("PrintFlags.ShowAPIVersion: %t", *o.PrintFlags.HumanReadableFlags.ShowAPIVersion)
+	klog.V(4).Infof("PrintFlags.ShowConditionAge: %t", *o.PrintFlags.HumanReadableFlags.ShowConditionAge)
 	klog.V(4).Infof("PrintFlags.ShowGroup: %t", *o.PrintFlags.HumanReadableFlags.ShowGroup)
 	klog.V(4).Infof("PrintFlags.ShowLabels: %t", *o.PrintFlags.HumanReadableFlags.ShowLabels)
 	klog.V(4).Infof("PrintFlags.ShowNamespace: %t", *o.PrintFlags.HumanReadableFlags.ShowNamespace)
+	klog.V(4).Infof("PrintFlags.ShowNode: %t", *o.PrintFlags.HumanReadableFlags.ShowNode)
+	klog.V(4).Infof("PrintFlags.ShowOwner: %t", *o.PrintFlags.HumanReadableFlags.ShowOwner)
+	klog.V(4).Infof("PrintFlags.SortBy: %s", *o.PrintFlags.SortBy)
+	klog.V(4).Infof("PrintFlags.StatusConditionType: %s", *o.PrintFlags.StatusConditionType)
+	klog.V(4).Infof("PrintFlags.StatusConfig: %s", *o.PrintFlags.StatusConfig)
+	klog.V(4).Infof("PrintFlags.Summary: %t", *o.PrintFlags.Summary)
+	klog.V(4).Infof("PrintFlags.Width: %d", *o.PrintFlags.HumanReadableFlags.Width)
 
 	return nil
 }
@@ -190,6 +254,11 @@ func (o *CmdOptions) Validate() error {
 //nolint:funlen,gocognit,gocyclo
 func (o *CmdOptions) Run() error {
 	ctx := context.Background()
+	if timeout := *o.Flags.Timeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
 	// First check if Kubernetes cluster is reachable
 	if err := o.Client.IsReachable(); err != nil {
@@ -289,14 +358,36 @@ func (o *CmdOptions) Run() error {
 		namespaces = append(namespaces, *o.Flags.Scopes...)
 	}
 
-	// Fetch resources in the cluster
+	// Fetch resources in the cluster, tracking the resource types that couldn't
+	// be listed due to missing permissions
+	forbidden := map[string]struct{}{}
+	listed := client.ListedResources{}
+	// Show the number of objects listed so far on stderr, unless only the
+	// relationship tree is expected to be printed
+	var indicator *progress.Indicator
+	if !o.PrintFlags.IsQuiet() {
+		indicator = progress.Start(o.ErrOut)
+	}
 	objs, err := o.Client.List(ctx, client.ListOptions{
 		APIResourcesToExclude: excludeAPIs,
 		APIResourcesToInclude: includeAPIs,
 		Namespaces:            namespaces,
+		MaxConcurrency:        *o.Flags.MaxConcurrency,
+		ForbiddenFn: func(api client.APIResource) {
+			forbidden[api.WithGroupString()] = struct{}{}
+		},
+		ListedFn:   listed.Add,
+		ProgressFn: indicator.SetObjects,
 	})
+	indicator.Stop()
+	// Build the relationship tree from the objects listed so far if the
+	// timeout is exceeded
+	var truncatedErr error
 	if err != nil {
-		return err
+		if objs == nil || ctx.Err() == nil {
+			return err
+		}
+		truncatedErr = fmt.Errorf("%w as not every object was listed: %v", lineage.ErrTruncated, err)
 	}
 
 	// Include release & secret objects into objects to handle cases where user
@@ -316,11 +407,30 @@ func (o *CmdOptions) Run() error {
 		uids = append(uids, stgObj.GetUID())
 	}
 
+	// Infer the owners of objects without owner references
+	if *o.Flags.InferOwners {
+		graph.InferOwnerReferences(objs.Items)
+	}
+
 	// Find all dependents of the release & storage objects
 	mapper := o.Client.GetMapper()
-	nodeMap, err := graph.ResolveDependents(mapper, objs.Items, uids)
+	resolveOpts := graph.ResolveOptions{
+		GitOpsTracking: graph.GitOpsTracking{
+			ArgoCDInstanceLabel:      *o.Flags.ArgoCDInstanceLabel,
+			ArgoCDNamespace:          *o.Flags.ArgoCDNamespace,
+			ArgoCDTrackingAnnotation: *o.Flags.ArgoCDTrackingAnnotation,
+		},
+		IsListedFn: listed.Has,
+	}
+	// Abort if the relationship tree is too large, the release & storage
+	// objects are one level below the Helm release object, which isn't counted
+	if o.MaxDepth > 0 {
+		resolveOpts.MaxNodes = *o.Flags.MaxNodes
+		resolveOpts.MaxDepth = o.MaxDepth - 1
+	}
+	nodeMap, err := graph.ResolveDependents(mapper, objs.Items, uids, resolveOpts)
 	if err != nil {
-		return err
+		return cli.WithTooManyNodesHint(err)
 	}
 
 	// Add the Helm release object to the root of the relationship tree
@@ -336,9 +446,63 @@ func (o *CmdOptions) Run() error {
 	}
 	rootUID := rootNode.GetUID()
 	nodeMap[rootUID] = rootNode
+	rootUIDs := []types.UID{rootUID}
+
+	// Write the relationship tree before any filter is applied, so that filters
+	// can still be applied when reading the snapshot
+	if path := *o.Flags.Dump; len(path) > 0 {
+		if err := cli.WriteSnapshot(path, nodeMap, rootUIDs, false); err != nil {
+			return err
+		}
+	}
 
-	// Print output
-	return o.Printer.Print(o.Out, nodeMap, rootUID, *o.Flags.Depth, false)
+	// Remove relationships of excluded categories
+	excludeRSet, err := graph.ResolveRelationshipCategories(*o.Flags.ExcludeRelationships)
+	if err != nil {
+		return err
+	}
+	nodeMap.ExcludeRelationships(rootUIDs, excludeRSet, false)
+
+	// Remove objects of excluded resource types & keep only objects of
+	// included resource types
+	excludeGKSet, err := cli.ResolveGroupKindSet(o.Client, *o.Flags.ExcludeKinds)
+	if err != nil {
+		return err
+	}
+	includeGKSet, err := cli.ResolveGroupKindSet(o.Client, *o.Flags.IncludeKinds)
+	if err != nil {
+		return err
+	}
+	nodeMap.ExcludeGroupKinds(rootUIDs, excludeGKSet, false)
+	nodeMap.FilterByGroupKinds(rootUIDs, includeGKSet, false)
+
+	// Filter the relationship tree by the provided selector
+	nodeMap.FilterByLabelSelector(rootUIDs, o.Selector, false)
+
+	// Filter the relationship tree by the creation time of objects
+	if since := *o.Flags.Since; since > 0 {
+		nodeMap.FilterByCreationTime(rootUIDs, time.Now().Add(-since), *o.Flags.InvertSince, false)
+	}
+
+	// Remove Pods that ran to completion
+	if *o.Flags.HideCompleted {
+		nodeMap.HideCompletedPods(rootUIDs, false)
+	}
+
+	// Keep only the objects that aren't ready & the objects leading to them
+	if *o.Flags.OnlyProblems {
+		if err := o.PrintFlags.FilterByNotReady(nodeMap, rootUIDs, o.MaxDepth, false); err != nil {
+			return err
+		}
+	}
+
+	// Print output, followed by the resource types that couldn't be listed &
+	// the error if the relationship tree is truncated
+	if err := o.PrintFlags.PrintOutput(o.Out, o.Printer, nodeMap, rootUIDs, o.MaxDepth, false); err != nil {
+		return err
+	}
+	cli.PrintForbiddenWarning(o.ErrOut, forbidden, "the relationship tree may be incomplete")
+	return truncatedErr
 }
 
 // getManifestObjects fetches all objects found in the manifest of the provided
@@ -2,35 +2,102 @@ package lineage
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util"
 
 	"github.com/tohjustin/kube-lineage/internal/completion"
+	"github.com/tohjustin/kube-lineage/internal/graph"
 )
 
 const (
-	flagAllNamespaces          = "all-namespaces"
-	flagAllNamespacesShorthand = "A"
-	flagDependencies           = "dependencies"
-	flagDependenciesShorthand  = "D"
-	flagDepth                  = "depth"
-	flagDepthShorthand         = "d"
-	flagExcludeTypes           = "exclude-types"
-	flagIncludeTypes           = "include-types"
-	flagScopes                 = "scopes"
-	flagScopesShorthand        = "S"
+	flagAllNamespaces            = "all-namespaces"
+	flagAllNamespacesShorthand   = "A"
+	flagArgoCDInstanceLabel      = "argocd-instance-label"
+	flagArgoCDNamespace          = "argocd-namespace"
+	flagArgoCDTrackingAnnotation = "argocd-tracking-annotation"
+	flagDependencies             = "dependencies"
+	flagDependenciesShorthand    = "D"
+	flagDepth                    = "depth"
+	flagDepthShorthand           = "d"
+	flagDump                     = "dump"
+	flagEvents                   = "events"
+	flagExcludeKinds             = "exclude-kinds"
+	flagExcludeRelationships     = "exclude-relationships"
+	flagExcludeTypes             = "exclude-types"
+	flagFailOnNotReady           = "fail-on-not-ready"
+	flagFieldSelector            = "field-selector"
+	flagHideCompleted            = "hide-completed"
+	flagFilename                 = "filename"
+	flagFilenameShorthand        = "f"
+	flagFromSnapshot             = "from-snapshot"
+	flagIncludeKinds             = "include-kinds"
+	flagIncludeTypes             = "include-types"
+	flagInferOwners              = "infer-owners"
+	flagInvertSince              = "invert-since"
+	flagMaxConcurrency           = "max-concurrency"
+	flagMaxNodes                 = "max-nodes"
+	flagOnlyProblems             = "only-problems"
+	flagPrefixMatch              = "prefix-match"
+	flagRelatedContexts          = "related-contexts"
+	flagSameNamespaceOnly        = "same-namespace-only"
+	flagScopes                   = "scopes"
+	flagScopesShorthand          = "S"
+	flagSelector                 = "selector"
+	flagSelectorShorthand        = "l"
+	flagSince                    = "since"
+	flagTimeout                  = "timeout"
+	flagUID                      = "uid"
+	flagUseCache                 = "use-cache"
+	flagWatch                    = "watch"
+	flagWatchShorthand           = "w"
+	flagWatchInterval            = "watch-interval"
 )
 
+// failOnNotReadyAllKinds is the value of --fail-on-not-ready when no resource
+// types are provided, which considers objects of every resource type.
+const failOnNotReadyAllKinds = "*"
+
 // Flags composes common configuration flag structs used in the command.
 type Flags struct {
-	AllNamespaces *bool
-	Dependencies  *bool
-	Depth         *uint
-	ExcludeTypes  *[]string
-	IncludeTypes  *[]string
-	Scopes        *[]string
+	AllNamespaces            *bool
+	ArgoCDInstanceLabel      *string
+	ArgoCDNamespace          *string
+	ArgoCDTrackingAnnotation *string
+	Dependencies             *bool
+	Depth                    *int
+	Dump                     *string
+	Events                   *bool
+	ExcludeKinds             *[]string
+	ExcludeRelationships     *[]string
+	ExcludeTypes             *[]string
+	FailOnNotReady           *[]string
+	FieldSelector            *string
+	HideCompleted            *bool
+	Filenames                *[]string
+	FromSnapshot             *string
+	IncludeKinds             *[]string
+	IncludeTypes             *[]string
+	InferOwners              *bool
+	InvertSince              *bool
+	MaxConcurrency           *uint
+	MaxNodes                 *uint
+	OnlyProblems             *bool
+	PrefixMatch              *bool
+	RelatedContexts          *[]string
+	SameNamespaceOnly        *bool
+	Scopes                   *[]string
+	Selector                 *string
+	Since                    *time.Duration
+	Timeout                  *time.Duration
+	UID                      *string
+	UseCache                 *bool
+	Watch                    *bool
+	WatchInterval            *time.Duration
 }
 
 // Copy returns a copy of Flags for mutation.
@@ -45,52 +112,218 @@ func (f *Flags) AddFlags(flags *pflag.FlagSet) {
 	if f.AllNamespaces != nil {
 		flags.BoolVarP(f.AllNamespaces, flagAllNamespaces, flagAllNamespacesShorthand, *f.AllNamespaces, "If present, list object relationships across all namespaces")
 	}
+	if f.ArgoCDInstanceLabel != nil {
+		flags.StringVar(f.ArgoCDInstanceLabel, flagArgoCDInstanceLabel, *f.ArgoCDInstanceLabel, "Label set to the name of the Argo CD Application managing each object (eg. \"app.kubernetes.io/instance\"), objects aren't tracked by label if empty")
+	}
+	if f.ArgoCDNamespace != nil {
+		flags.StringVar(f.ArgoCDNamespace, flagArgoCDNamespace, *f.ArgoCDNamespace, "Namespace Argo CD is installed in, which contains the Argo CD Applications tracking objects by name only")
+	}
+	if f.ArgoCDTrackingAnnotation != nil {
+		flags.StringVar(f.ArgoCDTrackingAnnotation, flagArgoCDTrackingAnnotation, *f.ArgoCDTrackingAnnotation, "Annotation set to the Argo CD tracking ID of each object, objects aren't tracked by annotation if empty")
+	}
 	if f.Dependencies != nil {
 		flags.BoolVarP(f.Dependencies, flagDependencies, flagDependenciesShorthand, *f.Dependencies, "If present, list object dependencies instead of dependents")
 	}
 	if f.Depth != nil {
-		flags.UintVarP(f.Depth, flagDepth, flagDepthShorthand, *f.Depth, "Maximum depth to find relationships")
+		flags.IntVarP(f.Depth, flagDepth, flagDepthShorthand, *f.Depth, "Maximum depth to find relationships, no limit is applied if negative")
+	}
+	if f.Dump != nil {
+		flags.StringVar(f.Dump, flagDump, *f.Dump, fmt.Sprintf("If non-empty, write a snapshot of the relationship tree before any filter is applied to the provided file, which can be printed without access to the cluster with --%s", flagFromSnapshot))
+	}
+	if f.Events != nil {
+		flags.BoolVar(f.Events, flagEvents, *f.Events, "If present, attach the events of every object in the relationship tree to the object they reference")
+	}
+	if f.ExcludeKinds != nil {
+		usage := fmt.Sprintf("Accepts a comma separated list of resource types to exclude from the relationship tree, their dependencies or dependents are attached to their nearest remaining ancestor instead. You can also use multiple flag options like --%s kind1 --%s kind2...", flagExcludeKinds, flagExcludeKinds)
+		flags.StringSliceVar(f.ExcludeKinds, flagExcludeKinds, *f.ExcludeKinds, usage)
+	}
+	if f.ExcludeRelationships != nil {
+		usage := fmt.Sprintf("Accepts a comma separated list of relationship categories to exclude from the relationship tree, along with the objects only reachable through them. One of: %s. You can also use multiple flag options like --%s selector --%s volume...", strings.Join(graph.RelationshipCategories(), "|"), flagExcludeRelationships, flagExcludeRelationships)
+		flags.StringSliceVar(f.ExcludeRelationships, flagExcludeRelationships, *f.ExcludeRelationships, usage)
 	}
 	if f.ExcludeTypes != nil {
 		usage := fmt.Sprintf("Accepts a comma separated list of resource types to exclude from relationship discovery. You can also use multiple flag options like --%s kind1 --%s kind1...", flagExcludeTypes, flagExcludeTypes)
 		flags.StringSliceVar(f.ExcludeTypes, flagExcludeTypes, *f.ExcludeTypes, usage)
 	}
+	if f.FailOnNotReady != nil {
+		usage := fmt.Sprintf("If present, exit with a non-zero code if any object in the relationship tree isn't ready. Optionally accepts a comma separated list of resource types to only consider (e.g. --%s=pods,deployments)", flagFailOnNotReady)
+		flags.StringSliceVar(f.FailOnNotReady, flagFailOnNotReady, *f.FailOnNotReady, usage)
+		flags.Lookup(flagFailOnNotReady).NoOptDefVal = failOnNotReadyAllKinds
+	}
+	if f.FieldSelector != nil {
+		flags.StringVar(f.FieldSelector, flagFieldSelector, *f.FieldSelector, "Selector (field query) to filter the objects of the provided resource type to find relationships for when no names are provided, supports '=', '==', and '!=' (e.g. --field-selector key1=value1,key2=value2). The server only supports a limited number of field queries per type")
+	}
+	if f.Filenames != nil {
+		usage := fmt.Sprintf("Filename, directory, or URL to files containing the objects to find relationships for, use \"-\" to read from stdin. You can also use multiple flag options like -%s file1 -%s file2...", flagFilenameShorthand, flagFilenameShorthand)
+		flags.StringSliceVarP(f.Filenames, flagFilename, flagFilenameShorthand, *f.Filenames, usage)
+	}
+	if f.FromSnapshot != nil {
+		flags.StringVar(f.FromSnapshot, flagFromSnapshot, *f.FromSnapshot, fmt.Sprintf("If non-empty, print the relationship tree from the provided snapshot file written with --%s instead of the cluster. The dependencies or dependents are listed as they were when the snapshot was written", flagDump))
+	}
+	if f.HideCompleted != nil {
+		flags.BoolVar(f.HideCompleted, flagHideCompleted, *f.HideCompleted, "If present, hide Pods that ran to completion from the relationship tree, along with the objects only related to them")
+	}
+	if f.IncludeKinds != nil {
+		usage := fmt.Sprintf("Accepts a comma separated list of resource types to only include in the relationship tree, along with the objects leading to them. You can also use multiple flag options like --%s kind1 --%s kind2...", flagIncludeKinds, flagIncludeKinds)
+		flags.StringSliceVar(f.IncludeKinds, flagIncludeKinds, *f.IncludeKinds, usage)
+	}
 	if f.IncludeTypes != nil {
 		usage := fmt.Sprintf("Accepts a comma separated list of resource types to only include in relationship discovery. You can also use multiple flag options like --%s kind1 --%s kind1...", flagIncludeTypes, flagIncludeTypes)
 		flags.StringSliceVar(f.IncludeTypes, flagIncludeTypes, *f.IncludeTypes, usage)
 	}
+	if f.InferOwners != nil {
+		flags.BoolVar(f.InferOwners, flagInferOwners, *f.InferOwners, "If present, infer the owners of ReplicaSets & Pods without owner references from their \"pod-template-hash\" label")
+	}
+	if f.InvertSince != nil {
+		flags.BoolVar(f.InvertSince, flagInvertSince, *f.InvertSince, fmt.Sprintf("If present, --%s keeps the objects created before the duration instead of the ones created within it", flagSince))
+	}
+	if f.MaxConcurrency != nil {
+		flags.UintVar(f.MaxConcurrency, flagMaxConcurrency, *f.MaxConcurrency, "Maximum number of concurrent requests to list objects from the server, no limit is applied if set to 0")
+	}
+	if f.MaxNodes != nil {
+		flags.UintVar(f.MaxNodes, flagMaxNodes, *f.MaxNodes, "Maximum number of objects in the relationship tree before aborting, no limit is applied if set to 0")
+	}
+	if f.OnlyProblems != nil {
+		flags.BoolVar(f.OnlyProblems, flagOnlyProblems, *f.OnlyProblems, "If present, only keep the objects in the relationship tree that aren't ready, along with the objects leading to them")
+	}
+	if f.PrefixMatch != nil {
+		flags.BoolVar(f.PrefixMatch, flagPrefixMatch, *f.PrefixMatch, "If present, find relationships for every object of the provided resource type whose name starts with one of the provided names (e.g. a name without its generated suffix)")
+	}
+	if f.RelatedContexts != nil {
+		usage := fmt.Sprintf("Accepts a comma separated list of kubeconfig contexts of other clusters to fetch the objects referenced in them (e.g. the Nodes of Cluster API workload clusters), matched by the name of either the context or its cluster. You can also use multiple flag options like --%s context1 --%s context2...", flagRelatedContexts, flagRelatedContexts)
+		flags.StringSliceVar(f.RelatedContexts, flagRelatedContexts, *f.RelatedContexts, usage)
+	}
+	if f.SameNamespaceOnly != nil {
+		flags.BoolVar(f.SameNamespaceOnly, flagSameNamespaceOnly, *f.SameNamespaceOnly, "If present, only find relationships with objects in the namespaces of the requested objects & with cluster-scoped objects, even for relationships that would otherwise cross namespaces")
+	}
 	if f.Scopes != nil {
 		usage := fmt.Sprintf("Accepts a comma separated list of additional namespaces to find relationships. You can also use multiple flag options like -%s namespace1 -%s namespace2...", flagScopesShorthand, flagScopesShorthand)
 		flags.StringSliceVarP(f.Scopes, flagScopes, flagScopesShorthand, *f.Scopes, usage)
 	}
+	if f.Selector != nil {
+		flags.StringVarP(f.Selector, flagSelector, flagSelectorShorthand, *f.Selector, "Selector (label query) to filter the relationship tree on, supports '=', '==', and '!=' (e.g. -l key1=value1,key2=value2). Objects that don't match are only kept if they lead to objects that do")
+	}
+	if f.Since != nil {
+		flags.DurationVar(f.Since, flagSince, *f.Since, "If non-zero, only keep objects created within the provided duration (e.g. 10m). Objects that don't match are only kept if they lead to objects that do")
+	}
+	if f.Timeout != nil {
+		flags.DurationVar(f.Timeout, flagTimeout, *f.Timeout, "If non-zero, the maximum duration for building the relationship tree (e.g. 30s). Once exceeded, the relationship tree of the objects listed so far is printed along with an error")
+	}
+	if f.UID != nil {
+		flags.StringVar(f.UID, flagUID, *f.UID, "If non-empty, find relationships for the object with the provided UID instead of a name, searching objects of the provided resource type if any or of every resource type otherwise")
+	}
+	if f.UseCache != nil {
+		flags.BoolVar(f.UseCache, flagUseCache, *f.UseCache, fmt.Sprintf("If present, keep the listed objects in informer caches that are kept up to date by watches, so that refreshes with --%s read objects from the caches instead of listing them from the server", flagWatch))
+	}
+	if f.Watch != nil {
+		flags.BoolVarP(f.Watch, flagWatch, flagWatchShorthand, *f.Watch, fmt.Sprintf("If present, refresh the relationship tree every --%s until interrupted", flagWatchInterval))
+	}
+	if f.WatchInterval != nil {
+		flags.DurationVar(f.WatchInterval, flagWatchInterval, *f.WatchInterval, fmt.Sprintf("Interval between refreshes of the relationship tree when using --%s", flagWatch))
+	}
 }
 
 // RegisterFlagCompletionFunc receives a *cobra.Command & register functions to
-// to provide completion for flags related to configuration.
-func (*Flags) RegisterFlagCompletionFunc(cmd *cobra.Command, f cmdutil.Factory) {
-	cmdutil.CheckErr(cmd.RegisterFlagCompletionFunc(
-		flagScopes,
-		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			return completion.GetScopeNamespaceList(f, cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
-		}))
+// to provide completion for flags related to configuration. Like AddFlags, only
+// flags that are set are registered.
+func (f *Flags) RegisterFlagCompletionFunc(cmd *cobra.Command, factory cmdutil.Factory) {
+	if f.Dump != nil {
+		cmdutil.CheckErr(cmd.MarkFlagFilename(flagDump, "json"))
+	}
+	if f.Filenames != nil {
+		cmdutil.CheckErr(cmd.MarkFlagFilename(flagFilename, "json", "yaml", "yml"))
+	}
+	if f.FromSnapshot != nil {
+		cmdutil.CheckErr(cmd.MarkFlagFilename(flagFromSnapshot, "json"))
+	}
+	if f.RelatedContexts != nil {
+		cmdutil.CheckErr(cmd.RegisterFlagCompletionFunc(
+			flagRelatedContexts,
+			func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return util.ListContextsInConfig(toComplete), cobra.ShellCompDirectiveNoFileComp
+			}))
+	}
+	if f.Scopes != nil {
+		cmdutil.CheckErr(cmd.RegisterFlagCompletionFunc(
+			flagScopes,
+			func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return completion.GetScopeNamespaceList(factory, cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+			}))
+	}
 }
 
 // NewFlags returns flags associated with command configuration, with default
 // values set.
 func NewFlags() *Flags {
 	allNamespaces := false
+	argoCDInstanceLabel := ""
+	argoCDNamespace := graph.DefaultArgoCDNamespace
+	argoCDTrackingAnnotation := graph.DefaultArgoCDTrackingAnnotation
 	dependencies := false
-	depth := uint(0)
+	depth := -1
+	dump := ""
+	events := false
+	excludeKinds := []string{}
+	excludeRelationships := []string{}
 	excludeTypes := []string{}
+	failOnNotReady := []string{}
+	fieldSelector := ""
+	hideCompleted := false
+	filenames := []string{}
+	fromSnapshot := ""
+	includeKinds := []string{}
 	includeTypes := []string{}
+	inferOwners := false
+	invertSince := false
+	maxConcurrency := uint(0)
+	maxNodes := uint(10000)
+	onlyProblems := false
+	prefixMatch := false
+	relatedContexts := []string{}
+	sameNamespaceOnly := false
 	scopes := []string{}
+	selector := ""
+	since := time.Duration(0)
+	timeout := time.Duration(0)
+	uid := ""
+	useCache := false
+	watch := false
+	watchInterval := 2 * time.Second
 
 	return &Flags{
-		AllNamespaces: &allNamespaces,
-		Dependencies:  &dependencies,
-		Depth:         &depth,
-		ExcludeTypes:  &excludeTypes,
-		IncludeTypes:  &includeTypes,
-		Scopes:        &scopes,
+		AllNamespaces:            &allNamespaces,
+		ArgoCDInstanceLabel:      &argoCDInstanceLabel,
+		ArgoCDNamespace:          &argoCDNamespace,
+		ArgoCDTrackingAnnotation: &argoCDTrackingAnnotation,
+		Dependencies:             &dependencies,
+		Depth:                    &depth,
+		Dump:                     &dump,
+		Events:                   &events,
+		ExcludeKinds:             &excludeKinds,
+		ExcludeRelationships:     &excludeRelationships,
+		ExcludeTypes:             &excludeTypes,
+		FailOnNotReady:           &failOnNotReady,
+		FieldSelector:            &fieldSelector,
+		HideCompleted:            &hideCompleted,
+		Filenames:                &filenames,
+		FromSnapshot:             &fromSnapshot,
+		IncludeKinds:             &includeKinds,
+		IncludeTypes:             &includeTypes,
+		InferOwners:              &inferOwners,
+		InvertSince:              &invertSince,
+		MaxConcurrency:           &maxConcurrency,
+		MaxNodes:                 &maxNodes,
+		OnlyProblems:             &onlyProblems,
+		PrefixMatch:              &prefixMatch,
+		RelatedContexts:          &relatedContexts,
+		SameNamespaceOnly:        &sameNamespaceOnly,
+		Scopes:                   &scopes,
+		Selector:                 &selector,
+		Since:                    &since,
+		Timeout:                  &timeout,
+		UID:                      &uid,
+		UseCache:                 &useCache,
+		Watch:                    &watch,
+		WatchInterval:            &watchInterval,
 	}
 }
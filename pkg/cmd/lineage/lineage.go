@@ -1,29 +1,45 @@
 package lineage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/klog/v2"
 	"k8s.io/kubectl/pkg/cmd/get"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util"
 	"k8s.io/kubectl/pkg/util/templates"
 
+	"github.com/tohjustin/kube-lineage/internal/cli"
 	"github.com/tohjustin/kube-lineage/internal/client"
 	"github.com/tohjustin/kube-lineage/internal/graph"
 	"github.com/tohjustin/kube-lineage/internal/log"
 	lineageprinters "github.com/tohjustin/kube-lineage/internal/printers"
+	"github.com/tohjustin/kube-lineage/internal/progress"
+	"github.com/tohjustin/kube-lineage/pkg/lineage"
 )
 
+// clearScreen is the ANSI escape sequence that moves the cursor to the top-left
+// corner & clears the screen.
+const clearScreen = "\x1b[H\x1b[2J"
+
 var (
 	cmdPath    string
 	cmdName    = "lineage"
-	cmdUse     = "%CMD% (TYPE[.VERSION][.GROUP] [NAME] | TYPE[.VERSION][.GROUP]/NAME) [flags]"
+	cmdUse     = "%CMD% (TYPE[.VERSION][.GROUP] [NAME ...] | TYPE[.VERSION][.GROUP]/NAME ... | [TYPE[.VERSION][.GROUP]] --uid UID | -f FILENAME | --from-snapshot FILENAME) [flags]"
 	cmdExample = templates.Examples(`
 		# List all dependents of the deployment named "bar" in the current namespace
 		%CMD_PATH% deployments bar
@@ -31,40 +47,120 @@ var (
 		# List all dependents of the cronjob named "bar" in namespace "foo"
 		%CMD_PATH% cronjobs.batch/bar --namespace=foo
 
+		# List all dependents of the deployments named "foo" & "bar" in the current namespace
+		%CMD_PATH% deployments foo bar
+
+		# List all dependencies of the running pods in the current namespace
+		%CMD_PATH% pods --field-selector=status.phase=Running --dependencies
+
+		# List all dependents of the pods whose names start with "web-" in the current namespace
+		%CMD_PATH% pods web- --prefix-match
+
+		# List all dependencies of the deployment named "foo" & the service named "bar"
+		%CMD_PATH% deploy/foo svc/bar --dependencies
+
 		# List all dependents of the node named "k3d-dev-server" & the corresponding relationship type(s)
 		%CMD_PATH% node/k3d-dev-server --output=wide
 
-		# List all dependents of the persistentvolume named "disk", excluding event & secret resource types
+		# List all pods scheduled on the node named "k3d-dev-server" along with their controllers
+		%CMD_PATH% node/k3d-dev-server --depth 1 --show-owner
+
+		# List the service & pods serving the apiservice named "v1beta1.metrics.k8s.io"
+		%CMD_PATH% apiservice/v1beta1.metrics.k8s.io --dependencies
+
+		# List the node provisioned by the cluster api machine named "workload-1-md-0-7f8d9" in the workload cluster of the "workload-1-admin@workload-1" context
+		%CMD_PATH% machines.cluster.x-k8s.io/workload-1-md-0-7f8d9 --related-contexts workload-1-admin@workload-1
+
+		# List all dependents of the deployment named "bar" along with the time since each object became (un)ready
+		%CMD_PATH% deploy/bar --show-condition-age
+
+		# List all dependents of the deployment named "bar" with the values of the "version" & "pod-template-hash" labels as columns
+		%CMD_PATH% deploy/bar -L version,pod-template-hash
+
+		# List all dependents of the deployment named "bar", collapsing its pods that share the same status into a single row
+		%CMD_PATH% deployments bar --collapse
+
+		# Stream all dependents of the node named "k3d-dev-server" as one json object per line
+		%CMD_PATH% node/k3d-dev-server --output=jsonl
+
+		# List all dependencies of the persistentvolume named "disk", excluding event & secret resource types
 		%CMD_PATH% pv/disk --dependencies --exclude-types=ev,secret
 
+		# List all dependents of the deployment named "bar", ignoring relationships inferred from label selectors or volumes
+		%CMD_PATH% deploy/bar --exclude-relationships=selector,volume
+
+		# List all objects deployed by the Argo CD application named "bar", which tracks its objects with a custom label
+		%CMD_PATH% applications.argoproj.io/bar --namespace=argocd --all-namespaces --argocd-instance-label=example.com/app
+
+		# List all dependents of the object with the UID "4e2bd2b8-5c16-4a3c-8d4e-6e0bde9f7d0a", found by searching every resource type
+		%CMD_PATH% --uid=4e2bd2b8-5c16-4a3c-8d4e-6e0bde9f7d0a
+
+		# List all dependencies of the pod with the UID "4e2bd2b8-5c16-4a3c-8d4e-6e0bde9f7d0a"
+		%CMD_PATH% pods --uid=4e2bd2b8-5c16-4a3c-8d4e-6e0bde9f7d0a --dependencies
+
 		# List all dependencies of the pod named "bar-5cc79d4bf5-xgvkc"
 		%CMD_PATH% pod.v1. bar-5cc79d4bf5-xgvkc --dependencies
 
+		# List all dependents of the deployment named "bar", grouping the dependents of each object by kind
+		%CMD_PATH% deploy/bar --group-by-kind
+
 		# List all dependencies of the serviceaccount named "default" in the current namespace, grouped by resource type
-		%CMD_PATH% sa/default --dependencies --output=split`)
+		%CMD_PATH% sa/default --dependencies --output=split
+
+		# List all dependents of the deployment named "bar" as seen by the user "jane" in the group "developers"
+		%CMD_PATH% deploy/bar --as=jane --as-group=developers
+
+		# Watch all dependents of the deployment named "bar", refreshing every 5 seconds
+		%CMD_PATH% deploy/bar --watch --watch-interval=5s
+
+		# Watch all dependents of the deployment named "bar", reading objects from informer caches on refreshes
+		%CMD_PATH% deploy/bar --watch --use-cache
+
+		# List all dependents of the objects defined in manifest.yaml
+		%CMD_PATH% -f manifest.yaml
+
+		# List all dependencies of the objects defined in a manifest passed into stdin
+		cat manifest.yaml | %CMD_PATH% -f - --dependencies
+
+		# Save a snapshot of all dependents of the deployment named "bar" & print it later without access to the cluster
+		%CMD_PATH% deploy/bar --dump=bar.json
+		%CMD_PATH% --from-snapshot=bar.json --output=wide`)
 	cmdShort = "Display all dependencies or dependents of a Kubernetes object"
 	cmdLong  = templates.LongDesc(`
-		Display all dependencies or dependents of a Kubernetes object.
+		Display all dependencies or dependents of one or more Kubernetes objects.
 
 		TYPE is a Kubernetes resource. Shortcuts and groups will be resolved.
-		NAME is the name of a particular Kubernetes resource.`)
+		NAME is the name of a particular Kubernetes resource.
+		FILENAME is a file, directory or URL containing the Kubernetes objects.
+		Relationships are listed for each object in the order they're defined.
+		Objects shared by the relationships of multiple requested objects are
+		only listed under the first requested object they're found in.`)
 )
 
 // CmdOptions contains all the options for running the lineage command.
 type CmdOptions struct {
-	// RequestType represents the type of the requested object.
-	RequestType string
-	// RequestName represents the name of the requested object.
-	RequestName string
-	Flags       *Flags
+	// RequestObjects represents the requested objects, either provided as
+	// arguments or read from the provided files.
+	RequestObjects []lineage.ObjectRef
+	// Snapshot represents the content of the snapshot file to read the
+	// relationship tree from instead of the cluster.
+	Snapshot []byte
+	Flags    *Flags
 
 	Namespace   string
 	Client      client.Interface
 	ClientFlags *client.Flags
+	// RelatedClients maps the names of the related contexts & of their
+	// clusters to the client connecting to them.
+	RelatedClients map[string]client.Interface
 
 	Printer    lineageprinters.Interface
 	PrintFlags *lineageprinters.Flags
 
+	Selector labels.Selector
+	// MaxDepth is the maximum depth of the relationship tree to print.
+	MaxDepth uint
+
 	genericclioptions.IOStreams
 }
 
@@ -92,7 +188,7 @@ func NewCmd(streams genericclioptions.IOStreams, name, parentCmdPath string) *co
 		Example:               strings.ReplaceAll(cmdExample, "%CMD_PATH%", cmdPath),
 		Short:                 cmdShort,
 		Long:                  cmdLong,
-		Args:                  cobra.MaximumNArgs(2),
+		Args:                  cobra.ArbitraryArgs,
 		DisableFlagsInUseLine: true,
 		DisableSuggestions:    true,
 		SilenceUsage:          true,
@@ -104,10 +200,10 @@ func NewCmd(streams genericclioptions.IOStreams, name, parentCmdPath string) *co
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			var comps []string
-			switch len(args) {
-			case 0:
+			switch {
+			case len(args) == 0:
 				comps = compGetResourceList(o, toComplete)
-			case 1:
+			case !strings.Contains(args[0], "/"):
 				comps = get.CompGetResource(f, cmd, args[0], toComplete)
 			}
 			return comps, cobra.ShellCompDirectiveNoFileComp
@@ -131,17 +227,25 @@ func NewCmd(streams genericclioptions.IOStreams, name, parentCmdPath string) *co
 func (o *CmdOptions) Complete(cmd *cobra.Command, args []string) error {
 	var err error
 
-	switch len(args) {
-	case 1:
-		resourceTokens := strings.SplitN(args[0], "/", 2)
-		if len(resourceTokens) != 2 {
-			return fmt.Errorf("arguments in <resource>/<name> form must have a single resource and name\nSee '%s -h' for help and examples", cmdPath)
+	// Read the relationship tree from the provided snapshot, which doesn't
+	// require access to the cluster
+	if path := *o.Flags.FromSnapshot; len(path) > 0 {
+		if len(args) > 0 || len(*o.Flags.Filenames) > 0 {
+			return fmt.Errorf("resource must not be specified together with --%s\nSee '%s -h' for help and examples", flagFromSnapshot, cmdPath)
+		}
+		o.Snapshot, err = os.ReadFile(path)
+		if err != nil {
+			return err
 		}
-		o.RequestType = resourceTokens[0]
-		o.RequestName = resourceTokens[1]
-	case 2:
-		o.RequestType = args[0]
-		o.RequestName = args[1]
+		_, _, dependencies, err := lineage.ReadSnapshot(bytes.NewReader(o.Snapshot))
+		if err != nil {
+			return err
+		}
+		if cmd.Flags().Changed(flagDependencies) && *o.Flags.Dependencies != dependencies {
+			return fmt.Errorf("--%s=%t must not be specified together with --%s of a snapshot written with --%s=%t", flagDependencies, *o.Flags.Dependencies, flagFromSnapshot, flagDependencies, dependencies)
+		}
+		*o.Flags.Dependencies = dependencies
+		return o.completeSelectorAndPrinter()
 	}
 
 	// Setup client
@@ -149,11 +253,70 @@ func (o *CmdOptions) Complete(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	o.Client, err = o.ClientFlags.ToClient()
+	// Informers backing the cache run until the command exits
+	if *o.Flags.UseCache {
+		o.Client, err = o.ClientFlags.ToCachedClient(wait.NeverStop)
+	} else {
+		o.Client, err = o.ClientFlags.ToClient()
+	}
+	if err != nil {
+		return err
+	}
+	if len(*o.Flags.RelatedContexts) > 0 {
+		o.RelatedClients, err = o.completeRelatedClients()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Determine the requested objects, either from the provided arguments or
+	// from the provided files
+	if len(args) > 0 && len(*o.Flags.Filenames) > 0 {
+		return fmt.Errorf("resource must not be specified together with --%s\nSee '%s -h' for help and examples", flagFilename, cmdPath)
+	}
+	if len(*o.Flags.Filenames) > 0 && len(*o.Flags.FieldSelector) > 0 {
+		return fmt.Errorf("--%s must not be specified together with --%s\nSee '%s -h' for help and examples", flagFieldSelector, flagFilename, cmdPath)
+	}
+	if len(*o.Flags.UID) > 0 && (len(*o.Flags.Filenames) > 0 || len(*o.Flags.FieldSelector) > 0) {
+		return fmt.Errorf("--%s must not be specified together with --%s or --%s\nSee '%s -h' for help and examples", flagUID, flagFilename, flagFieldSelector, cmdPath)
+	}
+	if *o.Flags.PrefixMatch && (len(*o.Flags.UID) > 0 || len(*o.Flags.Filenames) > 0 || len(*o.Flags.FieldSelector) > 0) {
+		return fmt.Errorf("--%s must not be specified together with --%s, --%s or --%s\nSee '%s -h' for help and examples", flagPrefixMatch, flagUID, flagFilename, flagFieldSelector, cmdPath)
+	}
+	switch {
+	case len(*o.Flags.UID) > 0:
+		o.RequestObjects, err = o.findRequestObjectByUID(args)
+	case len(*o.Flags.Filenames) > 0:
+		o.RequestObjects, err = o.readRequestObjects()
+	case len(*o.Flags.FieldSelector) > 0:
+		o.RequestObjects, err = o.listRequestObjects(args)
+	case *o.Flags.PrefixMatch:
+		o.RequestObjects, err = o.matchRequestObjectsByPrefix(args)
+	default:
+		o.RequestObjects, err = o.parseRequestObjects(args)
+	}
 	if err != nil {
 		return err
 	}
 
+	return o.completeSelectorAndPrinter()
+}
+
+// completeSelectorAndPrinter completes the selector & printer options for the
+// lineage command.
+func (o *CmdOptions) completeSelectorAndPrinter() error {
+	var err error
+
+	// Setup selector
+	o.Selector = labels.Everything()
+	if o.Flags.Selector != nil && len(*o.Flags.Selector) > 0 {
+		o.Selector, err = labels.Parse(*o.Flags.Selector)
+		if err != nil {
+			return err
+		}
+	}
+	o.MaxDepth = cli.MaxDepth(*o.Flags.Depth)
+
 	// Setup printer
 	o.Printer, err = o.PrintFlags.ToPrinter(o.Client)
 	if err != nil {
@@ -163,117 +326,574 @@ func (o *CmdOptions) Complete(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// completeRelatedClients returns the clients connecting to the related
+// contexts, keyed by the name of both each context & its cluster so that
+// references to objects in other clusters (eg. the cluster name of Cluster API
+// Machines) can be matched to them. Contexts provided first take precedence.
+func (o *CmdOptions) completeRelatedClients() (map[string]client.Interface, error) {
+	rawConfig, err := o.ClientFlags.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	clients := map[string]client.Interface{}
+	for _, name := range *o.Flags.RelatedContexts {
+		kubeContext, ok := rawConfig.Contexts[name]
+		if !ok {
+			return nil, fmt.Errorf("context \"%s\" provided with --%s not found in kubeconfig", name, flagRelatedContexts)
+		}
+		c, err := o.ClientFlags.ForContext(name).ToClient()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range []string{name, kubeContext.Cluster} {
+			if _, ok := clients[key]; !ok && len(key) > 0 {
+				clients[key] = c
+			}
+		}
+	}
+	return clients, nil
+}
+
 // Validate validates all the required options for the lineage command.
 func (o *CmdOptions) Validate() error {
-	if len(o.RequestType) == 0 || len(o.RequestName) == 0 {
+	if o.Snapshot != nil {
+		if len(*o.Flags.FieldSelector) > 0 {
+			return fmt.Errorf("--%s must not be specified together with --%s", flagFieldSelector, flagFromSnapshot)
+		}
+		if len(*o.Flags.ExcludeKinds) > 0 || len(*o.Flags.IncludeKinds) > 0 {
+			return fmt.Errorf("--%s & --%s require access to the cluster & must not be specified together with --%s", flagExcludeKinds, flagIncludeKinds, flagFromSnapshot)
+		}
+		if *o.Flags.SameNamespaceOnly {
+			return fmt.Errorf("--%s must not be specified together with --%s", flagSameNamespaceOnly, flagFromSnapshot)
+		}
+		if len(*o.Flags.UID) > 0 {
+			return fmt.Errorf("--%s must not be specified together with --%s", flagUID, flagFromSnapshot)
+		}
+		if *o.Flags.PrefixMatch {
+			return fmt.Errorf("--%s must not be specified together with --%s", flagPrefixMatch, flagFromSnapshot)
+		}
+		if len(*o.Flags.RelatedContexts) > 0 {
+			return fmt.Errorf("--%s must not be specified together with --%s", flagRelatedContexts, flagFromSnapshot)
+		}
+		if kinds := *o.Flags.FailOnNotReady; len(kinds) > 0 && !failOnNotReadyAll(kinds) {
+			return fmt.Errorf("resource types of --%s require access to the cluster & must not be specified together with --%s", flagFailOnNotReady, flagFromSnapshot)
+		}
+	} else if len(o.RequestObjects) == 0 {
+		if len(*o.Flags.Filenames) > 0 {
+			return fmt.Errorf("no objects found in the provided files")
+		}
+		if len(*o.Flags.FieldSelector) > 0 {
+			return fmt.Errorf("no objects found matching the provided field selector")
+		}
 		return fmt.Errorf("resource must be specified as <resource> <name> or <resource>/<name>\nSee '%s -h' for help and examples", cmdPath)
 	}
 
+	if *o.Flags.Watch && *o.Flags.WatchInterval <= 0 {
+		return fmt.Errorf("--%s must be greater than 0", flagWatchInterval)
+	}
+
+	if *o.Flags.UseCache && !*o.Flags.Watch {
+		return fmt.Errorf("--%s must be specified together with --%s", flagUseCache, flagWatch)
+	}
+	if *o.Flags.Watch && len(*o.PrintFlags.OutputFile) > 0 {
+		return fmt.Errorf("--%s must not be specified together with --%s", lineageprinters.FlagOutputFile, flagWatch)
+	}
+	if *o.Flags.Watch && len(*o.Flags.FailOnNotReady) > 0 {
+		return fmt.Errorf("--%s must not be specified together with --%s", flagFailOnNotReady, flagWatch)
+	}
+	if *o.Flags.SameNamespaceOnly && (*o.Flags.AllNamespaces || len(*o.Flags.Scopes) > 0) {
+		return fmt.Errorf("--%s must not be specified together with --%s or --%s", flagSameNamespaceOnly, flagAllNamespaces, flagScopes)
+	}
+	if *o.Flags.Since < 0 {
+		return fmt.Errorf("--%s must not be negative", flagSince)
+	}
+	if *o.Flags.Timeout < 0 {
+		return fmt.Errorf("--%s must not be negative", flagTimeout)
+	}
+
 	klog.V(4).Infof("Namespace: %s", o.Namespace)
-	klog.V(4).Infof("RequestType: %v", o.RequestType)
-	klog.V(4).Infof("RequestName: %v", o.RequestName)
+	klog.V(4).Infof("RequestObjects: %v", o.RequestObjects)
 	klog.V(4).Infof("Flags.AllNamespaces: %t", *o.Flags.AllNamespaces)
+	klog.V(4).Infof("Flags.ArgoCDInstanceLabel: %s", *o.Flags.ArgoCDInstanceLabel)
+	klog.V(4).Infof("Flags.ArgoCDNamespace: %s", *o.Flags.ArgoCDNamespace)
+	klog.V(4).Infof("Flags.ArgoCDTrackingAnnotation: %s", *o.Flags.ArgoCDTrackingAnnotation)
 	klog.V(4).Infof("Flags.Dependencies: %t", *o.Flags.Dependencies)
 	klog.V(4).Infof("Flags.Depth: %v", *o.Flags.Depth)
+	klog.V(4).Infof("Flags.Dump: %s", *o.Flags.Dump)
+	klog.V(4).Infof("Flags.Events: %t", *o.Flags.Events)
+	klog.V(4).Infof("Flags.FailOnNotReady: %v", *o.Flags.FailOnNotReady)
+	klog.V(4).Infof("Flags.FieldSelector: %s", *o.Flags.FieldSelector)
+	klog.V(4).Infof("Flags.Filenames: %v", *o.Flags.Filenames)
+	klog.V(4).Infof("Flags.FromSnapshot: %s", *o.Flags.FromSnapshot)
+	klog.V(4).Infof("Flags.ExcludeKinds: %v", *o.Flags.ExcludeKinds)
+	klog.V(4).Infof("Flags.ExcludeRelationships: %v", *o.Flags.ExcludeRelationships)
 	klog.V(4).Infof("Flags.ExcludeTypes: %v", *o.Flags.ExcludeTypes)
+	klog.V(4).Infof("Flags.HideCompleted: %t", *o.Flags.HideCompleted)
+	klog.V(4).Infof("Flags.IncludeKinds: %v", *o.Flags.IncludeKinds)
 	klog.V(4).Infof("Flags.IncludeTypes: %v", *o.Flags.IncludeTypes)
+	klog.V(4).Infof("Flags.InferOwners: %t", *o.Flags.InferOwners)
+	klog.V(4).Infof("Flags.InvertSince: %t", *o.Flags.InvertSince)
+	klog.V(4).Infof("Flags.MaxConcurrency: %v", *o.Flags.MaxConcurrency)
+	klog.V(4).Infof("Flags.MaxNodes: %v", *o.Flags.MaxNodes)
+	klog.V(4).Infof("Flags.OnlyProblems: %t", *o.Flags.OnlyProblems)
+	klog.V(4).Infof("Flags.PrefixMatch: %t", *o.Flags.PrefixMatch)
+	klog.V(4).Infof("Flags.RelatedContexts: %v", *o.Flags.RelatedContexts)
+	klog.V(4).Infof("Flags.SameNamespaceOnly: %t", *o.Flags.SameNamespaceOnly)
 	klog.V(4).Infof("Flags.Scopes: %v", *o.Flags.Scopes)
+	klog.V(4).Infof("Flags.Selector: %s", *o.Flags.Selector)
+	klog.V(4).Infof("Flags.Since: %v", *o.Flags.Since)
+	klog.V(4).Infof("Flags.Timeout: %v", *o.Flags.Timeout)
+	klog.V(4).Infof("Flags.UID: %s", *o.Flags.UID)
+	klog.V(4).Infof("Flags.UseCache: %t", *o.Flags.UseCache)
+	klog.V(4).Infof("Flags.Watch: %t", *o.Flags.Watch)
+	klog.V(4).Infof("Flags.WatchInterval: %v", *o.Flags.WatchInterval)
 	klog.V(4).Infof("ClientFlags.Context: %s", *o.ClientFlags.Context)
 	klog.V(4).Infof("ClientFlags.Namespace: %s", *o.ClientFlags.Namespace)
+	klog.V(4).Infof("ClientFlags.Impersonate: %s", *o.ClientFlags.Impersonate)
+	klog.V(4).Infof("ClientFlags.ImpersonateGroup: %v", *o.ClientFlags.ImpersonateGroup)
+	klog.V(4).Infof("PrintFlags.AgeFormat: %s", *o.PrintFlags.HumanReadableFlags.AgeFormat)
+	klog.V(4).Infof("PrintFlags.ASCII: %t", *o.PrintFlags.HumanReadableFlags.ASCII)
+	klog.V(4).Infof("PrintFlags.Collapse: %t", *o.PrintFlags.HumanReadableFlags.Collapse)
+	klog.V(4).Infof("PrintFlags.Color: %s", *o.PrintFlags.HumanReadableFlags.Color)
+	klog.V(4).Infof("PrintFlags.ColumnLabels: %v", *o.PrintFlags.HumanReadableFlags.ColumnLabels)
+	klog.V(4).Infof("PrintFlags.ConditionColumns: %v", *o.PrintFlags.HumanReadableFlags.ConditionColumns)
+	klog.V(4).Infof("PrintFlags.GroupByKind: %t", *o.PrintFlags.HumanReadableFlags.GroupByKind)
+	klog.V(4).Infof("PrintFlags.OutputFile: %s", *o.PrintFlags.OutputFile)
 	klog.V(4).Infof("PrintFlags.OutputFormat: %s", *o.PrintFlags.OutputFormat)
+	klog.V(4).Infof("PrintFlags.MaxNameWidth: %d", *o.PrintFlags.HumanReadableFlags.MaxNameWidth)
 	klog.V(4).Infof("PrintFlags.NoHeaders: %t", *o.PrintFlags.HumanReadableFlags.NoHeaders)
+	klog.V(4).Infof("PrintFlags.Quiet: %t", *o.PrintFlags.Quiet)
+	klog.V(4).Infof("PrintFlags.Reverse: %t", *o.PrintFlags.HumanReadableFlags.Reverse)
+	klog.V(4).Infof("PrintFlags.ShowAPIVersion: %t", *o.PrintFlags.HumanReadableFlags.ShowAPIVersion)
+	klog.V(4).Infof("PrintFlags.ShowConditionAge: %t", *o.PrintFlags.HumanReadableFlags.ShowConditionAge)
 	klog.V(4).Infof("PrintFlags.ShowGroup: %t", *o.PrintFlags.HumanReadableFlags.ShowGroup)
 	klog.V(4).Infof("PrintFlags.ShowLabels: %t", *o.PrintFlags.HumanReadableFlags.ShowLabels)
 	klog.V(4).Infof("PrintFlags.ShowNamespace: %t", *o.PrintFlags.HumanReadableFlags.ShowNamespace)
+	klog.V(4).Infof("PrintFlags.ShowNode: %t", *o.PrintFlags.HumanReadableFlags.ShowNode)
+	klog.V(4).Infof("PrintFlags.ShowOwner: %t", *o.PrintFlags.HumanReadableFlags.ShowOwner)
+	klog.V(4).Infof("PrintFlags.SortBy: %s", *o.PrintFlags.SortBy)
+	klog.V(4).Infof("PrintFlags.StatusConditionType: %s", *o.PrintFlags.StatusConditionType)
+	klog.V(4).Infof("PrintFlags.StatusConfig: %s", *o.PrintFlags.StatusConfig)
+	klog.V(4).Infof("PrintFlags.Summary: %t", *o.PrintFlags.Summary)
+	klog.V(4).Infof("PrintFlags.Width: %d", *o.PrintFlags.HumanReadableFlags.Width)
 
 	return nil
 }
 
 // Run implements all the necessary functionality for the lineage command.
-//nolint:funlen
 func (o *CmdOptions) Run() error {
 	ctx := context.Background()
 
-	// First check if Kubernetes cluster is reachable
-	if err := o.Client.IsReachable(); err != nil {
+	opts := lineage.Options{
+		AllNamespaces:            *o.Flags.AllNamespaces,
+		ArgoCDInstanceLabel:      *o.Flags.ArgoCDInstanceLabel,
+		ArgoCDNamespace:          *o.Flags.ArgoCDNamespace,
+		ArgoCDTrackingAnnotation: *o.Flags.ArgoCDTrackingAnnotation,
+		Dependencies:             *o.Flags.Dependencies,
+		Events:                   *o.Flags.Events,
+		ExcludeTypes:             *o.Flags.ExcludeTypes,
+		IncludeTypes:             *o.Flags.IncludeTypes,
+		InferOwners:              *o.Flags.InferOwners,
+		MaxConcurrency:           *o.Flags.MaxConcurrency,
+		MaxDepth:                 o.MaxDepth,
+		MaxNodes:                 *o.Flags.MaxNodes,
+		RelatedClients:           o.RelatedClients,
+		SameNamespaceOnly:        *o.Flags.SameNamespaceOnly,
+		Scopes:                   *o.Flags.Scopes,
+	}
+	// Track the resource types that couldn't be listed due to missing
+	// permissions, so that users know the relationship tree may be incomplete
+	forbidden := map[string]struct{}{}
+	opts.ForbiddenFn = func(resource string) {
+		forbidden[resource] = struct{}{}
+	}
+	warnFn := func() {
+		cli.PrintForbiddenWarning(o.ErrOut, forbidden, "the relationship tree may be incomplete")
+	}
+	excludeRSet, err := graph.ResolveRelationshipCategories(*o.Flags.ExcludeRelationships)
+	if err != nil {
+		return err
+	}
+	excludeGKSet, err := cli.ResolveGroupKindSet(o.Client, *o.Flags.ExcludeKinds)
+	if err != nil {
+		return err
+	}
+	includeGKSet, err := cli.ResolveGroupKindSet(o.Client, *o.Flags.IncludeKinds)
+	if err != nil {
+		return err
+	}
+
+	buildFn := func(ctx context.Context) (lineage.NodeMap, []types.UID, error) {
+		if timeout := *o.Flags.Timeout; timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		// Find either all dependencies or dependents of the requested objects,
+		// keeping the partial relationship tree if the timeout is exceeded
+		var nodeMap lineage.NodeMap
+		var rootUIDs []types.UID
+		var buildErr error
+		for resource := range forbidden {
+			delete(forbidden, resource)
+		}
+		if o.Snapshot != nil {
+			nodeMap, rootUIDs, _, buildErr = lineage.ReadSnapshot(bytes.NewReader(o.Snapshot))
+		} else {
+			// Show the number of objects listed so far on stderr, unless only
+			// the relationship tree is expected to be printed
+			var indicator *progress.Indicator
+			if !o.PrintFlags.IsQuiet() {
+				indicator = progress.Start(o.ErrOut)
+			}
+			buildOpts := opts
+			buildOpts.ProgressFn = indicator.SetObjects
+			nodeMap, rootUIDs, buildErr = lineage.BuildGraphForObjects(ctx, o.Client, o.RequestObjects, buildOpts)
+			indicator.Stop()
+		}
+		if buildErr != nil && !errors.Is(buildErr, lineage.ErrTruncated) {
+			return nil, nil, cli.WithTooManyNodesHint(buildErr)
+		}
+
+		// Write the relationship tree before any filter is applied, so that
+		// filters can still be applied when reading the snapshot
+		if path := *o.Flags.Dump; len(path) > 0 {
+			if err := cli.WriteSnapshot(path, nodeMap, rootUIDs, opts.Dependencies); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// Remove relationships of excluded categories
+		nodeMap.ExcludeRelationships(rootUIDs, excludeRSet, opts.Dependencies)
+
+		// Remove objects of excluded resource types & keep only objects of
+		// included resource types
+		nodeMap.ExcludeGroupKinds(rootUIDs, excludeGKSet, opts.Dependencies)
+		nodeMap.FilterByGroupKinds(rootUIDs, includeGKSet, opts.Dependencies)
+
+		// Filter the relationship tree by the provided selector
+		nodeMap.FilterByLabelSelector(rootUIDs, o.Selector, opts.Dependencies)
+
+		// Filter the relationship tree by the creation time of objects
+		if since := *o.Flags.Since; since > 0 {
+			nodeMap.FilterByCreationTime(rootUIDs, time.Now().Add(-since), *o.Flags.InvertSince, opts.Dependencies)
+		}
+
+		// Remove Pods that ran to completion
+		if *o.Flags.HideCompleted {
+			nodeMap.HideCompletedPods(rootUIDs, opts.Dependencies)
+		}
+
+		// Keep only the objects that aren't ready & the objects leading to them
+		if *o.Flags.OnlyProblems {
+			if err := o.PrintFlags.FilterByNotReady(nodeMap, rootUIDs, o.MaxDepth, opts.Dependencies); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		return nodeMap, rootUIDs, buildErr
+	}
+
+	if *o.Flags.Watch {
+		return o.watch(ctx, buildFn, warnFn)
+	}
+	nodeMap, rootUIDs, err := buildFn(ctx)
+	if err != nil && !errors.Is(err, lineage.ErrTruncated) {
 		return err
 	}
 
-	// Fetch the provided object to ensure it exists before proceeding
-	api, err := o.Client.ResolveAPIResource(o.RequestType)
+	// Print output, followed by the resource types that couldn't be listed &
+	// the error if the relationship tree is truncated
+	if err := o.PrintFlags.PrintOutput(o.Out, o.Printer, nodeMap, rootUIDs, o.MaxDepth, *o.Flags.Dependencies); err != nil {
+		return err
+	}
+	warnFn()
 	if err != nil {
 		return err
 	}
-	obj := client.ObjectMeta{
-		APIResource: *api,
-		Name:        o.RequestName,
-		Namespace:   o.Namespace,
+
+	// Fail if any object in the relationship tree isn't ready
+	if kinds := *o.Flags.FailOnNotReady; len(kinds) > 0 {
+		return o.checkReadiness(nodeMap, kinds)
 	}
-	root, err := o.Client.Get(ctx, obj.Name, client.GetOptions{
-		APIResource: obj.APIResource,
-		Namespace:   o.Namespace,
-	})
+	return nil
+}
+
+// checkReadiness returns an error listing the objects in the relationship tree
+// of the provided resource types that aren't ready, objects of every resource
+// type are considered if failOnNotReadyAllKinds is provided.
+func (o *CmdOptions) checkReadiness(nodeMap lineage.NodeMap, kinds []string) error {
+	var gkSet map[schema.GroupKind]struct{}
+	if !failOnNotReadyAll(kinds) {
+		var err error
+		gkSet, err = cli.ResolveGroupKindSet(o.Client, kinds)
+		if err != nil {
+			return err
+		}
+	}
+	notReady, err := o.PrintFlags.NotReadyObjects(nodeMap, o.MaxDepth, gkSet)
 	if err != nil {
 		return err
 	}
+	if len(notReady) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(notReady))
+	for _, node := range notReady {
+		names = append(names, fmt.Sprintf("%s/%s", node.Kind, node.Name))
+	}
+	return fmt.Errorf("%d object(s) in the relationship tree aren't ready: %s", len(notReady), strings.Join(names, ", "))
+}
+
+// failOnNotReadyAll returns whether objects of every resource type should be
+// considered by --fail-on-not-ready.
+func failOnNotReadyAll(kinds []string) bool {
+	for _, kind := range kinds {
+		if kind == failOnNotReadyAllKinds {
+			return true
+		}
+	}
+	return false
+}
 
-	// Determine resources to list
-	excludeAPIs := []client.APIResource{}
-	if o.Flags.ExcludeTypes != nil {
-		for _, kind := range *o.Flags.ExcludeTypes {
-			api, err := o.Client.ResolveAPIResource(kind)
-			if err != nil {
+// watch rebuilds & prints the relationship tree on every watch interval until
+// interrupted. Errors after the first successful print are displayed in place
+// of the relationship tree instead of ending the watch, since objects are
+// expected to come & go (eg. during a rollout). The provided warnFn is called
+// after every print.
+func (o *CmdOptions) watch(ctx context.Context, buildFn func(ctx context.Context) (lineage.NodeMap, []types.UID, error), warnFn func()) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(*o.Flags.WatchInterval)
+	defer ticker.Stop()
+
+	printed := false
+	for {
+		nodeMap, rootUIDs, err := buildFn(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil && !printed && !errors.Is(err, lineage.ErrTruncated):
+			return err
+		}
+
+		// Clear the screen only once the relationship tree is ready to be
+		// printed to minimize flickering, unless the output is quiet.
+		// Truncated relationship trees are printed followed by the error
+		if !o.PrintFlags.IsQuiet() {
+			fmt.Fprint(o.Out, clearScreen)
+		}
+		if nodeMap != nil {
+			if err := o.Printer.Print(o.Out, nodeMap, rootUIDs, o.MaxDepth, *o.Flags.Dependencies); err != nil {
 				return err
 			}
-			excludeAPIs = append(excludeAPIs, *api)
+			warnFn()
+		}
+		if err != nil {
+			fmt.Fprintf(o.ErrOut, "error: %v\n", err)
+		}
+		printed = true
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
 		}
 	}
-	includeAPIs := []client.APIResource{}
-	if o.Flags.IncludeTypes != nil {
-		for _, kind := range *o.Flags.IncludeTypes {
-			api, err := o.Client.ResolveAPIResource(kind)
-			if err != nil {
-				return err
+}
+
+// parseRequestObjects parses the requested objects from the provided
+// arguments, which are either in "<resource> <name>..." or
+// "<resource>/<name>..." form.
+func (o *CmdOptions) parseRequestObjects(args []string) ([]lineage.ObjectRef, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	refs := make([]lineage.ObjectRef, 0, len(args))
+	if !strings.Contains(args[0], "/") {
+		if len(args) == 1 {
+			return nil, fmt.Errorf("arguments in <resource>/<name> form must have a single resource and name\nSee '%s -h' for help and examples", cmdPath)
+		}
+		for _, name := range args[1:] {
+			if strings.Contains(name, "/") {
+				return nil, fmt.Errorf("there is no need to specify a resource type as a separate argument when passing arguments in <resource>/<name> form\nSee '%s -h' for help and examples", cmdPath)
 			}
-			includeAPIs = append(includeAPIs, *api)
+			refs = append(refs, lineage.ObjectRef{Type: args[0], Namespace: o.Namespace, Name: name})
 		}
+		return refs, nil
+	}
+	for _, arg := range args {
+		resourceTokens := strings.SplitN(arg, "/", 2)
+		if len(resourceTokens) != 2 || len(resourceTokens[0]) == 0 || len(resourceTokens[1]) == 0 {
+			return nil, fmt.Errorf("arguments in <resource>/<name> form must have a single resource and name\nSee '%s -h' for help and examples", cmdPath)
+		}
+		refs = append(refs, lineage.ObjectRef{Type: resourceTokens[0], Namespace: o.Namespace, Name: resourceTokens[1]})
+	}
+	return refs, nil
+}
+
+// readRequestObjects reads the requested objects from the provided files.
+func (o *CmdOptions) readRequestObjects() ([]lineage.ObjectRef, error) {
+	infos, err := resource.NewBuilder(o.ClientFlags).
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(o.Namespace).DefaultNamespace().
+		FilenameParam(false, &resource.FilenameOptions{Filenames: *o.Flags.Filenames}).
+		Flatten().
+		Do().
+		Infos()
+	if err != nil {
+		return nil, err
 	}
+	return infosToObjectRefs(infos), nil
+}
 
-	// Determine the namespaces to list objects
-	namespaces := []string{o.Namespace}
-	if o.Flags.AllNamespaces != nil && *o.Flags.AllNamespaces {
-		namespaces = append(namespaces, "")
+// listRequestObjects lists the objects of the provided resource type that match
+// the provided field selector as the requested objects. The field selector is
+// passed to the server so that only the matching objects are returned.
+func (o *CmdOptions) listRequestObjects(args []string) ([]lineage.ObjectRef, error) {
+	if len(args) != 1 || strings.Contains(args[0], "/") {
+		return nil, fmt.Errorf("--%s can only be used with a single resource type & no names\nSee '%s -h' for help and examples", flagFieldSelector, cmdPath)
+	}
+	infos, err := resource.NewBuilder(o.ClientFlags).
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(o.Namespace).DefaultNamespace().AllNamespaces(*o.Flags.AllNamespaces).
+		FieldSelectorParam(*o.Flags.FieldSelector).
+		ResourceTypeOrNameArgs(true, args...).
+		Flatten().
+		Do().
+		Infos()
+	if err != nil {
+		return nil, err
 	}
-	if o.Flags.Scopes != nil {
-		namespaces = append(namespaces, *o.Flags.Scopes...)
+	return infosToObjectRefs(infos), nil
+}
+
+// matchRequestObjectsByPrefix lists the objects of the provided resource types
+// whose names start with one of the provided names as the requested objects.
+// Objects are listed in the current namespace, or across all namespaces if
+// --all-namespaces is provided.
+func (o *CmdOptions) matchRequestObjectsByPrefix(args []string) ([]lineage.ObjectRef, error) {
+	refs, err := o.parseRequestObjects(args)
+	if err != nil || len(refs) == 0 {
+		return nil, err
 	}
 
-	// Fetch resources in the cluster
+	// Group the prefixes by resource type, so that every resource type is only
+	// listed once
+	resourceTypes, prefixesByType := []string{}, map[string][]string{}
+	for _, ref := range refs {
+		if _, ok := prefixesByType[ref.Type]; !ok {
+			resourceTypes = append(resourceTypes, ref.Type)
+		}
+		prefixesByType[ref.Type] = append(prefixesByType[ref.Type], ref.Name)
+	}
+	var matches []*resource.Info
+	for _, resourceType := range resourceTypes {
+		infos, err := resource.NewBuilder(o.ClientFlags).
+			Unstructured().
+			ContinueOnError().
+			NamespaceParam(o.Namespace).DefaultNamespace().AllNamespaces(*o.Flags.AllNamespaces).
+			ResourceTypeOrNameArgs(true, resourceType).
+			Flatten().
+			Do().
+			Infos()
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			for _, prefix := range prefixesByType[resourceType] {
+				if strings.HasPrefix(info.Name, prefix) {
+					matches = append(matches, info)
+					break
+				}
+			}
+		}
+	}
+	if len(matches) == 0 {
+		prefixes := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			prefixes = append(prefixes, fmt.Sprintf("\"%s\"", ref.Name))
+		}
+		return nil, fmt.Errorf("no objects found with names starting with %s", strings.Join(prefixes, ", "))
+	}
+	return infosToObjectRefs(matches), nil
+}
+
+// findRequestObjectByUID searches the objects of the provided resource type, or
+// of every resource type if none is provided, for the object with the UID
+// provided by --uid as the requested object. Objects are searched in the
+// current namespace & at the cluster scope, or across all namespaces if
+// --all-namespaces is provided.
+func (o *CmdOptions) findRequestObjectByUID(args []string) ([]lineage.ObjectRef, error) {
+	if len(args) > 1 || (len(args) == 1 && strings.Contains(args[0], "/")) {
+		return nil, fmt.Errorf("--%s can only be used with a single resource type & no names\nSee '%s -h' for help and examples", flagUID, cmdPath)
+	}
+	var apis []client.APIResource
+	if len(args) == 1 {
+		api, err := o.Client.ResolveAPIResource(args[0])
+		if err != nil {
+			return nil, err
+		}
+		apis = append(apis, *api)
+	}
+	var namespaces []string
+	if !*o.Flags.AllNamespaces {
+		namespaces = []string{o.Namespace}
+	}
+
+	ctx := context.Background()
+	if timeout := *o.Flags.Timeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	objs, err := o.Client.List(ctx, client.ListOptions{
-		APIResourcesToExclude: excludeAPIs,
-		APIResourcesToInclude: includeAPIs,
+		APIResourcesToInclude: apis,
 		Namespaces:            namespaces,
+		MaxConcurrency:        *o.Flags.MaxConcurrency,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	// Include root object into objects to handle cases where user has access
-	// to get the root object but unable to list its resource type
-	objs.Items = append(objs.Items, *root)
-
-	// Find either all dependencies or dependents of the root object
-	depsIsDependencies, resolveDeps := false, graph.ResolveDependents
-	if o.Flags.Dependencies != nil && *o.Flags.Dependencies {
-		depsIsDependencies, resolveDeps = true, graph.ResolveDependencies
+	for _, obj := range objs.Items {
+		if string(obj.GetUID()) != *o.Flags.UID {
+			continue
+		}
+		gvk := obj.GroupVersionKind()
+		mapping, err := o.Client.GetMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+		gvr := mapping.Resource
+		return []lineage.ObjectRef{{
+			Type:      fmt.Sprintf("%s.%s.%s", gvr.Resource, gvr.Version, gvr.Group),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+		}}, nil
 	}
-	mapper := o.Client.GetMapper()
-	rootUID := root.GetUID()
-	nodeMap, err := resolveDeps(mapper, objs.Items, []types.UID{rootUID})
-	if err != nil {
-		return err
+	if *o.Flags.AllNamespaces {
+		return nil, fmt.Errorf("no object found with UID \"%s\"", *o.Flags.UID)
 	}
+	return nil, fmt.Errorf("no object found with UID \"%s\" in namespace \"%s\" or at the cluster scope, use --%s to search across all namespaces", *o.Flags.UID, o.Namespace, flagAllNamespaces)
+}
 
-	// Print output
-	return o.Printer.Print(o.Out, nodeMap, rootUID, *o.Flags.Depth, depsIsDependencies)
+// infosToObjectRefs converts the provided resource infos into object
+// references.
+func infosToObjectRefs(infos []*resource.Info) []lineage.ObjectRef {
+	refs := make([]lineage.ObjectRef, 0, len(infos))
+	for _, info := range infos {
+		// Use the fully specified resource type to avoid ambiguity
+		gvr := info.Mapping.Resource
+		refs = append(refs, lineage.ObjectRef{
+			Type:      fmt.Sprintf("%s.%s.%s", gvr.Resource, gvr.Version, gvr.Group),
+			Namespace: info.Namespace,
+			Name:      info.Name,
+		})
+	}
+	return refs
 }
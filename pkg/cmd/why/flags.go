@@ -0,0 +1,24 @@
+package why
+
+import (
+	cmdlineage "github.com/tohjustin/kube-lineage/pkg/cmd/lineage"
+)
+
+const (
+	flagAllNamespaces = "all-namespaces"
+	flagTimeout       = "timeout"
+)
+
+// NewFlags returns the subset of the lineage command's flags that applies to
+// finding relationship paths, with the same default values set.
+func NewFlags() *cmdlineage.Flags {
+	f := cmdlineage.NewFlags()
+	return &cmdlineage.Flags{
+		AllNamespaces:  f.AllNamespaces,
+		ExcludeTypes:   f.ExcludeTypes,
+		IncludeTypes:   f.IncludeTypes,
+		MaxConcurrency: f.MaxConcurrency,
+		Scopes:         f.Scopes,
+		Timeout:        f.Timeout,
+	}
+}
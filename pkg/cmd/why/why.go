@@ -0,0 +1,242 @@
+package why
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/klog/v2"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/tohjustin/kube-lineage/internal/cli"
+	"github.com/tohjustin/kube-lineage/internal/client"
+	"github.com/tohjustin/kube-lineage/internal/log"
+	"github.com/tohjustin/kube-lineage/internal/progress"
+	cmdlineage "github.com/tohjustin/kube-lineage/pkg/cmd/lineage"
+	"github.com/tohjustin/kube-lineage/pkg/lineage"
+)
+
+var (
+	cmdPath    string
+	cmdName    = "why"
+	cmdUse     = "%CMD% TYPE[.VERSION][.GROUP]/NAME TYPE[.VERSION][.GROUP]/NAME [flags]"
+	cmdExample = templates.Examples(`
+		# Explain how the configmap named "web-config" is related to the ingress named "web" in the current namespace
+		%CMD_PATH% ingress/web configmap/web-config
+
+		# Explain how the pod named "web-5cc79d4bf5-xgvkc" is related to the node named "k3d-dev-server"
+		%CMD_PATH% node/k3d-dev-server pod/web-5cc79d4bf5-xgvkc`)
+	cmdShort = "Explain the relationship path between two Kubernetes objects"
+	cmdLong  = templates.LongDesc(`
+		Explain the shortest relationship path between two Kubernetes objects, if
+		any, along with the relationship type(s) of each hop.
+
+		The path is found by following either the dependencies or the dependents
+		of the first object until the second object is reached.`)
+)
+
+// CmdOptions contains all the options for running the why command.
+type CmdOptions struct {
+	// RequestObjects represents the two objects to find the relationship path
+	// between.
+	RequestObjects []lineage.ObjectRef
+	Flags          *cmdlineage.Flags
+
+	Namespace   string
+	Client      client.Interface
+	ClientFlags *client.Flags
+
+	genericclioptions.IOStreams
+}
+
+// NewCmd returns an initialized Command for the why command.
+func NewCmd(streams genericclioptions.IOStreams, name, parentCmdPath string) *cobra.Command {
+	o := &CmdOptions{
+		Flags:       NewFlags(),
+		ClientFlags: client.NewFlags(),
+		IOStreams:   streams,
+	}
+
+	f := cmdutil.NewFactory(o.ClientFlags)
+	util.SetFactoryForCompletion(f)
+
+	if len(name) > 0 {
+		cmdName = name
+	}
+	cmdPath = cmdName
+	if len(parentCmdPath) > 0 {
+		cmdPath = parentCmdPath + " " + cmdName
+	}
+	cmd := &cobra.Command{
+		Use:                   strings.ReplaceAll(cmdUse, "%CMD%", cmdName),
+		Example:               strings.ReplaceAll(cmdExample, "%CMD_PATH%", cmdPath),
+		Short:                 cmdShort,
+		Long:                  cmdLong,
+		Args:                  cobra.ArbitraryArgs,
+		DisableFlagsInUseLine: true,
+		DisableSuggestions:    true,
+		SilenceUsage:          true,
+		Run: func(c *cobra.Command, args []string) {
+			klog.V(4).Infof("Version: %s", c.Root().Version)
+			cmdutil.CheckErr(o.Complete(c, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	// Setup flags
+	o.Flags.AddFlags(cmd.Flags())
+	o.ClientFlags.AddFlags(cmd.Flags())
+	log.AddFlags(cmd.Flags())
+
+	// Setup flag completion function
+	o.Flags.RegisterFlagCompletionFunc(cmd, f)
+	o.ClientFlags.RegisterFlagCompletionFunc(cmd, f)
+
+	return cmd
+}
+
+// Complete completes all the required options for the why command.
+func (o *CmdOptions) Complete(cmd *cobra.Command, args []string) error {
+	var err error
+
+	// Setup client
+	o.Namespace, _, err = o.ClientFlags.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.Client, err = o.ClientFlags.ToClient()
+	if err != nil {
+		return err
+	}
+
+	// Determine the requested objects from the provided arguments
+	for _, arg := range args {
+		resourceTokens := strings.SplitN(arg, "/", 2)
+		if len(resourceTokens) != 2 || len(resourceTokens[0]) == 0 || len(resourceTokens[1]) == 0 {
+			return fmt.Errorf("arguments must be in <resource>/<name> form\nSee '%s -h' for help and examples", cmdPath)
+		}
+		o.RequestObjects = append(o.RequestObjects, lineage.ObjectRef{Type: resourceTokens[0], Namespace: o.Namespace, Name: resourceTokens[1]})
+	}
+
+	return nil
+}
+
+// Validate validates all the required options for the why command.
+func (o *CmdOptions) Validate() error {
+	if len(o.RequestObjects) != 2 {
+		return fmt.Errorf("exactly two objects must be specified as <resource>/<name>\nSee '%s -h' for help and examples", cmdPath)
+	}
+	if o.RequestObjects[0] == o.RequestObjects[1] {
+		return fmt.Errorf("both arguments refer to the same object")
+	}
+
+	if *o.Flags.Timeout < 0 {
+		return fmt.Errorf("--%s must not be negative", flagTimeout)
+	}
+
+	klog.V(4).Infof("Namespace: %s", o.Namespace)
+	klog.V(4).Infof("RequestObjects: %v", o.RequestObjects)
+	klog.V(4).Infof("Flags.AllNamespaces: %t", *o.Flags.AllNamespaces)
+	klog.V(4).Infof("Flags.ExcludeTypes: %v", *o.Flags.ExcludeTypes)
+	klog.V(4).Infof("Flags.IncludeTypes: %v", *o.Flags.IncludeTypes)
+	klog.V(4).Infof("Flags.MaxConcurrency: %v", *o.Flags.MaxConcurrency)
+	klog.V(4).Infof("Flags.Scopes: %v", *o.Flags.Scopes)
+	klog.V(4).Infof("Flags.Timeout: %v", *o.Flags.Timeout)
+	klog.V(4).Infof("ClientFlags.Context: %s", *o.ClientFlags.Context)
+	klog.V(4).Infof("ClientFlags.Namespace: %s", *o.ClientFlags.Namespace)
+
+	return nil
+}
+
+// Run implements all the necessary functionality for the why command.
+func (o *CmdOptions) Run() error {
+	ctx := context.Background()
+	if timeout := *o.Flags.Timeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Track the resource types that couldn't be listed due to missing
+	// permissions, so that users know the path may be missing
+	forbidden := map[string]struct{}{}
+	opts := lineage.Options{
+		AllNamespaces:  *o.Flags.AllNamespaces,
+		ExcludeTypes:   *o.Flags.ExcludeTypes,
+		IncludeTypes:   *o.Flags.IncludeTypes,
+		MaxConcurrency: *o.Flags.MaxConcurrency,
+		Scopes:         *o.Flags.Scopes,
+		ForbiddenFn: func(resource string) {
+			forbidden[resource] = struct{}{}
+		},
+	}
+
+	// Show the number of objects listed so far on stderr
+	indicator := progress.Start(o.ErrOut)
+	opts.ProgressFn = indicator.SetObjects
+	dependents, dependencies, rootUIDs, buildErr := lineage.BuildGraphsForObjects(ctx, o.Client, o.RequestObjects, opts)
+	indicator.Stop()
+	if buildErr != nil && !errors.Is(buildErr, lineage.ErrTruncated) {
+		return buildErr
+	}
+	if len(rootUIDs) != 2 {
+		return fmt.Errorf("both arguments refer to the same object")
+	}
+
+	// Find the shortest path following the dependents of the first object,
+	// then following its dependencies, preferring dependents on a tie
+	nodeMap, path, depsIsDependencies := dependents, dependents.ShortestPath(rootUIDs[0], rootUIDs[1], false), false
+	if p := dependencies.ShortestPath(rootUIDs[0], rootUIDs[1], true); p != nil && (path == nil || len(p) < len(path)) {
+		nodeMap, path, depsIsDependencies = dependencies, p, true
+	}
+	cli.PrintForbiddenWarning(o.ErrOut, forbidden, "the relationship path may be missing")
+	if path == nil {
+		// The path may go through objects that weren't listed
+		if buildErr != nil {
+			return buildErr
+		}
+		from, to := o.RequestObjects[0], o.RequestObjects[1]
+		if *o.Flags.AllNamespaces {
+			return fmt.Errorf("no relationship path found between %s/%s & %s/%s", from.Type, from.Name, to.Type, to.Name)
+		}
+		return fmt.Errorf("no relationship path found between %s/%s & %s/%s, use --%s to find relationship paths across all namespaces", from.Type, from.Name, to.Type, to.Name, flagAllNamespaces)
+	}
+
+	// Print the path found among the objects listed so far, followed by the
+	// error if not every object was listed since a shorter path may exist
+	printPath(o.Out, nodeMap, path, depsIsDependencies)
+	return buildErr
+}
+
+// printPath prints every hop of the provided relationship path along with
+// its relationship type(s).
+func printPath(w io.Writer, nodeMap lineage.NodeMap, path []types.UID, depsIsDependencies bool) {
+	from, to := nodeMap[path[0]], nodeMap[path[len(path)-1]]
+	fmt.Fprintf(w, "%s is related to %s through %d relationship(s):\n", nodeName(from), nodeName(to), len(path)-1)
+	for ix := 1; ix < len(path); ix++ {
+		parent, node := nodeMap[path[ix-1]], nodeMap[path[ix]]
+		rset := parent.GetDeps(depsIsDependencies)[node.UID]
+		if depsIsDependencies {
+			fmt.Fprintf(w, "  %s depends on %s [%s]\n", nodeName(parent), nodeName(node), strings.Join(rset.List(), ", "))
+		} else {
+			fmt.Fprintf(w, "  %s is a dependency of %s [%s]\n", nodeName(parent), nodeName(node), strings.Join(rset.List(), ", "))
+		}
+	}
+}
+
+// nodeName returns the name of the provided object in "<kind>/<name>" form,
+// followed by its namespace if it's namespaced.
+func nodeName(node *lineage.Node) string {
+	if len(node.Namespace) == 0 {
+		return fmt.Sprintf("%s/%s", node.Kind, node.Name)
+	}
+	return fmt.Sprintf("%s/%s (namespace \"%s\")", node.Kind, node.Name, node.Namespace)
+}
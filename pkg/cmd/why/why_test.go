@@ -0,0 +1,130 @@
+package why
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/tohjustin/kube-lineage/internal/client"
+	"github.com/tohjustin/kube-lineage/internal/graph"
+	"github.com/tohjustin/kube-lineage/pkg/lineage"
+)
+
+func TestCompleteValidate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("KUBECONFIG", filepath.Join(dir, "config"))
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []lineage.ObjectRef
+		wantErr  bool
+	}{
+		{
+			name: "two objects",
+			args: []string{"deploy/web", "cm/web-config"},
+			expected: []lineage.ObjectRef{
+				{Type: "deploy", Namespace: "team-a", Name: "web"},
+				{Type: "cm", Namespace: "team-a", Name: "web-config"},
+			},
+		},
+		{name: "no objects", args: nil, wantErr: true},
+		{name: "one object", args: []string{"deploy/web"}, wantErr: true},
+		{name: "three objects", args: []string{"deploy/web", "cm/web-config", "pod/web"}, wantErr: true},
+		{name: "missing name", args: []string{"deploy/", "cm/web-config"}, wantErr: true},
+		{name: "missing resource", args: []string{"deploy/web", "web-config"}, wantErr: true},
+		{name: "same object twice", args: []string{"deploy/web", "deploy/web"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		o := &CmdOptions{
+			Flags:       NewFlags(),
+			ClientFlags: newTestClientFlags(dir),
+			IOStreams:   genericclioptions.NewTestIOStreamsDiscard(),
+		}
+		err := o.Complete(nil, tt.args)
+		if err == nil {
+			err = o.Validate()
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Fatalf("%s: expected error got none", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(o.RequestObjects, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, o.RequestObjects)
+		}
+	}
+}
+
+func TestPrintPath(t *testing.T) {
+	t.Parallel()
+
+	newNode := func(kind, ns, name string) *lineage.Node {
+		return &lineage.Node{
+			UID:          types.UID("uid-" + name),
+			Kind:         kind,
+			Namespace:    ns,
+			Name:         name,
+			Dependencies: map[types.UID]graph.RelationshipSet{},
+			Dependents:   map[types.UID]graph.RelationshipSet{},
+		}
+	}
+	addRelationships := func(dependent, dependency *lineage.Node, rset graph.RelationshipSet) {
+		dependent.Dependencies[dependency.UID] = rset
+		dependency.Dependents[dependent.UID] = rset
+	}
+	deploy := newNode("Deployment", "team-a", "web")
+	rs := newNode("ReplicaSet", "team-a", "web-5cc79d4bf5")
+	pod := newNode("Pod", "team-a", "web-5cc79d4bf5-xgvkc")
+	node := newNode("Node", "", "k3d-dev-server")
+	addRelationships(rs, deploy, graph.RelationshipSet{graph.RelationshipControllerRef: {}})
+	addRelationships(pod, rs, graph.RelationshipSet{graph.RelationshipControllerRef: {}})
+	addRelationships(pod, node, graph.RelationshipSet{graph.RelationshipPodNode: {}, graph.RelationshipOwnerRef: {}})
+	nodeMap := lineage.NodeMap{deploy.UID: deploy, rs.UID: rs, pod.UID: pod, node.UID: node}
+
+	tests := []struct {
+		name               string
+		path               []types.UID
+		depsIsDependencies bool
+		expected           string
+	}{
+		{
+			name: "dependents",
+			path: []types.UID{deploy.UID, rs.UID, pod.UID},
+			expected: "Deployment/web (namespace \"team-a\") is related to Pod/web-5cc79d4bf5-xgvkc (namespace \"team-a\") through 2 relationship(s):\n" +
+				"  Deployment/web (namespace \"team-a\") is a dependency of ReplicaSet/web-5cc79d4bf5 (namespace \"team-a\") [ControllerReference]\n" +
+				"  ReplicaSet/web-5cc79d4bf5 (namespace \"team-a\") is a dependency of Pod/web-5cc79d4bf5-xgvkc (namespace \"team-a\") [ControllerReference]\n",
+		},
+		{
+			name:               "dependencies",
+			path:               []types.UID{pod.UID, node.UID},
+			depsIsDependencies: true,
+			expected: "Pod/web-5cc79d4bf5-xgvkc (namespace \"team-a\") is related to Node/k3d-dev-server through 1 relationship(s):\n" +
+				"  Pod/web-5cc79d4bf5-xgvkc (namespace \"team-a\") depends on Node/k3d-dev-server [OwnerReference, PodNode]\n",
+		},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		printPath(&buf, nodeMap, tt.path, tt.depsIsDependencies)
+		if output := buf.String(); output != tt.expected {
+			t.Fatalf("%s: expected %q got %q", tt.name, tt.expected, output)
+		}
+	}
+}
+
+// newTestClientFlags returns client flags connecting to a fixed server with
+// the "team-a" namespace, so that no kubeconfig is needed.
+func newTestClientFlags(dir string) *client.Flags {
+	f := client.NewFlags()
+	server, namespace, cacheDir := "https://127.0.0.1:6443", "team-a", filepath.Join(dir, "cache")
+	f.APIServer, f.Namespace, f.CacheDir = &server, &namespace, &cacheDir
+	return f
+}
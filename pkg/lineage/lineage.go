@@ -0,0 +1,394 @@
+// Package lineage provides functions for finding all dependencies or dependents
+// of a Kubernetes object.
+package lineage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/cockroachdb/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"github.com/tohjustin/kube-lineage/internal/client"
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+// ErrTruncated is returned along with a partial relationship tree when the
+// provided context is done before every object is listed from the server.
+var ErrTruncated = errors.New("relationship tree is truncated")
+
+// ErrTooManyNodes is returned when the relationship tree has more objects than
+// Options.MaxNodes.
+var ErrTooManyNodes = graph.ErrTooManyNodes
+
+// UnlimitedDepth is the value of Options.MaxDepth that counts objects at any
+// depth of the relationship tree towards Options.MaxNodes.
+const UnlimitedDepth = graph.UnlimitedDepth
+
+// Client is a Kubernetes client capable of discovering & listing resources in a
+// cluster.
+type Client = client.Interface
+
+// Node represents a Kubernetes object in a relationship tree.
+type Node = graph.Node
+
+// NodeMap contains a relationship tree stored as a map of nodes.
+type NodeMap = graph.NodeMap
+
+// NewClient returns a client based on the provided configuration flags.
+func NewClient(flags *genericclioptions.ConfigFlags) (Client, error) {
+	f := client.Flags{ConfigFlags: flags}
+	return f.ToClient()
+}
+
+// NewClientForInterfaces returns a client backed by the provided dynamic &
+// discovery clients, so that relationship trees can be built against fake
+// clients (e.g. "fake.NewSimpleDynamicClient") populated with fixture objects.
+//
+// A "discovery/fake.FakeDiscovery" client populated with the API resources of
+// the fixture objects can be passed as is.
+func NewClientForInterfaces(dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface) Client {
+	return client.New(dynamicClient, discoveryClient)
+}
+
+// ObjectRef is a reference to the Kubernetes object at the root of the
+// relationship tree.
+type ObjectRef struct {
+	// Type is the resource type of the object in TYPE[.VERSION][.GROUP] form.
+	// Shortcuts & groups will be resolved.
+	Type string
+	// Namespace is the namespace of the object, ignored for cluster-scoped
+	// objects.
+	Namespace string
+	// Name is the name of the object.
+	Name string
+}
+
+// Options configures how BuildGraph discovers relationships.
+type Options struct {
+	// AllNamespaces finds relationships across all namespaces.
+	AllNamespaces bool
+	// ArgoCDInstanceLabel is the label set to the name of the Argo CD
+	// Application managing each object, objects aren't tracked by label if
+	// empty (eg. "app.kubernetes.io/instance").
+	ArgoCDInstanceLabel string
+	// ArgoCDNamespace is the namespace Argo CD is installed in, which contains
+	// the Applications referenced by name only (eg. "argocd").
+	ArgoCDNamespace string
+	// ArgoCDTrackingAnnotation is the annotation set to the Argo CD tracking ID
+	// of each object, objects aren't tracked by annotation if empty (eg.
+	// "argocd.argoproj.io/tracking-id").
+	ArgoCDTrackingAnnotation string
+	// Dependencies finds the dependencies of the root object instead of its
+	// dependents.
+	Dependencies bool
+	// Events attaches the Events of every object in the relationship tree to
+	// the object they reference.
+	Events bool
+	// ExcludeTypes is the list of resource types to exclude from relationship
+	// discovery.
+	ExcludeTypes []string
+	// ForbiddenFn is called with every resource type (eg.
+	// "networkpolicies.networking.k8s.io") that couldn't be listed due to
+	// missing permissions, in which case the relationship tree may be missing
+	// some objects. It's never called concurrently.
+	ForbiddenFn func(resource string)
+	// IncludeTypes is the list of resource types to only include in
+	// relationship discovery.
+	IncludeTypes []string
+	// InferOwners infers the owners of ReplicaSets & Pods without owner
+	// references from their "pod-template-hash" label.
+	InferOwners bool
+	// MaxConcurrency is the maximum number of concurrent list requests sent to
+	// the server, no limit is applied if set to 0.
+	MaxConcurrency uint
+	// MaxDepth is the maximum depth of the objects counted towards MaxNodes,
+	// only the root objects are counted if set to 0 (see UnlimitedDepth).
+	MaxDepth uint
+	// MaxNodes is the maximum number of objects within MaxDepth of the
+	// relationship tree, building the relationship tree is aborted with an
+	// error wrapping ErrTooManyNodes as soon as it's exceeded. No limit is
+	// applied if set to 0.
+	MaxNodes uint
+	// ProgressFn is called with the number of objects listed so far while
+	// objects are being listed. It's never called concurrently.
+	ProgressFn func(objects int)
+	// RelatedClients maps the names of other clusters to the client used to
+	// fetch the objects referenced in them (eg. the Nodes of Cluster API
+	// workload clusters). Objects in clusters without a client are kept as
+	// placeholders tagged with the name of their cluster.
+	RelatedClients map[string]Client
+	// SameNamespaceOnly only finds relationships with objects in the namespaces
+	// of the root objects & with cluster-scoped objects, taking precedence over
+	// AllNamespaces & Scopes.
+	SameNamespaceOnly bool
+	// Scopes is the list of additional namespaces to find relationships.
+	Scopes []string
+}
+
+// BuildGraph fetches the provided object & finds either all its dependencies or
+// dependents, returning the resulting relationship tree along with the UID of
+// the root object. Like BuildGraphForObjects, a partial relationship tree is
+// returned along with an error wrapping ErrTruncated if the provided context is
+// done while listing objects.
+//
+// BuildGraph is safe to call concurrently as long as the provided client is.
+// The returned NodeMap isn't shared with any other caller & is safe to mutate,
+// but isn't safe for concurrent use without external synchronization.
+func BuildGraph(ctx context.Context, c Client, root ObjectRef, opts Options) (NodeMap, types.UID, error) {
+	nodeMap, rootUIDs, err := BuildGraphForObjects(ctx, c, []ObjectRef{root}, opts)
+	if err != nil && !errors.Is(err, ErrTruncated) {
+		return nil, "", err
+	}
+	return nodeMap, rootUIDs[0], err
+}
+
+// BuildGraphForObjects fetches the provided objects & finds either all their
+// dependencies or dependents, returning a single relationship tree shared by
+// all objects along with the UIDs of the root objects in the provided order.
+// Objects listed more than once are only returned once. If the provided
+// context is done while listing objects, the relationship tree built from the
+// objects listed so far is returned along with an error wrapping ErrTruncated.
+//
+// BuildGraphForObjects has the same concurrency guarantees as BuildGraph.
+func BuildGraphForObjects(ctx context.Context, c Client, roots []ObjectRef, opts Options) (NodeMap, []types.UID, error) {
+	nodeMaps, rootUIDs, err := buildGraphsForObjects(ctx, c, roots, opts, opts.Dependencies)
+	if err != nil && !errors.Is(err, ErrTruncated) {
+		return nil, nil, err
+	}
+	return nodeMaps[0], rootUIDs, err
+}
+
+// BuildGraphsForObjects is like BuildGraphForObjects, but finds both all the
+// dependents & dependencies of the provided objects from a single listing of
+// objects, returning the relationship tree of their dependents followed by the
+// relationship tree of their dependencies. opts.Dependencies is ignored.
+//
+// BuildGraphsForObjects has the same concurrency guarantees as BuildGraph.
+func BuildGraphsForObjects(ctx context.Context, c Client, roots []ObjectRef, opts Options) (NodeMap, NodeMap, []types.UID, error) {
+	nodeMaps, rootUIDs, err := buildGraphsForObjects(ctx, c, roots, opts, false, true)
+	if err != nil && !errors.Is(err, ErrTruncated) {
+		return nil, nil, nil, err
+	}
+	return nodeMaps[0], nodeMaps[1], rootUIDs, err
+}
+
+// buildGraphsForObjects fetches the provided objects & lists objects once to
+// build a relationship tree for each of the provided directions, finding the
+// dependencies of the provided objects if true or their dependents otherwise.
+//nolint:funlen,gocognit
+func buildGraphsForObjects(ctx context.Context, c Client, roots []ObjectRef, opts Options, directions ...bool) ([]NodeMap, []types.UID, error) {
+	if len(roots) == 0 {
+		return nil, nil, fmt.Errorf("at least one object must be provided")
+	}
+
+	// First check if Kubernetes cluster is reachable
+	if err := c.IsReachable(); err != nil {
+		return nil, nil, err
+	}
+
+	// Fetch the provided objects to ensure they exist before proceeding. Objects
+	// can have relationships with objects in their own namespace, or in any
+	// namespace if they are cluster-scoped
+	rootObjs := make([]unstructuredv1.Unstructured, 0, len(roots))
+	rootUIDs, rootUIDSet := []types.UID{}, map[types.UID]struct{}{}
+	namespaces, isClusterScoped := []string{}, false
+	for _, root := range roots {
+		api, err := c.ResolveAPIResource(root.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		rootObj, err := c.Get(ctx, root.Name, client.GetOptions{
+			APIResource: *api,
+			Namespace:   root.Namespace,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := rootUIDSet[rootObj.GetUID()]; ok {
+			continue
+		}
+		rootUIDSet[rootObj.GetUID()] = struct{}{}
+		rootUIDs = append(rootUIDs, rootObj.GetUID())
+		rootObjs = append(rootObjs, *rootObj)
+		namespaces = append(namespaces, root.Namespace)
+		isClusterScoped = isClusterScoped || !api.Namespaced
+	}
+
+	// Determine resources to list
+	excludeAPIs := []client.APIResource{}
+	for _, kind := range opts.ExcludeTypes {
+		api, err := c.ResolveAPIResource(kind)
+		if err != nil {
+			return nil, nil, err
+		}
+		excludeAPIs = append(excludeAPIs, *api)
+	}
+	includeAPIs := []client.APIResource{}
+	for _, kind := range opts.IncludeTypes {
+		api, err := c.ResolveAPIResource(kind)
+		if err != nil {
+			return nil, nil, err
+		}
+		includeAPIs = append(includeAPIs, *api)
+	}
+	// Ensure Events are listed when only including specific resource types
+	if opts.Events && len(includeAPIs) > 0 {
+		api, err := c.ResolveAPIResource("events")
+		if err != nil {
+			return nil, nil, err
+		}
+		includeAPIs = append(includeAPIs, *api)
+	}
+
+	// Determine the namespaces to list objects. Cluster-scoped objects can have
+	// relationships with objects in any namespace, so list objects across all
+	// namespaces when any of the requested objects is cluster-scoped, unless
+	// relationships are restricted to the namespaces of the requested objects
+	rootNamespaces := map[string]struct{}{}
+	for _, ns := range namespaces {
+		rootNamespaces[ns] = struct{}{}
+	}
+	if !opts.SameNamespaceOnly {
+		if opts.AllNamespaces || isClusterScoped {
+			namespaces = append(namespaces, "")
+		}
+		namespaces = append(namespaces, opts.Scopes...)
+	}
+
+	// Fetch resources in the cluster
+	var forbiddenFn func(api client.APIResource)
+	if opts.ForbiddenFn != nil {
+		forbiddenFn = func(api client.APIResource) {
+			opts.ForbiddenFn(api.WithGroupString())
+		}
+	}
+	listed := client.ListedResources{}
+	objs, err := c.List(ctx, client.ListOptions{
+		APIResourcesToExclude: excludeAPIs,
+		APIResourcesToInclude: includeAPIs,
+		Namespaces:            namespaces,
+		MaxConcurrency:        opts.MaxConcurrency,
+		ForbiddenFn:           forbiddenFn,
+		ListedFn:              listed.Add,
+		ProgressFn:            opts.ProgressFn,
+	})
+	// Build the relationship tree from the objects listed so far if the
+	// context is done, so that callers can still print a partial tree
+	var truncatedErr error
+	if err != nil {
+		if objs == nil || ctx.Err() == nil {
+			return nil, nil, err
+		}
+		truncatedErr = fmt.Errorf("%w as not every object was listed: %v", ErrTruncated, err)
+	}
+
+	// Include root objects into objects to handle cases where user has access
+	// to get the root objects but unable to list their resource type
+	objs.Items = append(objs.Items, rootObjs...)
+
+	// Infer the owners of objects without owner references
+	if opts.InferOwners {
+		graph.InferOwnerReferences(objs.Items)
+	}
+
+	// Find either all dependencies or dependents of the root objects for each
+	// direction
+	resolveOpts := graph.ResolveOptions{
+		GitOpsTracking: graph.GitOpsTracking{
+			ArgoCDInstanceLabel:      opts.ArgoCDInstanceLabel,
+			ArgoCDNamespace:          opts.ArgoCDNamespace,
+			ArgoCDTrackingAnnotation: opts.ArgoCDTrackingAnnotation,
+		},
+		IsListedFn: listed.Has,
+		MaxNodes:   opts.MaxNodes,
+		MaxDepth:   opts.MaxDepth,
+	}
+	nodeMaps := make([]NodeMap, 0, len(directions))
+	for _, dependencies := range directions {
+		resolveDeps := graph.ResolveDependents
+		if dependencies {
+			resolveDeps = graph.ResolveDependencies
+		}
+		nodeMap, err := resolveDeps(c.GetMapper(), objs.Items, rootUIDs, resolveOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if opts.Events {
+			if err := nodeMap.AttachEvents(c.GetMapper(), objs.Items, dependencies); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// Remove objects in other namespaces, which may still be related to the
+		// root objects (eg. the subjects of RoleBindings)
+		if opts.SameNamespaceOnly {
+			nodeMap.RestrictToNamespaces(rootUIDs, rootNamespaces, dependencies)
+		}
+
+		if len(opts.RelatedClients) > 0 {
+			resolveRelatedClusterObjects(ctx, nodeMap, opts.RelatedClients)
+		}
+		nodeMaps = append(nodeMaps, nodeMap)
+	}
+
+	return nodeMaps, rootUIDs, truncatedErr
+}
+
+// resolveRelatedClusterObjects fetches the objects of the relationship tree that
+// live in other clusters with the client of their cluster, replacing their
+// placeholders so that their ready & status value can be shown. Objects that
+// don't exist are marked as not found, while objects that can't be fetched are
+// kept as placeholders.
+func resolveRelatedClusterObjects(ctx context.Context, nodeMap NodeMap, clients map[string]Client) {
+	for _, node := range nodeMap {
+		if len(node.Cluster) == 0 {
+			continue
+		}
+		c, ok := clients[node.Cluster]
+		if !ok {
+			continue
+		}
+		mapping, err := c.GetMapper().RESTMapping(schema.GroupKind{Group: node.Group, Kind: node.Kind})
+		if err != nil {
+			klog.V(4).Infof("Failed to resolve %s \"%s\" in cluster \"%s\": %s", node.Kind, node.Name, node.Cluster, err)
+			continue
+		}
+		api := client.APIResource{
+			Name:       mapping.Resource.Resource,
+			Namespaced: mapping.Scope.Name() == meta.RESTScopeNameNamespace,
+			Group:      mapping.Resource.Group,
+			Version:    mapping.Resource.Version,
+			Kind:       mapping.GroupVersionKind.Kind,
+		}
+		obj, err := c.Get(ctx, node.Name, client.GetOptions{APIResource: api, Namespace: node.Namespace})
+		switch {
+		case apierrors.IsNotFound(err):
+			node.NotFound = true
+		case err != nil:
+			klog.V(4).Infof("Failed to get %s \"%s\" in cluster \"%s\": %s", node.Kind, node.Name, node.Cluster, err)
+		default:
+			// The resource is left empty since the object can't be referred to
+			// by name in the cluster the relationship tree was built from
+			node.Unstructured = obj
+			node.Version = mapping.Resource.Version
+		}
+	}
+}
+
+// ReadSnapshot reads a relationship tree previously written with
+// NodeMap.WriteSnapshot, returning the relationship tree along with the UIDs of
+// its root objects & whether it contains the dependencies instead of the
+// dependents of the root objects.
+func ReadSnapshot(r io.Reader) (NodeMap, []types.UID, bool, error) {
+	return graph.ReadSnapshot(r)
+}
@@ -0,0 +1,280 @@
+package lineage
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func newTestObject(apiVersion, kind, ns, name string, owners ...metav1.OwnerReference) runtime.Object {
+	u := &unstructuredv1.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(ns)
+	u.SetName(name)
+	u.SetUID(types.UID("uid-" + name))
+	u.SetOwnerReferences(owners)
+	return u
+}
+
+// newTestDeploymentClient returns a client serving a Deployment "default/web"
+// with a ReplicaSet & a Pod as its dependents, along with an unrelated Pod.
+func newTestDeploymentClient() Client {
+	verbs := metav1.Verbs{"get", "list", "watch"}
+	dis := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	dis.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", SingularName: "pod", Namespaced: true, Kind: "Pod", ShortNames: []string{"po"}, Verbs: verbs},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", SingularName: "deployment", Namespaced: true, Kind: "Deployment", ShortNames: []string{"deploy"}, Verbs: verbs},
+				{Name: "replicasets", SingularName: "replicaset", Namespaced: true, Kind: "ReplicaSet", ShortNames: []string{"rs"}, Verbs: verbs},
+			},
+		},
+	}
+	controller := true
+	dyn := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(),
+		newTestObject("apps/v1", "Deployment", "default", "web"),
+		newTestObject("apps/v1", "ReplicaSet", "default", "web-5cc79d4bf5",
+			metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "uid-web", Controller: &controller}),
+		newTestObject("v1", "Pod", "default", "web-5cc79d4bf5-xgvkc",
+			metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5cc79d4bf5", UID: "uid-web-5cc79d4bf5", Controller: &controller}),
+		newTestObject("v1", "Pod", "other", "web-5cc79d4bf5-rjc7d"),
+	)
+	return NewClientForInterfaces(dyn, dis)
+}
+
+func TestBuildGraphFakeClient(t *testing.T) {
+	t.Parallel()
+
+	c := newTestDeploymentClient()
+	nodeMap, rootUID, err := BuildGraph(context.Background(), c, ObjectRef{Type: "deploy", Namespace: "default", Name: "web"}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rootUID != "uid-web" {
+		t.Fatalf("expected root \"uid-web\" got \"%s\"", rootUID)
+	}
+	uids := []string{}
+	for uid := range nodeMap {
+		uids = append(uids, string(uid))
+	}
+	sort.Strings(uids)
+	if expected := []string{"uid-web", "uid-web-5cc79d4bf5", "uid-web-5cc79d4bf5-xgvkc"}; !reflect.DeepEqual(uids, expected) {
+		t.Fatalf("expected \"%v\" got \"%v\"", expected, uids)
+	}
+}
+
+func TestBuildGraphsForObjects(t *testing.T) {
+	t.Parallel()
+
+	c := newTestDeploymentClient()
+	roots := []ObjectRef{
+		{Type: "rs", Namespace: "default", Name: "web-5cc79d4bf5"},
+		{Type: "pods", Namespace: "default", Name: "web-5cc79d4bf5-xgvkc"},
+	}
+	dependents, dependencies, rootUIDs, err := BuildGraphsForObjects(context.Background(), c, roots, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []types.UID{"uid-web-5cc79d4bf5", "uid-web-5cc79d4bf5-xgvkc"}; !reflect.DeepEqual(rootUIDs, expected) {
+		t.Fatalf("expected roots \"%v\" got \"%v\"", expected, rootUIDs)
+	}
+	tests := []struct {
+		name     string
+		nodeMap  NodeMap
+		expected []string
+	}{
+		{"dependents", dependents, []string{"uid-web-5cc79d4bf5", "uid-web-5cc79d4bf5-xgvkc"}},
+		{"dependencies", dependencies, []string{"uid-web", "uid-web-5cc79d4bf5", "uid-web-5cc79d4bf5-xgvkc"}},
+	}
+	for _, tt := range tests {
+		uids := []string{}
+		for uid := range tt.nodeMap {
+			uids = append(uids, string(uid))
+		}
+		sort.Strings(uids)
+		if !reflect.DeepEqual(uids, tt.expected) {
+			t.Fatalf("%s: expected \"%v\" got \"%v\"", tt.name, tt.expected, uids)
+		}
+	}
+}
+
+func TestBuildGraphMaxNodes(t *testing.T) {
+	t.Parallel()
+
+	c := newTestDeploymentClient()
+	ref := ObjectRef{Type: "deploy", Namespace: "default", Name: "web"}
+	tests := []struct {
+		name        string
+		maxNodes    uint
+		maxDepth    uint
+		expectedErr bool
+	}{
+		{"no limit", 0, UnlimitedDepth, false},
+		{"within limit", 3, UnlimitedDepth, false},
+		{"exceeding limit", 2, UnlimitedDepth, true},
+		{"exceeding limit beyond max depth", 2, 1, false},
+		{"exceeding limit within max depth", 1, 1, true},
+	}
+	for _, tt := range tests {
+		nodeMap, _, err := BuildGraph(context.Background(), c, ref, Options{MaxNodes: tt.maxNodes, MaxDepth: tt.maxDepth})
+		if tt.expectedErr {
+			if !errors.Is(err, ErrTooManyNodes) {
+				t.Fatalf("%s: expected error wrapping ErrTooManyNodes got \"%v\"", tt.name, err)
+			}
+			if nodeMap != nil {
+				t.Fatalf("%s: expected no relationship tree got %d objects", tt.name, len(nodeMap))
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if len(nodeMap) != 3 {
+			t.Fatalf("%s: expected 3 objects got %d", tt.name, len(nodeMap))
+		}
+	}
+}
+
+func TestBuildGraphNotFoundOwner(t *testing.T) {
+	t.Parallel()
+
+	verbs := metav1.Verbs{"get", "list", "watch"}
+	newClient := func(forbidden bool) Client {
+		dis := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+		dis.Resources = []*metav1.APIResourceList{
+			{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{
+					{Name: "pods", SingularName: "pod", Namespaced: true, Kind: "Pod", ShortNames: []string{"po"}, Verbs: verbs},
+				},
+			},
+			{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "replicasets", SingularName: "replicaset", Namespaced: true, Kind: "ReplicaSet", ShortNames: []string{"rs"}, Verbs: verbs},
+				},
+			},
+		}
+		controller := true
+		dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			{Version: "v1", Resource: "pods"}:                       "PodList",
+			{Group: "apps", Version: "v1", Resource: "replicasets"}: "ReplicaSetList",
+		}, newTestObject("v1", "Pod", "default", "web-5cc79d4bf5-xgvkc",
+			metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5cc79d4bf5", UID: "uid-web-5cc79d4bf5", Controller: &controller}))
+		if forbidden {
+			dyn.PrependReactor("list", "replicasets", func(clienttesting.Action) (bool, runtime.Object, error) {
+				return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "replicasets"}, "", nil)
+			})
+		}
+		return NewClientForInterfaces(dyn, dis)
+	}
+
+	tests := []struct {
+		name             string
+		forbidden        bool
+		excludeTypes     []string
+		expectedNotFound bool
+	}{
+		{"owner type listed", false, nil, true},
+		{"owner type excluded", false, []string{"rs"}, false},
+		{"owner type forbidden", true, nil, false},
+	}
+	for _, tt := range tests {
+		ref := ObjectRef{Type: "pods", Namespace: "default", Name: "web-5cc79d4bf5-xgvkc"}
+		nodeMap, _, err := BuildGraph(context.Background(), newClient(tt.forbidden), ref, Options{Dependencies: true, ExcludeTypes: tt.excludeTypes})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		node, ok := nodeMap["uid-web-5cc79d4bf5"]
+		if ok != tt.expectedNotFound || (ok && !node.NotFound) {
+			t.Fatalf("%s: expected not found owner %t got %d objects", tt.name, tt.expectedNotFound, len(nodeMap))
+		}
+	}
+}
+
+func TestBuildGraphRelatedClients(t *testing.T) {
+	t.Parallel()
+
+	verbs := metav1.Verbs{"get", "list", "watch"}
+	newClient := func(resources []*metav1.APIResourceList, objects ...runtime.Object) Client {
+		dis := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+		dis.Resources = resources
+		return NewClientForInterfaces(fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), objects...), dis)
+	}
+	machine := func(name, nodeName string) runtime.Object {
+		u := newTestObject("cluster.x-k8s.io/v1beta1", "Machine", "default", name).(*unstructuredv1.Unstructured)
+		u.Object["spec"] = map[string]interface{}{"clusterName": "workload-1"}
+		u.Object["status"] = map[string]interface{}{
+			"nodeRef": map[string]interface{}{"apiVersion": "v1", "kind": "Node", "name": nodeName},
+		}
+		return u
+	}
+	management := newClient([]*metav1.APIResourceList{
+		{
+			GroupVersion: "cluster.x-k8s.io/v1beta1",
+			APIResources: []metav1.APIResource{
+				{Name: "machines", SingularName: "machine", Namespaced: true, Kind: "Machine", ShortNames: []string{"ma"}, Verbs: verbs},
+			},
+		},
+	}, machine("md-0-abc", "worker-1"), machine("md-0-def", "worker-2"))
+	workload := newClient([]*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "nodes", SingularName: "node", Namespaced: false, Kind: "Node", ShortNames: []string{"no"}, Verbs: verbs},
+			},
+		},
+	}, newTestObject("v1", "Node", "", "worker-1"))
+
+	tests := []struct {
+		name             string
+		machine          string
+		relatedClients   map[string]Client
+		expectedNotFound bool
+		expectedResolved bool
+	}{
+		{"node without related client", "md-0-abc", nil, false, false},
+		{"node found in related client", "md-0-abc", map[string]Client{"workload-1": workload}, false, true},
+		{"node not found in related client", "md-0-def", map[string]Client{"workload-1": workload}, true, false},
+	}
+	for _, tt := range tests {
+		nodeMap, _, err := BuildGraph(context.Background(), management, ObjectRef{Type: "machines", Namespace: "default", Name: tt.machine}, Options{RelatedClients: tt.relatedClients})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		var node *Node
+		for _, n := range nodeMap {
+			if n.Cluster == "workload-1" {
+				node = n
+			}
+		}
+		if node == nil || len(nodeMap) != 2 {
+			t.Fatalf("%s: expected the Machine & a Node of cluster \"workload-1\" got %d objects", tt.name, len(nodeMap))
+		}
+		// Resolved objects keep the UID of their placeholder in the relationship
+		// tree, but their content is fetched from the related cluster
+		resolved := node.GetUID() == "uid-worker-1"
+		if node.NotFound != tt.expectedNotFound || resolved != tt.expectedResolved {
+			t.Fatalf("%s: expected not found %t & resolved %t got %t & %t", tt.name, tt.expectedNotFound, tt.expectedResolved, node.NotFound, resolved)
+		}
+	}
+}